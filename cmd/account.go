@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/wallet"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+)
+
+// accountCmd groups the keystore management commands
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Manage encrypted keystore accounts",
+	Long: `Manage encrypted keystore accounts in KEYSTORE_DIR (default DATA_DIR/keystore).
+
+Passphrases are read from KEYSTORE_PASSWORD_FILE, KEYSTORE_PASSWORD or an
+interactive prompt. Point KEYSTORE_FILE at an account file to use it for
+signing instead of WALLET_PRIVATE_KEY.`,
+}
+
+var accountNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Create a new account in the keystore",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadAccountConfig()
+
+		passphrase, err := wallet.Passphrase(cfg, "Passphrase for the new account: ", true)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		account, err := wallet.OpenKeyStore(cfg).NewAccount(passphrase)
+		if err != nil {
+			fmt.Printf("Error creating account: %v\n", err)
+			os.Exit(1)
+		}
+
+		printAccountCreated(account.Address.Hex(), account.URL.Path)
+	},
+}
+
+var accountImportCmd = &cobra.Command{
+	Use:   "import [key-file]",
+	Short: "Import a hex private key into the keystore",
+	Long: `Import a hex private key into the keystore.
+
+The key is read from the first line of key-file or, if no file is given,
+from WALLET_PRIVATE_KEY. Remove the plaintext key once it is imported.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadAccountConfig()
+
+		var privateKey *ecdsa.PrivateKey
+		var err error
+		switch {
+		case len(args) == 1:
+			privateKey, err = wallet.ReadHexKey(args[0])
+		case cfg.WalletPrivateKey != "":
+			privateKey, err = crypto.HexToECDSA(cfg.WalletPrivateKey)
+		default:
+			err = errors.New("pass a key file or set WALLET_PRIVATE_KEY")
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		passphrase, err := wallet.Passphrase(cfg, "Passphrase to encrypt the key with: ", true)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		account, err := wallet.OpenKeyStore(cfg).ImportECDSA(privateKey, passphrase)
+		if err != nil {
+			fmt.Printf("Error importing key: %v\n", err)
+			os.Exit(1)
+		}
+
+		printAccountCreated(account.Address.Hex(), account.URL.Path)
+	},
+}
+
+var accountListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the accounts in the keystore",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadAccountConfig()
+
+		accounts := wallet.OpenKeyStore(cfg).Accounts()
+		if len(accounts) == 0 {
+			fmt.Printf("No accounts in %s\n", cfg.KeystoreDir)
+			return
+		}
+
+		// Keystore paths are absolute, KEYSTORE_FILE may be relative
+		keystoreFile := cfg.KeystoreFile
+		if keystoreFile != "" {
+			if abs, err := filepath.Abs(keystoreFile); err == nil {
+				keystoreFile = abs
+			}
+		}
+
+		for i, account := range accounts {
+			marker := " "
+			if account.URL.Path == keystoreFile {
+				marker = "*"
+			}
+			fmt.Printf("%s #%d: %s %s\n", marker, i, account.Address.Hex(), account.URL.Path)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(accountCmd)
+	accountCmd.AddCommand(accountNewCmd)
+	accountCmd.AddCommand(accountImportCmd)
+	accountCmd.AddCommand(accountListCmd)
+}
+
+// loadAccountConfig loads the configuration without requiring a signing key,
+// since these commands are how one is set up
+func loadAccountConfig() *config.Config {
+	opts := configOptions()
+	opts.AccountOnly = true
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	return cfg
+}
+
+// printAccountCreated tells the operator how to start using a new keystore file
+func printAccountCreated(address, path string) {
+	fmt.Printf("Address: %s\n", address)
+	fmt.Printf("Keystore file: %s\n", path)
+	fmt.Println("\nTo sign with this account, set KEYSTORE_FILE to the keystore file and remove WALLET_PRIVATE_KEY.")
+}
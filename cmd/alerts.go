@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/alerts"
+	"github.com/spf13/cobra"
+)
+
+// alertsCmd groups the alert commands
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Manage alert notifications",
+	Long: `Manage the alerts a running node posts to ALERT_WEBHOOK_URL.
+
+The node alerts when a transaction reverts (tx_reverted), the ETH balance drops
+below ALERT_MIN_BALANCE_ETH (low_balance), it falls more than ALERT_MAX_HEAD_LAG
+blocks behind the chain head (head_lag) or it is no longer registered with the
+protocol contract (deregistered). ALERT_RULES selects the rules to apply.`,
+}
+
+var alertsTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a test alert to the configured webhook",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Only the alert settings are needed
+		opts := configOptions()
+		opts.KeyOptional = true
+		cfg, err := loadConfigWith(opts)
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if cfg.AlertWebhookURL == "" {
+			fmt.Println("Error: ALERT_WEBHOOK_URL is not set")
+			os.Exit(1)
+		}
+
+		// The node's account is not unlocked for a test, so name the host instead
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown host"
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		webhook := alerts.NewWebhook(cfg.AlertWebhookURL, cfg.AlertWebhookFormat, int(cfg.AlertRetries))
+		if err := webhook.Send(ctx, alerts.NewTestAlert(host)); err != nil {
+			fmt.Printf("Error sending test alert: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Test alert delivered in %s format\n", cfg.AlertWebhookFormat)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(alertsCmd)
+	alertsCmd.AddCommand(alertsTestCmd)
+}
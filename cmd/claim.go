@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/offline"
 	"github.com/dexponent/geth-validator/internal/validator"
 	"github.com/spf13/cobra"
 )
@@ -15,13 +16,52 @@ var claimCmd = &cobra.Command{
 	Short: "Claim accumulated rewards for the validator",
 	Long:  `Claim accumulated rewards for the validator from successful verifications.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load configuration
-		cfg, err := config.LoadConfig()
+		// Nothing can be claimed, signed or unsigned, until the contract
+		// has a claim method
+		if !contracts.ClaimSupported {
+			fmt.Printf("Error claiming rewards: %v\n", contracts.ErrClaimUnsupported)
+			os.Exit(1)
+		}
+
+		unsignedOut, _ := cmd.Flags().GetString("unsigned-out")
+		format, _ := cmd.Flags().GetString("format")
+
+		// An unsigned claim is signed offline with the reward address key,
+		// so no operator key is needed to build it
+		opts := configOptions()
+		opts.KeyOptional = unsignedOut != ""
+		cfg, err := loadConfigWith(opts)
 		if err != nil {
 			fmt.Printf("Error loading configuration: %v\n", err)
 			os.Exit(1)
 		}
 
+		// Write the claim for offline signing with the reward address key
+		if unsignedOut != "" {
+			tx, from, err := validator.BuildUnsignedClaim(cfg)
+			if err != nil {
+				fmt.Printf("Error building claim transaction: %v\n", err)
+				os.Exit(1)
+			}
+
+			// The description only states what the transaction does; the
+			// amount claimed is whatever the contract holds when it is mined
+			err = offline.WriteUnsigned(unsignedOut, format, &offline.UnsignedTx{
+				ChainID:     cfg.ChainID,
+				From:        from,
+				Description: fmt.Sprintf("Claim the pending DXP rewards of %s", from.Hex()),
+				Tx:          tx,
+			})
+			if err != nil {
+				fmt.Printf("Error writing claim transaction: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Unsigned claim from %s written to %s\n", from.Hex(), unsignedOut)
+			fmt.Printf("Sign it offline with the reward address key ('tx sign %s'), then send it with 'tx broadcast'.\n", unsignedOut)
+			return
+		}
+
 		// Check pending rewards first
 		rewards, err := validator.GetValidatorRewards(cfg)
 		if err != nil {
@@ -47,3 +87,8 @@ var claimCmd = &cobra.Command{
 		fmt.Println("Rewards claimed successfully!")
 	},
 }
+
+func init() {
+	claimCmd.Flags().String("unsigned-out", "", "Write an unsigned claim from REWARD_ADDRESS to this file for offline signing instead of sending it")
+	claimCmd.Flags().String("format", offline.FormatJSON, "Format of the unsigned transaction file (json or rlp)")
+}
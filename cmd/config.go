@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configCmd groups the configuration commands
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the validator configuration",
+	Long: `Inspect the configuration the validator would run with.
+
+Settings are resolved with the precedence command line flags, environment
+variables (including .env), the --config file and then defaults.`,
+}
+
+// configShowCmd prints the effective configuration
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration with secrets redacted",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Load configuration
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			fmt.Printf("Error formatting configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Print(string(out))
+	},
+}
+
+// configValidateCmd checks the configuration, for use in CI and deploy scripts
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration and report every problem found",
+	Long: `Check the configuration and report every problem found.
+
+Exits with status 1 if the configuration is invalid. With --check-rpc the RPC
+endpoint is also contacted to confirm it serves the configured chain.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRPC, _ := cmd.Flags().GetBool("check-rpc")
+
+		// Load configuration, which runs the validation
+		cfg, err := loadConfig()
+		if err != nil {
+			var validationErr *config.ValidationError
+			if errors.As(err, &validationErr) {
+				fmt.Printf("Configuration is invalid, %d problem(s) found:\n", len(validationErr.Problems))
+				for _, problem := range validationErr.Problems {
+					fmt.Printf("  - %s\n", problem)
+				}
+			} else {
+				fmt.Printf("Error loading configuration: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		if checkRPC {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			client, err := ethclient.DialContext(ctx, cfg.BaseRPCURL)
+			if err != nil {
+				fmt.Printf("Error connecting to RPC endpoint: %v\n", err)
+				os.Exit(1)
+			}
+			defer client.Close()
+
+			if err := cfg.VerifyChainID(ctx, client); err != nil {
+				fmt.Printf("Error checking RPC endpoint: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Printf("Configuration is valid (network %s, chain ID %d)\n", cfg.Network, cfg.ChainID)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+
+	configValidateCmd.Flags().Bool("check-rpc", false, "Also check that the RPC endpoint serves the configured chain")
+}
@@ -2,18 +2,20 @@ package cmd
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"log"
 	"math/big"
-	"os"
 	"strings"
 
+	"github.com/dexponent/geth-validator/internal/config"
 	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/gas"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/dexponent/geth-validator/internal/signer"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
 )
@@ -22,14 +24,13 @@ var (
 	farmID          int64
 	performanceScore int64
 	approvalAmount   int64
-	dxpTokenAddress string = "0x4ed4E862860beD51a9570b96d89aF5E1B0Efefed" // Replace with actual DXP token address
 )
 
 // Contract commands
 var contractCmd = &cobra.Command{
 	Use:   "contract",
 	Short: "Interact with the Dexponent Protocol contract",
-	Long:  "Commands for interacting with the Dexponent Protocol contract on Sepolia testnet",
+	Long:  "Commands for interacting with the Dexponent Protocol contract on the configured network",
 }
 
 var checkCmd = &cobra.Command{
@@ -89,29 +90,40 @@ func init() {
 	approveCmd.Flags().Int64VarP(&approvalAmount, "amount", "a", 1000, "Amount of DXP tokens to approve (in tokens, not wei)")
 }
 
-// getClient establishes a connection to the Ethereum client
-func getClient() (*ethclient.Client, error) {
-	rpcURL := os.Getenv("BASE_RPC_URL")
-	if rpcURL == "" {
-		return nil, fmt.Errorf("BASE_RPC_URL not set in .env file")
+// loadContractConfig loads the configuration used by the contract commands
+func loadContractConfig() *config.Config {
+	// With --offline --from the key lives on another machine
+	opts := configOptions()
+	opts.KeyOptional = isOffline() && offlineFrom != ""
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
 	}
+	logging.SetLevel(cfg.LogLevel)
+
+	return cfg
+}
 
-	client, err := ethclient.Dial(rpcURL)
+// getClient establishes a connection to the Ethereum client and checks that it
+// serves the configured chain
+func getClient(cfg *config.Config) (*ethclient.Client, error) {
+	client, err := ethclient.Dial(cfg.BaseRPCURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to the Ethereum client: %v", err)
 	}
 
+	if err := cfg.VerifyChainID(context.Background(), client); err != nil {
+		client.Close()
+		return nil, err
+	}
+
 	return client, nil
 }
 
 // getContract creates an instance of the Dexponent contract
-func getContract(client *ethclient.Client) (*contracts.DexponentContractWrapper, error) {
-	contractAddr := os.Getenv("DXP_CONTRACT_ADDRESS")
-	if contractAddr == "" {
-		return nil, fmt.Errorf("DXP_CONTRACT_ADDRESS not set in .env file")
-	}
-
-	contractAddress := common.HexToAddress(contractAddr)
+func getContract(client *ethclient.Client, cfg *config.Config) (*contracts.DexponentContractWrapper, error) {
+	contractAddress := common.HexToAddress(cfg.DXPContractAddress)
 	contract, err := contracts.NewDexponentContractWrapper(contractAddress, client)
 	if err != nil {
 		return nil, fmt.Errorf("failed to instantiate contract: %v", err)
@@ -120,56 +132,86 @@ func getContract(client *ethclient.Client) (*contracts.DexponentContractWrapper,
 	return contract, nil
 }
 
-// getAccount retrieves the account from the private key
-func getAccount() (*ecdsa.PrivateKey, common.Address, error) {
-	privateKeyStr := os.Getenv("WALLET_PRIVATE_KEY")
-	if privateKeyStr == "" {
-		return nil, common.Address{}, fmt.Errorf("WALLET_PRIVATE_KEY not set in .env file")
+// getTokenAddress returns the DXP token address of the configured network
+func getTokenAddress(cfg *config.Config) (common.Address, error) {
+	if cfg.DXPTokenAddress == "" {
+		return common.Address{}, fmt.Errorf("no DXP token address for network %s, set DXP_TOKEN_ADDRESS", cfg.Network)
+	}
+
+	return common.HexToAddress(cfg.DXPTokenAddress), nil
+}
+
+// getAccount creates the configured signer, backed by the keystore, the
+// configured private key or an external signer. With --offline the signer
+// leaves transactions unsigned.
+func getAccount(cfg *config.Config) (signer.Signer, common.Address, error) {
+	if isOffline() && offlineFrom != "" {
+		if !common.IsHexAddress(offlineFrom) {
+			return nil, common.Address{}, fmt.Errorf("--from is not a valid address: %q", offlineFrom)
+		}
+		from := common.HexToAddress(offlineFrom)
+		return signer.NewUnsigned(from), from, nil
 	}
 
-	// Parse private key
-	privateKey, err := crypto.HexToECDSA(privateKeyStr)
+	txSigner, err := signer.New(cfg)
 	if err != nil {
-		return nil, common.Address{}, fmt.Errorf("invalid private key: %v", err)
+		return nil, common.Address{}, err
 	}
 
-	// Get account address from private key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, common.Address{}, fmt.Errorf("error casting public key to ECDSA")
+	if isOffline() {
+		return signer.NewUnsigned(txSigner.Address()), txSigner.Address(), nil
 	}
 
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
-	return privateKey, address, nil
+	return txSigner, txSigner.Address(), nil
 }
 
 // getAuthOptions creates transaction options for contract interactions
-func getAuthOptions(client *ethclient.Client, privateKey *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
-	chainIDStr := os.Getenv("CHAIN_ID")
-	if chainIDStr == "" {
-		return nil, fmt.Errorf("CHAIN_ID not set in .env file")
+func getAuthOptions(client *ethclient.Client, cfg *config.Config, txSigner signer.Signer) (*bind.TransactOpts, error) {
+	// Create transaction options for the configured chain
+	auth := signer.TransactOpts(context.Background(), txSigner, big.NewInt(cfg.ChainID))
+
+	// Set fees and gas limit
+	fees, err := gas.Suggest(context.Background(), client, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price transaction: %v", err)
 	}
+	fees.Apply(auth)
+
+	// Build with a placeholder gas limit, unsigned through gas.Unsigned;
+	// sendTransaction simulates the transaction, replaces the limit with an
+	// estimate and only then signs it
+	auth.GasLimit = cfg.GasLimit
+	auth.NoSend = true
 
-	// Default to Sepolia chain ID
-	chainID := big.NewInt(11155111)
+	fmt.Printf("Transaction fees: %s\n", fees)
 
-	// Create transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	return auth, nil
+}
+
+// sendTransaction checks a transaction built with getAuthOptions against the
+// latest block, sizes its gas limit from an estimate and broadcasts it
+func sendTransaction(client *ethclient.Client, cfg *config.Config, auth *bind.TransactOpts, tx *types.Transaction) (*types.Transaction, error) {
+	ctx := context.Background()
+	tx, err := gas.Prepare(ctx, client, cfg, auth, tx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transaction options: %v", err)
+		return nil, err
 	}
 
-	// Set gas price and limit
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+	fmt.Printf("Gas limit: %d\n", tx.Gas())
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %v", err)
 	}
 
-	auth.GasPrice = gasPrice
-	auth.GasLimit = 3000000
+	return tx, nil
+}
 
-	return auth, nil
+// printExplorerLink points the user at the transaction on the network's block explorer
+func printExplorerLink(cfg *config.Config, txHash string) {
+	if url := cfg.ExplorerTxURL(txHash); url != "" {
+		fmt.Printf("Check the transaction status on the block explorer: %s\n", url)
+	} else {
+		fmt.Println("Check the transaction status on the block explorer")
+	}
 }
 
 // formatEther converts wei to ether
@@ -183,20 +225,22 @@ func formatEther(wei *big.Int) string {
 
 // checkRegistration checks if the account is registered as a verifier
 func checkRegistration() {
+	cfg := loadContractConfig()
+
 	// Connect to client
-	client, err := getClient()
+	client, err := getClient(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get contract
-	contract, err := getContract(client)
+	contract, err := getContract(client, cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get account
-	_, address, err := getAccount()
+	_, address, err := getAccount(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
@@ -225,15 +269,12 @@ func checkRegistration() {
 }
 
 // checkDXPRequirements checks if the address has enough DXP tokens and has approved the contract
-func checkDXPRequirements(client *ethclient.Client, address common.Address) (bool, error) {
-	// Get DXP contract address
-	contractAddr := os.Getenv("DXP_CONTRACT_ADDRESS")
-	if contractAddr == "" {
-		return false, fmt.Errorf("DXP_CONTRACT_ADDRESS not set in .env file")
-	}
-
+func checkDXPRequirements(client *ethclient.Client, cfg *config.Config, address common.Address) (bool, error) {
 	// Create DXP token contract instance
-	tokenAddress := common.HexToAddress(dxpTokenAddress)
+	tokenAddress, err := getTokenAddress(cfg)
+	if err != nil {
+		return false, err
+	}
 	tokenABI := `[{"constant":true,"inputs":[{"name":"_owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"balance","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[{"name":"_owner","type":"address"},{"name":"_spender","type":"address"}],"name":"allowance","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"}]`
 	parsedABI, err := abi.JSON(strings.NewReader(tokenABI))
 	if err != nil {
@@ -252,7 +293,7 @@ func checkDXPRequirements(client *ethclient.Client, address common.Address) (boo
 	// Check allowance
 	var allowance *big.Int
 	allowanceResult := []interface{}{&allowance}
-	contractAddress := common.HexToAddress(contractAddr)
+	contractAddress := common.HexToAddress(cfg.DXPContractAddress)
 	err = tokenContract.Call(&bind.CallOpts{}, &allowanceResult, "allowance", address, contractAddress)
 	if err != nil {
 		return false, fmt.Errorf("failed to get allowance: %v", err)
@@ -271,20 +312,22 @@ func checkDXPRequirements(client *ethclient.Client, address common.Address) (boo
 
 // registerVerifier registers the account as a verifier
 func registerVerifier() {
+	cfg := loadContractConfig()
+
 	// Connect to client
-	client, err := getClient()
+	client, err := getClient(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get contract
-	contract, err := getContract(client)
+	contract, err := getContract(client, cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get account
-	privateKey, address, err := getAccount()
+	txSigner, address, err := getAccount(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
@@ -303,7 +346,7 @@ func registerVerifier() {
 	}
 
 	// Check DXP token balance and approval
-	hasRequirements, err := checkDXPRequirements(client, address)
+	hasRequirements, err := checkDXPRequirements(client, cfg, address)
 	if err != nil {
 		fmt.Printf("Warning: Could not check DXP requirements: %v\n", err)
 	} else if !hasRequirements {
@@ -313,7 +356,7 @@ func registerVerifier() {
 	}
 
 	// Get auth options
-	auth, err := getAuthOptions(client, privateKey)
+	auth, err := getAuthOptions(client, cfg, txSigner)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
@@ -321,39 +364,47 @@ func registerVerifier() {
 	fmt.Println("Note: Registration requires DXP tokens for staking. Make sure your account has approved the contract to spend DXP tokens.")
 	fmt.Println("Attempting to register as verifier...")
 
-	tx, err := contract.RegisterValidator(auth)
+	tx, err := contract.RegisterValidator(gas.Unsigned(auth))
+	if err == nil && isOffline() {
+		if err := writeUnsignedTx(client, cfg, auth, tx, "Register as a verifier"); err != nil {
+			log.Fatalf("Failed to prepare registration: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		tx, err = sendTransaction(client, cfg, auth, tx)
+	}
 	if err != nil {
 		log.Fatalf("Failed to register as verifier: %v", err)
 	}
 
 	fmt.Printf("Transaction sent: %s\n", tx.Hash().Hex())
-	fmt.Println("Check the transaction status on Sepolia block explorer")
+	printExplorerLink(cfg, tx.Hash().Hex())
 }
 
 // checkDXPTokens checks DXP token balance and approval status
 func checkDXPTokens() {
+	cfg := loadContractConfig()
+
 	// Connect to client
-	client, err := getClient()
+	client, err := getClient(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get account
-	_, address, err := getAccount()
+	_, address, err := getAccount(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	fmt.Printf("Account address: %s\n", address.Hex())
 
-	// Get DXP contract address
-	contractAddr := os.Getenv("DXP_CONTRACT_ADDRESS")
-	if contractAddr == "" {
-		log.Fatalf("DXP_CONTRACT_ADDRESS not set in .env file")
-	}
-
 	// Create DXP token contract instance
-	tokenAddress := common.HexToAddress(dxpTokenAddress)
+	tokenAddress, err := getTokenAddress(cfg)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
 	tokenABI := `[{"constant":true,"inputs":[{"name":"_owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"balance","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[{"name":"_owner","type":"address"},{"name":"_spender","type":"address"}],"name":"allowance","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"}]`
 	parsedABI, err := abi.JSON(strings.NewReader(tokenABI))
 	if err != nil {
@@ -374,7 +425,7 @@ func checkDXPTokens() {
 	// Check allowance
 	var allowance *big.Int
 	allowanceResult := []interface{}{&allowance}
-	contractAddress := common.HexToAddress(contractAddr)
+	contractAddress := common.HexToAddress(cfg.DXPContractAddress)
 	err = tokenContract.Call(&bind.CallOpts{}, &allowanceResult, "allowance", address, contractAddress)
 	if err != nil {
 		fmt.Printf("Failed to get allowance: %v\n", err)
@@ -417,30 +468,27 @@ func checkDXPTokens() {
 
 // approveDXPTokens approves the contract to spend DXP tokens
 func approveDXPTokens() {
+	cfg := loadContractConfig()
+
 	// Connect to client
-	client, err := getClient()
+	client, err := getClient(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get account
-	privateKey, address, err := getAccount()
+	txSigner, address, err := getAccount(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	fmt.Printf("Account address: %s\n", address.Hex())
 
-	// Get DXP token address from the contract
-	contractAddr := os.Getenv("DXP_CONTRACT_ADDRESS")
-	if contractAddr == "" {
-		log.Fatalf("DXP_CONTRACT_ADDRESS not set in .env file")
-	}
-
-	// Use the global dxpTokenAddress variable
-
 	// Create DXP token contract instance
-	tokenAddress := common.HexToAddress(dxpTokenAddress)
+	tokenAddress, err := getTokenAddress(cfg)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
 	tokenABI := `[{"inputs":[{"internalType":"address","name":"spender","type":"address"},{"internalType":"uint256","name":"amount","type":"uint256"}],"name":"approve","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"}]`
 	parsedABI, err := abi.JSON(strings.NewReader(tokenABI))
 	if err != nil {
@@ -449,42 +497,53 @@ func approveDXPTokens() {
 	tokenContract := bind.NewBoundContract(tokenAddress, parsedABI, client, client, client)
 
 	// Get auth options
-	auth, err := getAuthOptions(client, privateKey)
+	auth, err := getAuthOptions(client, cfg, txSigner)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Convert approval amount to wei (assuming 18 decimals)
 	amount := new(big.Int).Mul(big.NewInt(approvalAmount), big.NewInt(1000000000000000000))
-	fmt.Printf("Approving %d DXP tokens for contract %s...\n", approvalAmount, contractAddr)
+	fmt.Printf("Approving %d DXP tokens for contract %s...\n", approvalAmount, cfg.DXPContractAddress)
 
 	// Call approve function on the token contract
-	contractAddress := common.HexToAddress(contractAddr)
-	tx, err := tokenContract.Transact(auth, "approve", contractAddress, amount)
+	contractAddress := common.HexToAddress(cfg.DXPContractAddress)
+	tx, err := tokenContract.Transact(gas.Unsigned(auth), "approve", contractAddress, amount)
+	if err == nil && isOffline() {
+		if err := writeUnsignedTx(client, cfg, auth, tx, fmt.Sprintf("Approve %d DXP for contract %s", approvalAmount, contractAddress.Hex())); err != nil {
+			log.Fatalf("Failed to prepare approval: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		tx, err = sendTransaction(client, cfg, auth, tx)
+	}
 	if err != nil {
 		log.Fatalf("Failed to approve tokens: %v", err)
 	}
 
 	fmt.Printf("Transaction sent: %s\n", tx.Hash().Hex())
-	fmt.Println("Check the transaction status on Sepolia block explorer")
+	printExplorerLink(cfg, tx.Hash().Hex())
 }
 
 // submitProof submits a proof to the contract
 func submitProof() {
+	cfg := loadContractConfig()
+
 	// Connect to client
-	client, err := getClient()
+	client, err := getClient(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get contract
-	contract, err := getContract(client)
+	contract, err := getContract(client, cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 
 	// Get account
-	privateKey, address, err := getAccount()
+	txSigner, address, err := getAccount(cfg)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
@@ -503,7 +562,7 @@ func submitProof() {
 	}
 
 	// Get auth options
-	auth, err := getAuthOptions(client, privateKey)
+	auth, err := getAuthOptions(client, cfg, txSigner)
 	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
@@ -512,11 +571,20 @@ func submitProof() {
 
 	// For the wrapper, we need to use SubmitVerificationResult
 	// The wrapper will convert this to a submitProof call
-	tx, err := contract.SubmitVerificationResult(auth, big.NewInt(farmID), []byte{}, []byte{})
+	tx, err := contract.SubmitVerificationResult(gas.Unsigned(auth), big.NewInt(farmID), []byte{}, []byte{})
+	if err == nil && isOffline() {
+		if err := writeUnsignedTx(client, cfg, auth, tx, fmt.Sprintf("Submit proof for farm %d with score %d", farmID, performanceScore)); err != nil {
+			log.Fatalf("Failed to prepare proof submission: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		tx, err = sendTransaction(client, cfg, auth, tx)
+	}
 	if err != nil {
 		log.Fatalf("Failed to submit proof: %v", err)
 	}
 
 	fmt.Printf("Transaction sent: %s\n", tx.Hash().Hex())
-	fmt.Println("Check the transaction status on Sepolia block explorer")
+	printExplorerLink(cfg, tx.Hash().Hex())
 }
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/dexponent/geth-validator/internal/ui"
+	"github.com/dexponent/geth-validator/internal/validator"
+)
+
+// dashboardRefreshInterval is how often the dashboard metrics are refreshed
+const dashboardRefreshInterval = 2 * time.Second
+
+// runDashboard shows the console dashboard for a running node, with the
+// request log fed by the node's events, until the returned function is called
+func runDashboard(ctx context.Context, node *validator.Validator) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	dashboard := ui.NewConsoleUI()
+
+	// Subscribe before starting so no early event is missed
+	activity, unsubscribe := node.Events().Subscribe(100)
+
+	updateDashboardMetrics(ctx, dashboard, node)
+	dashboard.Start()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer unsubscribe()
+
+		ticker := time.NewTicker(dashboardRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-activity:
+				addDashboardLog(dashboard, event)
+			case <-ticker.C:
+				updateDashboardMetrics(ctx, dashboard, node)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		dashboard.Stop()
+	}
+}
+
+// updateDashboardMetrics refreshes the metrics table from the node
+func updateDashboardMetrics(ctx context.Context, dashboard *ui.ConsoleUI, node *validator.Validator) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	metrics, err := node.DashboardMetrics(ctx)
+	if err != nil {
+		logging.Logger().Warn("Failed to refresh dashboard metrics", "err", err)
+		return
+	}
+
+	dashboard.UpdateMetrics(metrics)
+}
+
+// addDashboardLog adds a request log entry for an event about a request
+func addDashboardLog(dashboard *ui.ConsoleUI, event events.Event) {
+	header := event.EventHeader()
+	if header.RequestID == "" {
+		return
+	}
+
+	switch event.(type) {
+	case *events.TxReverted:
+		dashboard.AddRevert(header.RequestID, header.TxHash, event.Message())
+	case *events.TxMined:
+		dashboard.AddLog(header.RequestID, "success", header.TxHash, event.Message())
+	case *events.ComputeFailed, *events.ProofFailed, *events.TxFailed, *events.RequestDropped, *events.RequestExpired:
+		dashboard.AddLog(header.RequestID, "error", header.TxHash, event.Message())
+	case *events.ConsensusChecked:
+		status := "processing"
+		if event.Type() == events.TypeConsensusSplit {
+			status = "error"
+		}
+		dashboard.AddLog(header.RequestID, status, header.TxHash, event.Message())
+	case *events.RequestSetAside, *events.RequestFinished:
+		dashboard.AddLog(header.RequestID, "info", header.TxHash, event.Message())
+	case *events.RequestFound, *events.TxSent:
+		dashboard.AddLog(header.RequestID, "pending", header.TxHash, event.Message())
+	default:
+		dashboard.AddLog(header.RequestID, "processing", header.TxHash, event.Message())
+	}
+}
@@ -2,13 +2,18 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"time"
 
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/gas"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
 )
 
@@ -20,27 +25,33 @@ var forceRegisterCmd = &cobra.Command{
 	},
 }
 
+var skipSimulation bool
+
 func init() {
 	// Add force-register command to the contract command
 	contractCmd.AddCommand(forceRegisterCmd)
+
+	forceRegisterCmd.Flags().BoolVar(&skipSimulation, "skip-simulation", false, "Send the transaction even if the pre-flight simulation reverts")
 }
 
 // forceRegisterVerifier attempts to register without checking DXP requirements
 func forceRegisterVerifier() {
+	cfg := loadContractConfig()
+
 	// Connect to client
-	client, err := getClient()
+	client, err := getClient(cfg)
 	if err != nil {
 		log.Fatalf("Error connecting to blockchain: %v", err)
 	}
 
 	// Get contract
-	contract, err := getContract(client)
+	contract, err := getContract(client, cfg)
 	if err != nil {
 		log.Fatalf("Error getting contract: %v", err)
 	}
 
 	// Get account
-	privateKey, address, err := getAccount()
+	txSigner, address, err := getAccount(cfg)
 	if err != nil {
 		log.Fatalf("Error getting account: %v", err)
 	}
@@ -57,7 +68,7 @@ func forceRegisterVerifier() {
 	}
 
 	// Get auth options
-	auth, err := getAuthOptions(client, privateKey)
+	auth, err := getAuthOptions(client, cfg, txSigner)
 	if err != nil {
 		log.Fatalf("Error creating transaction options: %v", err)
 	}
@@ -85,16 +96,6 @@ func forceRegisterVerifier() {
 		fmt.Printf("Current nonce: %d\n", nonce)
 	}
 	
-	// Get gas price
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		log.Printf("Warning: Failed to get gas price: %v", err)
-	} else {
-		// Convert wei to gwei for logging
-		gwei := new(big.Float).Quo(new(big.Float).SetInt(gasPrice), big.NewFloat(1e9))
-		fmt.Printf("Current gas price: %s Gwei\n", gwei.Text('f', 2))
-	}
-	
 	// Check connection to blockchain
 	blockNumber, err := client.BlockNumber(context.Background())
 	if err != nil {
@@ -106,28 +107,22 @@ func forceRegisterVerifier() {
 	fmt.Println("WARNING: Bypassing DXP token checks. This transaction will likely fail on-chain.")
 	fmt.Println("Forcing registration attempt...")
 
-	// Set higher gas price to ensure transaction is picked up
-	// Get suggested gas price
-	suggestedGasPrice, err := client.SuggestGasPrice(context.Background())
+	tx, err := contract.RegisterValidator(gas.Unsigned(auth))
 	if err != nil {
-		log.Printf("Warning: Failed to get suggested gas price: %v", err)
-	} else {
-		// Increase gas price by 20% to ensure transaction is picked up
-		multiplier := big.NewFloat(1.2)
-		adjustedGasPrice := new(big.Float).Mul(new(big.Float).SetInt(suggestedGasPrice), multiplier)
-		adjustedGasPriceInt, _ := adjustedGasPrice.Int(nil)
-		auth.GasPrice = adjustedGasPriceInt
-		
-		// Convert to Gwei for logging
-		gwei := new(big.Float).Quo(new(big.Float).SetInt(adjustedGasPriceInt), big.NewFloat(1e9))
-		fmt.Printf("Setting gas price to: %s Gwei (increased by 20%%)\n", gwei.Text('f', 2))
+		log.Fatalf("Failed to build registration transaction: %v", err)
 	}
-	
-	// Ensure gas limit is sufficient
-	auth.GasLimit = 300000 // Higher gas limit to ensure transaction goes through
-	fmt.Printf("Setting gas limit to: %d\n", auth.GasLimit)
 
-	tx, err := contract.RegisterValidator(auth)
+	if skipSimulation {
+		// Size the gas limit without the pre-flight check so the attempt is
+		// sent even if it is expected to revert
+		fmt.Println("Skipping pre-flight simulation")
+		tx, err = forceSend(client, cfg, auth, tx)
+	} else {
+		tx, err = sendTransaction(client, cfg, auth, tx)
+	}
+	if errors.Is(err, gas.ErrSimulationFailed) {
+		fmt.Println("Re-run with --skip-simulation to send the transaction anyway.")
+	}
 	if err != nil {
 		log.Fatalf("Failed to send registration transaction: %v", err)
 	}
@@ -151,13 +146,33 @@ func forceRegisterVerifier() {
 			fmt.Printf("Transaction confirmed successfully in block %d!\n", receipt.BlockNumber)
 			fmt.Printf("Gas used: %d\n", receipt.GasUsed)
 		} else {
-			fmt.Printf("Transaction failed on-chain (status: 0). Check block explorer for details.\n")
+			fmt.Printf("Transaction failed on-chain (status: 0).\n")
+			if reason, err := contracts.FailureReason(context.Background(), client, tx, receipt); err != nil {
+				fmt.Printf("Could not determine revert reason: %v\n", err)
+			} else {
+				fmt.Printf("Revert reason: %s\n", reason)
+			}
 			fmt.Printf("Block number: %d\n", receipt.BlockNumber)
 			fmt.Printf("Gas used: %d\n", receipt.GasUsed)
 		}
 	}
 	
 	fmt.Println("\nNote: The transaction may fail on-chain due to contract requirements.")
-	fmt.Println("Check the transaction status on Sepolia block explorer:")
-	fmt.Printf("https://sepolia.etherscan.io/tx/%s\n", txHash)
+	printExplorerLink(cfg, txHash)
+}
+
+// forceSend sizes the gas limit of a transaction and broadcasts it without a pre-flight simulation
+func forceSend(client *ethclient.Client, cfg *config.Config, auth *bind.TransactOpts, tx *types.Transaction) (*types.Transaction, error) {
+	ctx := context.Background()
+	tx, err := gas.SetLimit(ctx, client, cfg, auth, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Gas limit: %d\n", tx.Gas())
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %v", err)
+	}
+
+	return tx, nil
 }
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dexponent/geth-validator/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+// requestsCmd groups the request journal commands
+var requestsCmd = &cobra.Command{
+	Use:   "requests",
+	Short: "Inspect the verification requests recorded in the journal",
+	Long: `Inspect the verification requests recorded in the request journal,
+DATA_DIR/request-journal.jsonl.
+
+The node appends every step of every request to the journal: the block it was
+found in, the computed result, the consensus outcome and votes, the proof, the
+transaction and its receipt. The journal can be read while the node is running.`,
+}
+
+var requestsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded requests, most recently updated first",
+	Run: func(cmd *cobra.Command, args []string) {
+		status, _ := cmd.Flags().GetString("status")
+		limit, _ := cmd.Flags().GetInt("limit")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		records := readJournal()
+
+		var matching []journal.Record
+		for _, record := range records {
+			if status != "" && record.Status != status {
+				continue
+			}
+			matching = append(matching, record)
+			if limit > 0 && len(matching) == limit {
+				break
+			}
+		}
+
+		if jsonOutput {
+			// Leave out the histories, 'requests show' has them
+			for i := range matching {
+				matching[i].Entries = nil
+			}
+			printJSON(matching)
+			return
+		}
+
+		if len(matching) == 0 {
+			fmt.Println("No requests recorded")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tBLOCK\tCONSENSUS\tTX\tGAS USED\tUPDATED")
+		for _, record := range matching {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				record.ID, record.Status, optionalNumber(record.Block), valueOrDash(record.Consensus),
+				valueOrDash(record.TxHash), optionalNumber(record.GasUsed), record.Updated.Local().Format("2006-01-02 15:04:05"))
+		}
+		w.Flush()
+	},
+}
+
+var requestsShowCmd = &cobra.Command{
+	Use:   "show <request-id>",
+	Short: "Show everything recorded about a request",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		var record *journal.Record
+		for _, candidate := range readJournal() {
+			if candidate.ID == args[0] {
+				record = &candidate
+				break
+			}
+		}
+		if record == nil {
+			fmt.Printf("Error: request %s is not in the journal\n", args[0])
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			printJSON(record)
+			return
+		}
+
+		fmt.Printf("Request %s\n", record.ID)
+		fmt.Println("====================")
+		fmt.Printf("Status:        %s\n", record.Status)
+		fmt.Printf("Requester:     %s\n", valueOrDash(record.Requester))
+		fmt.Printf("Found in:      %s\n", optionalNumber(record.Block))
+		if record.BlockHash != "" {
+			fmt.Printf("Log:           %d in block %s\n", record.LogIndex, record.BlockHash)
+		}
+		if record.Deadline != nil {
+			fmt.Printf("Deadline:      %s\n", record.Deadline.Local().Format("2006-01-02 15:04:05"))
+		}
+		fmt.Printf("Result:        %s\n", valueOrDash(record.Result))
+		fmt.Printf("Consensus:     %s\n", valueOrDash(record.Consensus))
+		if record.AgreedResult != "" {
+			fmt.Printf("Agreed result: %s\n", record.AgreedResult)
+		}
+		for _, vote := range record.Votes {
+			fmt.Printf("  vote %-10s %s\n", vote.Participant, vote.Result)
+		}
+		fmt.Printf("Proof:         %s\n", valueOrDash(record.Proof))
+		fmt.Printf("Transaction:   %s\n", valueOrDash(record.TxHash))
+		if record.Receipt != "" {
+			fmt.Printf("Receipt:       %s in block %d, gas used %d, fee %s ETH\n",
+				record.Receipt, record.ReceiptBlock, record.GasUsed, weiText(record.Fee))
+		}
+		if record.Error != "" {
+			fmt.Printf("Error:         %s\n", record.Error)
+		}
+
+		fmt.Println("\nLifecycle:")
+		for _, entry := range record.Entries {
+			fmt.Printf("  %s  %-18s %s\n", entry.Time.Local().Format("2006-01-02 15:04:05.000"), entry.Type, entry.Message)
+		}
+	},
+}
+
+// readJournal loads the request journal of the configured data directory
+func readJournal() []journal.Record {
+	// Only the data directory is needed
+	opts := configOptions()
+	opts.KeyOptional = true
+	cfg, err := loadConfigWith(opts)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	records, err := journal.Read(journal.Path(cfg))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	return records
+}
+
+// printJSON prints a value as indented JSON
+func printJSON(value interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		fmt.Printf("Error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// optionalNumber formats a number that is zero when unknown
+func optionalNumber(n uint64) string {
+	if n == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d", n)
+}
+
+// valueOrDash returns value, or a dash when it is empty
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// weiText formats an amount in wei, given as a decimal string, in ETH
+func weiText(wei string) string {
+	amount, ok := new(big.Int).SetString(wei, 10)
+	if !ok {
+		return "?"
+	}
+
+	ether := new(big.Float).Quo(new(big.Float).SetInt(amount), big.NewFloat(1e18)).Text('f', 18)
+	return strings.TrimRight(strings.TrimRight(ether, "0"), ".")
+}
+
+func init() {
+	RootCmd.AddCommand(requestsCmd)
+	requestsCmd.AddCommand(requestsListCmd)
+	requestsCmd.AddCommand(requestsShowCmd)
+
+	requestsListCmd.Flags().String("status", "", "Only list requests with this status, e.g. confirmed, reverted or failed")
+	requestsListCmd.Flags().Int("limit", 50, "List at most this many requests (0 for all)")
+	requestsListCmd.Flags().Bool("json", false, "Print the requests as JSON")
+	requestsShowCmd.Flags().Bool("json", false, "Print the request as JSON")
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/dexponent/geth-validator/internal/config"
 	"github.com/dexponent/geth-validator/internal/validator"
 	"github.com/spf13/cobra"
 )
@@ -16,7 +15,7 @@ var rewardsCmd = &cobra.Command{
 	Long:  `Check accumulated rewards for the validator from successful verifications.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Load configuration
-		cfg, err := config.LoadConfig()
+		cfg, err := loadConfig()
 		if err != nil {
 			fmt.Printf("Error loading configuration: %v\n", err)
 			os.Exit(1)
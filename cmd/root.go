@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/logging"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
@@ -32,6 +34,44 @@ func Execute() {
 	}
 }
 
+// configOptions collects the global command line flags that override configuration
+func configOptions() config.Options {
+	flags := RootCmd.PersistentFlags()
+	configFile, _ := flags.GetString("config")
+	network, _ := flags.GetString("network")
+
+	// The log level flag has a default, so only let it override the
+	// environment and config file when it was given explicitly
+	logLevel := ""
+	if flags.Changed("log-level") {
+		logLevel, _ = flags.GetString("log-level")
+	}
+
+	return config.Options{
+		ConfigFile: configFile,
+		Network:    network,
+		LogLevel:   logLevel,
+	}
+}
+
+// loadConfig loads the configuration, applying the global command line flags
+func loadConfig() (*config.Config, error) {
+	return loadConfigWith(configOptions())
+}
+
+// loadConfigWith loads the configuration with the given options and applies its log level
+func loadConfigWith(opts config.Options) (*config.Config, error) {
+	cfg, err := config.Load(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The level was validated with the rest of the configuration
+	logging.SetLevel(cfg.LogLevel)
+
+	return cfg, nil
+}
+
 func init() {
 	// Load environment variables from .env file if it exists
 	envFile := filepath.Join(".env")
@@ -42,8 +82,9 @@ func init() {
 	}
 
 	// Add persistent flags that will be global for all subcommands
-	RootCmd.PersistentFlags().StringP("config", "c", "", "config file (default is .env)")
+	RootCmd.PersistentFlags().StringP("config", "c", "", "YAML or TOML config file; flags and environment variables (including .env) take precedence")
 	RootCmd.PersistentFlags().StringP("log-level", "l", "info", "log level (debug, info, warn, error)")
+	RootCmd.PersistentFlags().StringP("network", "n", "", "network profile to use (built-in: base, base-sepolia, sepolia, dev; more in networks.yaml)")
 
 	// Initialize subcommands
 	RootCmd.AddCommand(startCmd)
@@ -6,12 +6,22 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/dexponent/geth-validator/internal/admin"
+	"github.com/dexponent/geth-validator/internal/alerts"
 	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/daemon"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/dexponent/geth-validator/internal/metrics"
+	"github.com/dexponent/geth-validator/internal/tui"
 	"github.com/dexponent/geth-validator/internal/validator"
 	"github.com/spf13/cobra"
 )
 
+// alertFlushTimeout is how long a stopping node waits for queued alerts to be delivered
+const alertFlushTimeout = 15 * time.Second
+
 // startCmd represents the start command
 var startCmd = &cobra.Command{
 	Use:   "start",
@@ -21,70 +31,255 @@ var startCmd = &cobra.Command{
 		// Parse flags
 		blockPollingInterval, _ := cmd.Flags().GetInt("block-polling-interval")
 		detached, _ := cmd.Flags().GetBool("detached")
+		dashboard, _ := cmd.Flags().GetBool("dashboard")
+		interactive, _ := cmd.Flags().GetBool("tui")
+		logFile, _ := cmd.Flags().GetString("log-file")
+		drainTimeout, _ := cmd.Flags().GetDuration("drain-timeout")
+		metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
 
 		// Load configuration
-		cfg, err := config.LoadConfig()
+		cfg, err := loadConfig()
 		if err != nil {
 			fmt.Printf("Error loading configuration: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Create validator instance
-		validatorNode, err := validator.NewValidator(cfg)
+		// The log file and metrics flags override LOG_FILE and METRICS_ADDR
+		if logFile != "" {
+			cfg.LogFile = logFile
+		}
+		if metricsAddr != "" {
+			cfg.MetricsAddr = metricsAddr
+		}
+
+		// The dashboard and terminal UI take over the terminal, so logs go to a file
+		if dashboard || interactive {
+			if detached {
+				fmt.Println("Error: --dashboard and --tui cannot be used with --detached")
+				os.Exit(1)
+			}
+			if dashboard && interactive {
+				fmt.Println("Error: use either --dashboard or --tui")
+				os.Exit(1)
+			}
+			if cfg.LogFile == "" {
+				cfg.LogFile = daemon.LogPath(cfg)
+			}
+		}
+
+		// Re-run this command in the background and return once it is up
+		if detached {
+			startDetached(cmd, cfg)
+			return
+		}
+
+		logOutput, err := logging.Setup(logging.Options{
+			Level:      cfg.LogLevel,
+			Format:     cfg.LogFormat,
+			File:       cfg.LogFile,
+			MaxSizeMB:  int(cfg.LogMaxSizeMB),
+			MaxBackups: int(cfg.LogMaxBackups),
+		})
 		if err != nil {
-			fmt.Printf("Error creating validator: %v\n", err)
+			fmt.Printf("Error setting up logging: %v\n", err)
 			os.Exit(1)
 		}
+		defer logOutput.Close()
 
-		// Check if validator is registered
-		isRegistered, err := validatorNode.IsRegistered()
+		err = runNode(cfg, blockPollingInterval, dashboard, interactive, drainTimeout)
 		if err != nil {
-			fmt.Printf("Error checking registration status: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
+			logOutput.Close()
 			os.Exit(1)
 		}
+	},
+}
 
-		if !isRegistered {
-			fmt.Println("Validator is not registered. Attempting to register...")
-			txHash, err := validatorNode.RegisterValidator()
-			if err != nil {
-				fmt.Printf("Error registering validator: %v\n", err)
-				os.Exit(1)
-			}
-			fmt.Printf("Validator registered successfully! TX: %s\n", txHash)
-		} else {
-			fmt.Println("Validator is already registered with the DXP contract.")
+// runNode runs the validator node until it is stopped. It returns instead of
+// exiting on errors, so the admin socket and PID file are always cleaned up.
+func runNode(cfg *config.Config, blockPollingInterval int, dashboard, interactive bool, drainTimeout time.Duration) error {
+	// Create validator instance
+	validatorNode, err := validator.NewValidator(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create validator: %v", err)
+	}
+
+	// Serve the admin API used by the status command; this also refuses to
+	// run a second node on the same data directory
+	adminServer := admin.NewServer(admin.SocketPath(cfg), validatorNode)
+	if err := adminServer.Start(); err != nil {
+		return fmt.Errorf("failed to start admin API: %v", err)
+	}
+	defer adminServer.Close()
+
+	// Serve Prometheus metrics when an address is configured
+	if cfg.MetricsAddr != "" {
+		metricsServer := metrics.NewServer(cfg.MetricsAddr, validatorNode.Metrics())
+		if err := metricsServer.Start(); err != nil {
+			return fmt.Errorf("failed to start metrics server: %v", err)
 		}
+		defer metricsServer.Close()
+	}
 
-		// Create context with cancellation
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+	// Post alerts to the webhook when one is configured, delivering the
+	// ones raised during shutdown before exiting
+	if cfg.AlertWebhookURL != "" {
+		alertManager := alerts.New(cfg, validatorNode.Address().Hex())
+		alertManager.Start(validatorNode.Events())
+		defer alertManager.Close(alertFlushTimeout)
+	}
 
-		// Start the validator
-		fmt.Println("Starting validator node...")
-		if err := validatorNode.Start(ctx, blockPollingInterval); err != nil {
-			fmt.Printf("Error starting validator: %v\n", err)
-			os.Exit(1)
+	// Record the PID so stop can signal the node if the admin API is unavailable
+	pidPath := daemon.PIDPath(cfg)
+	if err := daemon.WritePID(pidPath); err != nil {
+		return err
+	}
+	defer daemon.RemovePID(pidPath)
+
+	// Check if validator is registered
+	isRegistered, err := validatorNode.IsRegistered()
+	if err != nil {
+		return err
+	}
+
+	if !isRegistered {
+		fmt.Println("Validator is not registered. Attempting to register...")
+		txHash, err := validatorNode.RegisterValidator()
+		if err != nil {
+			return err
 		}
+		fmt.Printf("Validator registered successfully! TX: %s\n", txHash)
+	} else {
+		fmt.Println("Validator is already registered with the DXP contract.")
+	}
+
+	// Create context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start the validator
+	fmt.Println("Starting validator node...")
+	if err := validatorNode.Start(ctx, blockPollingInterval); err != nil {
+		return fmt.Errorf("failed to start validator: %v", err)
+	}
+
+	fmt.Println("Validator node started successfully!")
+
+	// Show the dashboard, fed by the node's events
+	stopDashboard := func() {}
+	if dashboard {
+		stopDashboard = runDashboard(ctx, validatorNode)
+	}
+
+	// Run the terminal UI; quitting it stops the node
+	tuiCtx, stopTUI := context.WithCancel(ctx)
+	tuiDone := make(chan struct{})
+	if interactive {
+		go func() {
+			defer close(tuiDone)
+			if err := tui.Run(tuiCtx, validatorNode, "local"); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+		}()
+	}
+
+	// Apply changes to gas settings and log level from the config file
+	go config.Watch(ctx, configOptions(), 5*time.Second, validatorNode.ReloadConfig)
+
+	// Block until we receive a signal or a stop request through the admin API
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-c:
+	case <-adminServer.StopRequested():
+	case <-tuiDone:
+	}
+
+	// Give the terminal back before reporting the shutdown
+	stopDashboard()
+	stopTUI()
+	if interactive {
+		<-tuiDone
+	}
 
-		fmt.Println("Validator node started successfully!")
+	// Stop taking requests and let in-flight verifications drain
+	fmt.Println("\nStopping validator node...")
+	report := validatorNode.Shutdown(drainTimeout)
+	fmt.Println(report)
+	fmt.Println("Validator node stopped.")
 
-		// Handle graceful shutdown if not in detached mode
-		if !detached {
-			c := make(chan os.Signal, 1)
-			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	return nil
+}
+
+// startDetached starts the validator in a background process logging to
+// LOG_FILE (DATA_DIR/validator.log by default), and waits until the node's
+// admin API answers
+func startDetached(cmd *cobra.Command, cfg *config.Config) {
+	logFile := cfg.LogFile
+	if logFile == "" {
+		logFile = daemon.LogPath(cfg)
+	}
+
+	// A detached node has no terminal to prompt for the keystore passphrase
+	if cfg.KeystoreFile != "" && cfg.KeystorePasswordFile == "" && cfg.KeystorePassword == "" {
+		fmt.Println("Error: detached mode needs KEYSTORE_PASSWORD_FILE or KEYSTORE_PASSWORD to unlock the keystore")
+		os.Exit(1)
+	}
+
+	client := admin.NewClient(admin.SocketPath(cfg))
+	if _, err := client.Status(context.Background()); err == nil {
+		fmt.Println("Error: a validator node is already running with this data directory")
+		os.Exit(1)
+	}
+
+	// Anything the node prints outside its logs, such as a panic, goes to a
+	// separate file so it does not interfere with log rotation
+	args := daemon.DetachArgs(os.Args[1:])
+	if !cmd.Flags().Changed("log-file") {
+		args = append(args, "--log-file", logFile)
+	}
+
+	process, err := daemon.Detach(args, daemon.OutputPath(cfg))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-			// Block until we receive a signal
-			<-c
+	exited := make(chan struct{})
+	go func() {
+		process.Wait()
+		close(exited)
+	}()
 
-			fmt.Println("\nStopping validator node...")
-			validatorNode.Stop()
-			fmt.Println("Validator node stopped.")
+	deadline := time.After(2 * time.Minute)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-exited:
+			fmt.Printf("Error: validator exited during startup, see %s and %s\n", logFile, daemon.OutputPath(cfg))
+			os.Exit(1)
+		case <-deadline:
+			fmt.Printf("Error: validator (PID %d) did not come up in time, see %s\n", process.Pid, logFile)
+			os.Exit(1)
+		case <-ticker.C:
+			if _, err := client.Status(context.Background()); err == nil {
+				fmt.Printf("Validator node running in the background (PID %d), logs in %s\n", process.Pid, logFile)
+				fmt.Println("Use 'dxp-validator status' to check on it and 'dxp-validator stop' to stop it.")
+				return
+			}
 		}
-	},
+	}
 }
 
 func init() {
 	startCmd.Flags().Int("block-polling-interval", 10, "Interval in seconds to poll for new blocks")
-	startCmd.Flags().Bool("detached", false, "Run the validator in detached mode")
-	startCmd.Flags().String("log-file", "", "Log file to write validator logs to")
+	startCmd.Flags().Bool("detached", false, "Run the validator in the background")
+	startCmd.Flags().Bool("tui", false, "Run the interactive terminal UI; quitting it stops the node (logs go to LOG_FILE or DATA_DIR/validator.log)")
+	startCmd.Flags().Bool("dashboard", false, "Show a live dashboard of the node's metrics and requests (logs go to LOG_FILE or DATA_DIR/validator.log)")
+	startCmd.Flags().String("log-file", "", "Log file to write validator logs to, overriding LOG_FILE (detached default: DATA_DIR/validator.log)")
+	startCmd.Flags().String("metrics-addr", "", "Serve Prometheus metrics on this host:port, overriding METRICS_ADDR")
+	startCmd.Flags().Duration("drain-timeout", 30*time.Second, "How long to wait for in-flight verifications when stopping")
 }
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
-	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/admin"
 	"github.com/dexponent/geth-validator/internal/validator"
 	"github.com/spf13/cobra"
 )
@@ -13,32 +17,77 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check the status of the validator node",
-	Long:  `Check the status of the validator node, including registration, block processing, and more.`,
+	Long: `Check the status of the validator node, including registration, block processing, and more.
+
+The running node is queried through its admin socket in DATA_DIR. When no node
+is running, registration and balance are read from the chain instead.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Load configuration
-		cfg, err := config.LoadConfig()
+		cfg, err := loadConfig()
 		if err != nil {
 			fmt.Printf("Error loading configuration: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Get validator status
-		status, err := validator.GetValidatorStatus(cfg)
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		// Ask the running node, falling back to the on-chain state
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		status, err := admin.NewClient(admin.SocketPath(cfg)).Status(ctx)
+		if errors.Is(err, admin.ErrNotRunning) {
+			status, err = validator.GetValidatorStatus(cfg)
+		}
 		if err != nil {
 			fmt.Printf("Error getting validator status: %v\n", err)
 			os.Exit(1)
 		}
 
+		if jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(status); err != nil {
+				fmt.Printf("Error encoding status: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		// Print status information
 		fmt.Println("Validator Node Status")
 		fmt.Println("=====================")
 		fmt.Printf("Running: %v\n", status.Running)
-		fmt.Printf("Node ID: %s\n", status.NodeID)
+		if status.Running {
+			fmt.Printf("Node ID: %s\n", status.NodeID)
+		}
 		fmt.Printf("Account: %s\n", status.Account)
-		fmt.Printf("ETH Balance: %.6f ETH\n", status.Balance)
+		if status.Balance != nil {
+			fmt.Printf("ETH Balance: %.6f ETH\n", *status.Balance)
+		} else {
+			fmt.Printf("ETH Balance: unavailable (%s)\n", status.BalanceError)
+		}
 		fmt.Printf("Registered: %v\n", status.Registered)
+		if !status.Running {
+			fmt.Println("(node is not running, showing on-chain state only)")
+			return
+		}
 		fmt.Printf("Last Block Processed: %d\n", status.LastBlockProcessed)
 		fmt.Printf("Verification Queue: %d\n", status.VerificationQueueSize)
 		fmt.Printf("Consensus Participants: %d\n", status.ConsensusParticipants)
+		if status.IntakePaused {
+			fmt.Println("Request Intake: paused")
+		}
+		for _, endpoint := range status.RPCEndpoints {
+			health := "healthy"
+			if !endpoint.Healthy {
+				health = "unhealthy: " + endpoint.Error
+			}
+			fmt.Printf("RPC %s: head %d, %d ms, %s\n", endpoint.URL, endpoint.Head, endpoint.LatencyMs, health)
+		}
 	},
 }
+
+func init() {
+	statusCmd.Flags().Bool("json", false, "Print the status as JSON")
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/dexponent/geth-validator/internal/admin"
 	"github.com/dexponent/geth-validator/internal/config"
-	"github.com/dexponent/geth-validator/internal/validator"
+	"github.com/dexponent/geth-validator/internal/daemon"
 	"github.com/spf13/cobra"
 )
 
@@ -13,17 +17,26 @@ import (
 var stopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop the validator node",
-	Long:  `Stop a running validator node.`,
+	Long: `Stop a running validator node.
+
+The node is asked to shut down through its admin socket, or sent SIGTERM using
+the PID file in DATA_DIR, and then drains in-flight verifications before it
+exits. The command waits for it to exit.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load configuration
-		cfg, err := config.LoadConfig()
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		force, _ := cmd.Flags().GetBool("force")
+
+		// Load configuration; stopping needs no key
+		opts := configOptions()
+		opts.KeyOptional = true
+		cfg, err := config.Load(opts)
 		if err != nil {
 			fmt.Printf("Error loading configuration: %v\n", err)
 			os.Exit(1)
 		}
 
 		// Stop the validator
-		if err := validator.StopValidator(cfg); err != nil {
+		if err := stopNode(cfg, timeout, force); err != nil {
 			fmt.Printf("Error stopping validator: %v\n", err)
 			os.Exit(1)
 		}
@@ -31,3 +44,61 @@ var stopCmd = &cobra.Command{
 		fmt.Println("Validator node stopped.")
 	},
 }
+
+func init() {
+	stopCmd.Flags().Duration("timeout", time.Minute, "How long to wait for the node to drain and exit")
+	stopCmd.Flags().Bool("force", false, "Kill the node if it has not exited when the timeout expires")
+}
+
+// stopNode asks the running node to shut down and waits until it has exited
+func stopNode(cfg *config.Config, timeout time.Duration, force bool) error {
+	pidPath := daemon.PIDPath(cfg)
+	client := admin.NewClient(admin.SocketPath(cfg))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Prefer the admin API, falling back to a signal
+	pid, err := client.Stop(ctx)
+	switch {
+	case err == nil:
+		fmt.Printf("Stop requested, waiting for the node (PID %d) to drain...\n", pid)
+	case errors.Is(err, admin.ErrNotRunning):
+		pid, err = daemon.ReadPID(pidPath)
+		if errors.Is(err, daemon.ErrNoPIDFile) {
+			return errors.New("validator node is not running")
+		}
+		if err != nil {
+			return err
+		}
+		if !daemon.Alive(pid) {
+			os.Remove(pidPath)
+			return fmt.Errorf("validator node is not running (removed stale PID file for %d)", pid)
+		}
+		if err := daemon.Terminate(pid); err != nil {
+			return fmt.Errorf("failed to signal PID %d: %v", pid, err)
+		}
+		fmt.Printf("Sent SIGTERM, waiting for the node (PID %d) to drain...\n", pid)
+	default:
+		return err
+	}
+
+	// Wait for the process to exit
+	deadline := time.Now().Add(timeout)
+	for daemon.Alive(pid) {
+		if time.Now().After(deadline) {
+			if !force {
+				return fmt.Errorf("node (PID %d) is still running after %s, re-run with --force to kill it", pid, timeout)
+			}
+			fmt.Printf("Node did not exit within %s, killing PID %d\n", timeout, pid)
+			if err := daemon.Kill(pid); err != nil {
+				return fmt.Errorf("failed to kill PID %d: %v", pid, err)
+			}
+			os.Remove(pidPath)
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return nil
+}
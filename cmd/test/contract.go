@@ -1,3 +1,5 @@
+//go:build ignore
+
 package main
 
 import (
@@ -9,11 +11,12 @@ import (
 	"os"
 	"strings"
 
+	"github.com/dexponent/geth-validator/internal/config"
 	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/wallet"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
@@ -129,26 +132,18 @@ func getContract(client *ethclient.Client) (*contracts.DexponentContractWrapper,
 }
 
 func getAccount() (*ecdsa.PrivateKey, common.Address, error) {
-	privateKeyStr := os.Getenv("WALLET_PRIVATE_KEY")
-	if privateKeyStr == "" {
-		return nil, common.Address{}, fmt.Errorf("WALLET_PRIVATE_KEY not set in .env file")
-	}
-
-	// Parse private key
-	privateKey, err := crypto.HexToECDSA(privateKeyStr)
+	// Load the signing key from the keystore or WALLET_PRIVATE_KEY
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		return nil, common.Address{}, fmt.Errorf("invalid private key: %v", err)
+		return nil, common.Address{}, err
 	}
 
-	// Get account address from private key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, common.Address{}, fmt.Errorf("error casting public key to ECDSA")
+	privateKey, err := wallet.LoadKey(cfg)
+	if err != nil {
+		return nil, common.Address{}, err
 	}
 
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
-	return privateKey, address, nil
+	return privateKey, wallet.Address(privateKey), nil
 }
 
 func getAuthOptions(client *ethclient.Client, privateKey *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
@@ -0,0 +1,171 @@
+//go:build ignore
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/joho/godotenv"
+)
+
+// signTxArgs is the transaction object sent by the validator's remote signer
+type signTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                hexutil.Big     `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+// mockSigner signs every request for its single key without asking
+type mockSigner struct {
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+// sign builds the requested transaction and signs it
+func (m *mockSigner) sign(args signTxArgs) (*types.Transaction, error) {
+	if args.From != m.address {
+		return nil, fmt.Errorf("unknown account %s", args.From.Hex())
+	}
+	if args.ChainID == nil {
+		return nil, fmt.Errorf("chainId is required")
+	}
+
+	var txData types.TxData
+	if args.MaxFeePerGas != nil {
+		txData = &types.DynamicFeeTx{
+			ChainID:   args.ChainID.ToInt(),
+			Nonce:     uint64(args.Nonce),
+			GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas:       uint64(args.Gas),
+			To:        args.To,
+			Value:     args.Value.ToInt(),
+			Data:      args.Data,
+		}
+	} else {
+		gasPrice := new(big.Int)
+		if args.GasPrice != nil {
+			gasPrice = args.GasPrice.ToInt()
+		}
+		txData = &types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: gasPrice,
+			Gas:      uint64(args.Gas),
+			To:       args.To,
+			Value:    args.Value.ToInt(),
+			Data:     args.Data,
+		}
+	}
+
+	signer := types.LatestSignerForChainID(args.ChainID.ToInt())
+	tx, err := types.SignNewTx(m.privateKey, signer, txData)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Signed transaction %s (nonce %d, to %v)", tx.Hash().Hex(), tx.Nonce(), tx.To())
+	return tx, nil
+}
+
+// clefAPI serves account_list and account_signTransaction like Clef
+type clefAPI struct {
+	signer *mockSigner
+}
+
+// List handles account_list
+func (c *clefAPI) List() []common.Address {
+	return []common.Address{c.signer.address}
+}
+
+// clefSignResult mirrors Clef's response
+type clefSignResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// SignTransaction handles account_signTransaction
+func (c *clefAPI) SignTransaction(args signTxArgs) (*clefSignResult, error) {
+	tx, err := c.signer.sign(args)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &clefSignResult{Raw: raw, Tx: tx}, nil
+}
+
+// web3SignerAPI serves eth_accounts and eth_signTransaction like Web3Signer
+type web3SignerAPI struct {
+	signer *mockSigner
+}
+
+// Accounts handles eth_accounts
+func (w *web3SignerAPI) Accounts() []common.Address {
+	return []common.Address{w.signer.address}
+}
+
+// SignTransaction handles eth_signTransaction
+func (w *web3SignerAPI) SignTransaction(args signTxArgs) (hexutil.Bytes, error) {
+	tx, err := w.signer.sign(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.MarshalBinary()
+}
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8550", "address to serve the signer API on")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file loaded: %v", err)
+	}
+
+	// The mock signer holds the key that the validator should not see
+	privateKey, err := crypto.HexToECDSA(os.Getenv("MOCK_SIGNER_KEY"))
+	if err != nil {
+		log.Fatalf("Set MOCK_SIGNER_KEY to a hex private key: %v", err)
+	}
+
+	mock := &mockSigner{
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+
+	// Serve both the Clef and the Web3Signer method names
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &clefAPI{signer: mock}); err != nil {
+		log.Fatalf("Failed to register account API: %v", err)
+	}
+	if err := server.RegisterName("eth", &web3SignerAPI{signer: mock}); err != nil {
+		log.Fatalf("Failed to register eth API: %v", err)
+	}
+
+	fmt.Printf("Mock signer for %s listening on http://%s\n", mock.address.Hex(), *listen)
+	fmt.Printf("Set SIGNER_URL=http://%s and SIGNER_ADDRESS=%s\n", *listen, mock.address.Hex())
+	log.Fatal(http.ListenAndServe(*listen, server))
+}
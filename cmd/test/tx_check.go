@@ -1,3 +1,5 @@
+//go:build ignore
+
 package main
 
 import (
@@ -6,7 +8,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/dexponent/geth-validator/internal/contracts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/joho/godotenv"
 )
@@ -66,5 +70,14 @@ func main() {
 		fmt.Printf("Status: %d (0=failed, 1=success)\n", receipt.Status)
 		fmt.Printf("Block number: %d\n", receipt.BlockNumber)
 		fmt.Printf("Gas used: %d\n", receipt.GasUsed)
+
+		if receipt.Status == types.ReceiptStatusFailed {
+			reason, err := contracts.FailureReason(ctx, client, tx, receipt)
+			if err != nil {
+				fmt.Printf("Could not determine revert reason: %v\n", err)
+			} else {
+				fmt.Printf("Revert reason: %s\n", reason)
+			}
+		}
 	}
 }
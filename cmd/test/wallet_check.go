@@ -1,16 +1,17 @@
+//go:build ignore
+
 package main
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"log"
 	"math/big"
-	"os"
 	"time"
 
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/wallet"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/joho/godotenv"
 )
@@ -22,14 +23,13 @@ func main() {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	// Get RPC URL and private key from environment
-	rpcURL := os.Getenv("BASE_RPC_URL")
-	privateKeyHex := os.Getenv("WALLET_PRIVATE_KEY")
-	dxpContractAddr := os.Getenv("DXP_CONTRACT_ADDRESS")
-
-	if rpcURL == "" || privateKeyHex == "" || dxpContractAddr == "" {
-		log.Fatalf("Required environment variables not set")
+	// Load RPC URL, contract address and signing key from the configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
 	}
+	rpcURL := cfg.BaseRPCURL
+	dxpContractAddr := cfg.DXPContractAddress
 
 	// Connect to Ethereum client
 	fmt.Printf("Connecting to %s...\n", rpcURL)
@@ -38,20 +38,13 @@ func main() {
 		log.Fatalf("Failed to connect to the Ethereum client: %v", err)
 	}
 
-	// Get the private key
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	// Get the private key from the keystore or WALLET_PRIVATE_KEY
+	privateKey, err := wallet.LoadKey(cfg)
 	if err != nil {
-		log.Fatalf("Failed to parse private key: %v", err)
-	}
-
-	// Get the public key and address
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatalf("Failed to get public key")
+		log.Fatalf("Failed to load private key: %v", err)
 	}
 
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
+	address := wallet.Address(privateKey)
 	fmt.Printf("Wallet address: %s\n", address.Hex())
 
 	// Check the balance
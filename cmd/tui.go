@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/admin"
+	"github.com/dexponent/geth-validator/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// tuiCmd opens the terminal UI of a running node
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Open the interactive terminal UI of a running validator node",
+	Long: `Open the interactive terminal UI of a validator node running in the
+background, through its admin socket in DATA_DIR or the one given with --socket.
+
+The UI shows the request queue, consensus votes, pending transactions and the
+node's recent events. Select a request and press enter to see its lifecycle,
+press f to filter requests by status and p to pause or resume request intake.
+Run 'start --tui' to start a node with the UI instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		socket, _ := cmd.Flags().GetString("socket")
+
+		// Only the data directory is needed to find the socket
+		if socket == "" {
+			opts := configOptions()
+			opts.KeyOptional = true
+			cfg, err := loadConfigWith(opts)
+			if err != nil {
+				fmt.Printf("Error loading configuration: %v\n", err)
+				os.Exit(1)
+			}
+			socket = admin.SocketPath(cfg)
+		}
+
+		client := admin.NewClient(socket)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := client.Status(ctx)
+		cancel()
+		if errors.Is(err, admin.ErrNotRunning) {
+			fmt.Printf("Error: no validator node is running with admin socket %s\n", socket)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := tui.Run(context.Background(), client, socket); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(tuiCmd)
+
+	tuiCmd.Flags().String("socket", "", "Admin socket of the node (default: DATA_DIR/admin.sock)")
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/gas"
+	"github.com/dexponent/geth-validator/internal/offline"
+	"github.com/dexponent/geth-validator/internal/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	offlineMode     bool
+	unsignedOutFile string
+	txFormat        string
+	offlineFrom     string
+)
+
+// txCmd groups the commands of the offline signing workflow
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Sign and broadcast transactions prepared with --offline",
+	Long: `Sign and broadcast transactions prepared with --offline.
+
+The contract commands and claim can write an unsigned transaction instead of
+sending it. Copy the file to the machine holding the key and run 'tx sign'
+there, then copy the signed file back and run 'tx broadcast'.`,
+}
+
+var txSignCmd = &cobra.Command{
+	Use:   "sign <unsigned-file>",
+	Short: "Sign an unsigned transaction with the configured key, without network access",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out, _ := cmd.Flags().GetString("out")
+		chainIDFlag, _ := cmd.Flags().GetInt64("chain-id")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		unsigned, err := offline.ReadUnsigned(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// The chain ID comes from the file; legacy transactions in the RLP
+		// format need it on the command line
+		chainID := unsigned.ChainID
+		if chainIDFlag != 0 {
+			if chainID != 0 && chainID != chainIDFlag {
+				fmt.Printf("Error: transaction is for chain %d, not %d\n", chainID, chainIDFlag)
+				os.Exit(1)
+			}
+			chainID = chainIDFlag
+		}
+		if chainID == 0 {
+			fmt.Println("Error: the transaction file has no chain ID, pass --chain-id")
+			os.Exit(1)
+		}
+
+		// Load the key only; signing needs no RPC endpoint
+		cfg := loadAccountConfig()
+		txSigner, err := signer.New(cfg)
+		if err != nil {
+			fmt.Printf("Error loading signing key: %v\n", err)
+			os.Exit(1)
+		}
+
+		if unsigned.From != (common.Address{}) && unsigned.From != txSigner.Address() {
+			fmt.Printf("Error: transaction is from %s but the configured key is %s\n", unsigned.From.Hex(), txSigner.Address().Hex())
+			os.Exit(1)
+		}
+
+		printUnsignedTx(unsigned, chainID, txSigner.Address())
+		if !yes && !confirm("Sign this transaction?") {
+			fmt.Println("Not signed.")
+			return
+		}
+
+		signed, err := txSigner.SignTx(context.Background(), unsigned.Tx, big.NewInt(chainID))
+		if err != nil {
+			fmt.Printf("Error signing transaction: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := offline.WriteSigned(out, signed); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Signed transaction %s written to %s\n", signed.Hash().Hex(), out)
+	},
+}
+
+var txBroadcastCmd = &cobra.Command{
+	Use:   "broadcast <signed-file>",
+	Short: "Send a signed transaction and wait for its receipt",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		tx, err := offline.ReadSigned(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Broadcasting needs no key
+		opts := configOptions()
+		opts.KeyOptional = true
+		cfg, err := config.Load(opts)
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := getClient(cfg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer client.Close()
+
+		// Refuse transactions signed for another chain
+		if tx.Protected() && tx.ChainId().Int64() != cfg.ChainID {
+			fmt.Printf("Error: transaction is signed for chain %s but network %s is chain %d\n", tx.ChainId(), cfg.Network, cfg.ChainID)
+			os.Exit(1)
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(cfg.ChainID)), tx)
+		if err != nil {
+			fmt.Printf("Error: cannot recover transaction sender: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		fmt.Printf("Sending transaction %s from %s...\n", tx.Hash().Hex(), from.Hex())
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			fmt.Printf("Error sending transaction: %v\n", err)
+			os.Exit(1)
+		}
+		printExplorerLink(cfg, tx.Hash().Hex())
+
+		fmt.Println("Waiting for the transaction to be mined...")
+		receipt, err := bind.WaitMined(ctx, client, tx)
+		if err != nil {
+			fmt.Printf("Error waiting for receipt: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Mined in block %s, gas used: %d\n", receipt.BlockNumber, receipt.GasUsed)
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			reason, err := contracts.FailureReason(ctx, client, tx, receipt)
+			if err != nil {
+				reason = fmt.Sprintf("unknown reason (%v)", err)
+			}
+			fmt.Printf("Transaction reverted: %s\n", reason)
+			os.Exit(1)
+		}
+
+		fmt.Println("Transaction succeeded")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(txCmd)
+	txCmd.AddCommand(txSignCmd)
+	txCmd.AddCommand(txBroadcastCmd)
+
+	txSignCmd.Flags().StringP("out", "o", "signed-tx.txt", "File to write the signed transaction to")
+	txSignCmd.Flags().Int64("chain-id", 0, "Chain ID to sign for, required for legacy transactions in the rlp format")
+	txSignCmd.Flags().BoolP("yes", "y", false, "Sign without asking for confirmation")
+	txBroadcastCmd.Flags().Duration("timeout", 5*time.Minute, "How long to wait for the transaction to be mined")
+
+	for _, cmd := range []*cobra.Command{registerCmd, approveCmd, submitCmd} {
+		addOfflineFlags(cmd)
+	}
+}
+
+// addOfflineFlags adds the flags that write an unsigned transaction instead of sending it
+func addOfflineFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&offlineMode, "offline", false, "Write the unsigned transaction for signing elsewhere instead of signing and sending it")
+	cmd.Flags().StringVar(&unsignedOutFile, "unsigned-out", "", "File to write the unsigned transaction to (implies --offline, default unsigned-tx.json)")
+	cmd.Flags().StringVar(&txFormat, "format", offline.FormatJSON, "Format of the unsigned transaction file (json or rlp)")
+	cmd.Flags().StringVar(&offlineFrom, "from", "", "Sender of the unsigned transaction, so no key needs to be loaded (default: the configured account)")
+}
+
+// isOffline reports whether the transaction should be written out unsigned
+func isOffline() bool {
+	return offlineMode || unsignedOutFile != ""
+}
+
+// writeUnsignedTx checks an unsigned transaction against the latest block,
+// sizes its gas limit and writes it for signing elsewhere
+func writeUnsignedTx(client gas.CallBackend, cfg *config.Config, auth *bind.TransactOpts, tx *types.Transaction, description string) error {
+	tx, err := gas.Prepare(context.Background(), client, cfg, auth, tx)
+	if err != nil {
+		return err
+	}
+
+	path := unsignedOutFile
+	if path == "" {
+		path = "unsigned-tx.json"
+		if txFormat == offline.FormatRLP {
+			path = "unsigned-tx.txt"
+		}
+	}
+
+	err = offline.WriteUnsigned(path, txFormat, &offline.UnsignedTx{
+		ChainID:     cfg.ChainID,
+		From:        auth.From,
+		Description: description,
+		Tx:          tx,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Unsigned transaction (nonce %d, gas limit %d) written to %s\n", tx.Nonce(), tx.Gas(), path)
+	fmt.Printf("Sign it with 'tx sign %s' on the machine holding the key for %s, then send it with 'tx broadcast'.\n", path, auth.From.Hex())
+
+	return nil
+}
+
+// printUnsignedTx shows what is about to be signed
+func printUnsignedTx(unsigned *offline.UnsignedTx, chainID int64, from common.Address) {
+	tx := unsigned.Tx
+
+	fmt.Println("Transaction to sign:")
+	if unsigned.Description != "" {
+		fmt.Printf("  Description: %s\n", unsigned.Description)
+	}
+	fmt.Printf("  Chain ID:    %d\n", chainID)
+	fmt.Printf("  From:        %s\n", from.Hex())
+	if tx.To() != nil {
+		fmt.Printf("  To:          %s\n", tx.To().Hex())
+	} else {
+		fmt.Println("  To:          (contract creation)")
+	}
+	fmt.Printf("  Value:       %s ETH\n", formatEther(tx.Value()))
+	fmt.Printf("  Nonce:       %d\n", tx.Nonce())
+	fmt.Printf("  Gas limit:   %d\n", tx.Gas())
+	if tx.Type() == types.DynamicFeeTxType {
+		fmt.Printf("  Max fee:     %s gwei (priority %s gwei)\n", gas.FormatGwei(tx.GasFeeCap()), gas.FormatGwei(tx.GasTipCap()))
+	} else {
+		fmt.Printf("  Gas price:   %s gwei\n", gas.FormatGwei(tx.GasPrice()))
+	}
+	fmt.Printf("  Data:        %d bytes\n", len(tx.Data()))
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/validator"
+)
+
+// ErrNotRunning is returned when no validator node is listening on the admin socket
+var ErrNotRunning = errors.New("validator node is not running")
+
+// Client talks to the admin API of a running validator node
+type Client struct {
+	path string
+	http *http.Client
+}
+
+// NewClient creates a client for the admin socket at the given path
+func NewClient(path string) *Client {
+	return &Client{
+		path: path,
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			},
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Status returns the live status of the node
+func (c *Client) Status(ctx context.Context) (*validator.ValidatorStatus, error) {
+	var status validator.ValidatorStatus
+	if err := c.do(ctx, http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// Stop asks the node to shut down gracefully and returns its process ID. The
+// node drains in-flight work before exiting, so the call returns before it has stopped.
+func (c *Client) Stop(ctx context.Context) (int, error) {
+	var accepted struct {
+		PID int `json:"pid"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/stop", nil, &accepted); err != nil {
+		return 0, err
+	}
+
+	return accepted.PID, nil
+}
+
+// Activity returns the node's status with its recent requests and events
+func (c *Client) Activity(ctx context.Context) (*validator.Activity, error) {
+	var activity validator.Activity
+	if err := c.do(ctx, http.MethodGet, "/activity", nil, &activity); err != nil {
+		return nil, err
+	}
+
+	return &activity, nil
+}
+
+// SetIntakePaused stops or resumes taking requests off the node's queue
+func (c *Client) SetIntakePaused(ctx context.Context, paused bool) error {
+	var intake intakeRequest
+	return c.do(ctx, http.MethodPost, "/intake", intakeRequest{Paused: paused}, &intake)
+}
+
+// do sends a request to the admin API, with body encoded as JSON when it is
+// not nil, and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://admin"+path, payload)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return ErrNotRunning
+		}
+		return fmt.Errorf("admin API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		var failure errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error == "" {
+			return fmt.Errorf("admin API returned %s", resp.Status)
+		}
+		return fmt.Errorf("admin API: %s", failure.Error)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode admin API response: %v", err)
+	}
+
+	return nil
+}
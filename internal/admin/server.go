@@ -0,0 +1,206 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/dexponent/geth-validator/internal/validator"
+)
+
+// SocketName is the name of the admin socket created in the data directory
+const SocketName = "admin.sock"
+
+// SocketPath returns the admin socket of the node using the given configuration
+func SocketPath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, SocketName)
+}
+
+// Node is the part of a running validator exposed by the admin API
+type Node interface {
+	Status(ctx context.Context) (*validator.ValidatorStatus, error)
+	Activity(ctx context.Context) (*validator.Activity, error)
+	SetIntakePaused(ctx context.Context, paused bool) error
+}
+
+// intakeRequest is the body of POST /intake
+type intakeRequest struct {
+	Paused bool `json:"paused"`
+}
+
+// Server serves the admin API over a Unix socket, which only local users
+// with access to the data directory can reach
+type Server struct {
+	path     string
+	node     Node
+	listener net.Listener
+	server   *http.Server
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// errorResponse is the body of failed admin requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer creates an admin server for the node on the given socket path
+func NewServer(path string, node Node) *Server {
+	s := &Server{
+		path: path,
+		node: node,
+		stop: make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/stop", s.handleStop)
+	mux.HandleFunc("/activity", s.handleActivity)
+	mux.HandleFunc("/intake", s.handleIntake)
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return s
+}
+
+// Start listens on the socket and serves requests in the background
+func (s *Server) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create admin socket directory: %v", err)
+	}
+
+	// A socket left behind by a node that crashed is removed, but one that
+	// still answers belongs to a node that is running
+	if _, err := os.Stat(s.path); err == nil {
+		if conn, err := net.DialTimeout("unix", s.path, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("another validator node is already running with admin socket %s", s.path)
+		}
+		if err := os.Remove(s.path); err != nil {
+			return fmt.Errorf("failed to remove stale admin socket: %v", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on admin socket: %v", err)
+	}
+	if err := os.Chmod(s.path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict admin socket permissions: %v", err)
+	}
+	s.listener = listener
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Logger().Error("Admin API stopped", "err", err)
+		}
+	}()
+
+	logging.Logger().Info("Admin API listening", "socket", s.path)
+	return nil
+}
+
+// Close stops serving and removes the socket
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := s.server.Shutdown(ctx)
+	os.Remove(s.path)
+
+	return err
+}
+
+// StopRequested is closed when a client asks the node to shut down
+func (s *Server) StopRequested() <-chan struct{} {
+	return s.stop
+}
+
+// handleStop serves POST /stop, which starts a graceful shutdown
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+
+	s.stopOnce.Do(func() {
+		logging.Logger().Info("Shutdown requested through the admin API")
+		close(s.stop)
+	})
+
+	writeJSON(w, http.StatusAccepted, map[string]int{"pid": os.Getpid()})
+}
+
+// handleStatus serves GET /status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use GET"})
+		return
+	}
+
+	status, err := s.node.Status(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleActivity serves GET /activity, the live view used by the terminal UI
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use GET"})
+		return
+	}
+
+	activity, err := s.node.Activity(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, activity)
+}
+
+// handleIntake serves POST /intake, which pauses or resumes taking requests
+func (s *Server) handleIntake(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+
+	var intake intakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&intake); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := s.node.SetIntakePaused(r.Context(), intake.Paused); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, intake)
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logging.Logger().Warn("Failed to write admin response", "err", err)
+	}
+}
@@ -0,0 +1,327 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+)
+
+// Alert rules, as listed in ALERT_RULES
+const (
+	RuleTxReverted   = "tx_reverted"
+	RuleLowBalance   = "low_balance"
+	RuleHeadLag      = "head_lag"
+	RuleDeregistered = "deregistered"
+	// RuleTest marks the alert sent by 'alerts test'
+	RuleTest = "test"
+)
+
+// Severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// queueSize is how many alerts can wait for delivery before new ones are dropped
+const queueSize = 100
+
+// Alert is a notification sent to the webhook. This is also the payload of
+// the generic json format.
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Severity  string    `json:"severity"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	Node      string    `json:"node"`
+	RequestID string    `json:"requestId,omitempty"`
+	TxHash    string    `json:"txHash,omitempty"`
+	Block     uint64    `json:"block,omitempty"`
+	Resolved  bool      `json:"resolved"`
+	Time      time.Time `json:"time"`
+
+	// key identifies the condition the alert is about, for deduplication.
+	// One-shot alerts, about an event rather than a condition that can clear,
+	// have none.
+	key string
+}
+
+// details returns the alert text followed by what it is about, for chat messages
+func (a Alert) details() string {
+	lines := []string{a.Text, "Node: " + a.Node}
+	if a.RequestID != "" {
+		lines = append(lines, "Request: "+a.RequestID)
+	}
+	if a.TxHash != "" {
+		lines = append(lines, "Transaction: "+a.TxHash)
+	}
+	if a.Block != 0 {
+		lines = append(lines, fmt.Sprintf("Block: %d", a.Block))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// NewTestAlert returns the alert sent to check a webhook
+func NewTestAlert(node string) Alert {
+	return Alert{
+		Rule:     RuleTest,
+		Severity: SeverityInfo,
+		Title:    "Test alert",
+		Text:     "Alerts from this validator reach this webhook.",
+		Node:     node,
+		Time:     time.Now(),
+	}
+}
+
+// Manager applies the alert rules to the validator's events and delivers the
+// resulting alerts. An alert that keeps firing is only repeated once per
+// repeat interval, and once its condition clears a resolved alert is sent.
+type Manager struct {
+	webhook    *Webhook
+	node       string
+	rules      map[string]bool
+	minBalance *big.Int
+	maxHeadLag uint64
+	repeat     time.Duration
+	maxPerHour int
+
+	mutex sync.Mutex
+	// firing holds the conditions alerted about that have not cleared yet
+	firing map[string]condition
+	// sent holds when alerts were sent in the last hour, for rate limiting
+	sent       []time.Time
+	suppressed int
+
+	queue    chan Alert
+	done     chan struct{}
+	cancel   context.CancelFunc
+	unhandle func()
+}
+
+// condition is an alerted condition that has not cleared yet
+type condition struct {
+	title string
+	// last is when the condition was last alerted about
+	last time.Time
+}
+
+// New creates an alert manager for the node with the given address, set up
+// from the ALERT_ settings
+func New(cfg *config.Config, node string) *Manager {
+	rules := make(map[string]bool)
+	for _, rule := range cfg.EnabledAlertRules() {
+		rules[rule] = true
+	}
+
+	minBalance, _ := new(big.Float).Mul(big.NewFloat(cfg.AlertMinBalanceETH), big.NewFloat(1e18)).Int(nil)
+
+	return &Manager{
+		webhook:    NewWebhook(cfg.AlertWebhookURL, cfg.AlertWebhookFormat, int(cfg.AlertRetries)),
+		node:       node,
+		rules:      rules,
+		minBalance: minBalance,
+		maxHeadLag: cfg.AlertMaxHeadLag,
+		repeat:     time.Duration(cfg.AlertRepeatMinutes) * time.Minute,
+		maxPerHour: int(cfg.AlertMaxPerHour),
+		firing:     make(map[string]condition),
+		queue:      make(chan Alert, queueSize),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start applies the rules to every event published on bus and starts delivering alerts
+func (m *Manager) Start(bus *events.Bus) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	go m.deliver(ctx)
+	m.unhandle = bus.Handle(m.Observe)
+}
+
+// Close stops applying the rules and waits up to timeout for the queued
+// alerts to be delivered
+func (m *Manager) Close(timeout time.Duration) {
+	m.unhandle()
+	close(m.queue)
+
+	select {
+	case <-m.done:
+	case <-time.After(timeout):
+		m.cancel()
+		<-m.done
+	}
+	m.cancel()
+}
+
+// deliver sends queued alerts one at a time until the queue is closed
+func (m *Manager) deliver(ctx context.Context) {
+	defer close(m.done)
+
+	for alert := range m.queue {
+		logger := logging.Logger().With("rule", alert.Rule, "title", alert.Title)
+		if err := m.webhook.Send(ctx, alert); err != nil {
+			logger.Error("Failed to deliver alert", "err", err)
+			continue
+		}
+		logger.Info("Alert delivered")
+	}
+}
+
+// Observe applies the alert rules to an event. It runs as a handler on the
+// event bus, so it only queues alerts and never waits for delivery.
+func (m *Manager) Observe(event events.Event) {
+	switch e := event.(type) {
+	case *events.TxReverted:
+		if !m.rules[RuleTxReverted] {
+			return
+		}
+		title := "Verification result transaction reverted"
+		if e.RequestID == "" {
+			title = "Registration transaction reverted"
+		}
+		m.fire(Alert{
+			Rule:      RuleTxReverted,
+			Severity:  SeverityWarning,
+			Title:     title,
+			Text:      "Revert reason: " + e.Reason,
+			RequestID: e.RequestID,
+			TxHash:    e.TxHash,
+			Block:     e.Block,
+		})
+
+	case *events.BalanceChecked:
+		if !m.rules[RuleLowBalance] {
+			return
+		}
+		if e.Balance.Cmp(m.minBalance) >= 0 {
+			m.resolve(RuleLowBalance, fmt.Sprintf("Wallet balance is back to %s ETH", formatEther(e.Balance)))
+			return
+		}
+		m.fire(Alert{
+			Rule:     RuleLowBalance,
+			Severity: SeverityCritical,
+			Title:    "Wallet balance is low",
+			Text:     fmt.Sprintf("Balance of %s ETH is below %s ETH, transactions may fail for lack of fees", formatEther(e.Balance), formatEther(m.minBalance)),
+			key:      RuleLowBalance,
+		})
+
+	case *events.HeadChecked:
+		if !m.rules[RuleHeadLag] {
+			return
+		}
+		lag := e.Backlog()
+		if lag <= m.maxHeadLag {
+			m.resolve(RuleHeadLag, fmt.Sprintf("Back to %d blocks behind the head", lag))
+			return
+		}
+		m.fire(Alert{
+			Rule:     RuleHeadLag,
+			Severity: SeverityWarning,
+			Title:    "Validator is falling behind the chain",
+			Text:     fmt.Sprintf("Last processed block %d is %d blocks behind the head at %d, the limit is %d", e.LastProcessed, lag, e.Head, m.maxHeadLag),
+			Block:    e.LastProcessed,
+			key:      RuleHeadLag,
+		})
+
+	case *events.RegistrationChecked:
+		if !m.rules[RuleDeregistered] {
+			return
+		}
+		if e.Registered {
+			m.resolve(RuleDeregistered, "Validator is registered again")
+			return
+		}
+		m.fire(Alert{
+			Rule:     RuleDeregistered,
+			Severity: SeverityCritical,
+			Title:    "Validator is not registered",
+			Text:     "The protocol contract no longer lists this validator as registered, so its results are not accepted",
+			key:      RuleDeregistered,
+		})
+	}
+}
+
+// fire queues an alert unless its condition was alerted about within the
+// repeat interval. One-shot alerts are always queued and not remembered.
+func (m *Manager) fire(alert Alert) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	if alert.key == "" {
+		m.enqueue(alert, now)
+		return
+	}
+	if firing, ok := m.firing[alert.key]; ok && now.Sub(firing.last) < m.repeat {
+		return
+	}
+	m.firing[alert.key] = condition{title: alert.Title, last: now}
+
+	m.enqueue(alert, now)
+}
+
+// resolve sends a resolved alert if the condition was firing
+func (m *Manager) resolve(key, text string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	firing, ok := m.firing[key]
+	if !ok {
+		return
+	}
+	delete(m.firing, key)
+
+	m.enqueue(Alert{
+		Rule:     key,
+		Severity: SeverityInfo,
+		Title:    "Resolved: " + firing.title,
+		Text:     text,
+		Resolved: true,
+	}, time.Now())
+}
+
+// enqueue hands an alert to delivery, unless the hourly limit is reached or
+// the queue is full. Must be called with the mutex held.
+func (m *Manager) enqueue(alert Alert, now time.Time) {
+	// Drop the sends that left the rate limit window
+	recent := m.sent[:0]
+	for _, sent := range m.sent {
+		if now.Sub(sent) < time.Hour {
+			recent = append(recent, sent)
+		}
+	}
+	m.sent = recent
+
+	if len(m.sent) >= m.maxPerHour {
+		m.suppressed++
+		logging.Logger().Warn("Alert suppressed by the rate limit", "rule", alert.Rule, "title", alert.Title)
+		return
+	}
+
+	alert.Node = m.node
+	alert.Time = now
+	if m.suppressed > 0 {
+		alert.Text += fmt.Sprintf("\n(%d earlier alerts were suppressed by the rate limit)", m.suppressed)
+	}
+
+	select {
+	case m.queue <- alert:
+		m.sent = append(m.sent, now)
+		m.suppressed = 0
+	default:
+		logging.Logger().Warn("Alert queue is full, dropping alert", "rule", alert.Rule, "title", alert.Title)
+	}
+}
+
+// formatEther formats an amount in wei as ether
+func formatEther(wei *big.Int) string {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Text('f', 6)
+}
@@ -0,0 +1,264 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/events"
+)
+
+// sink is a webhook endpoint that keeps the alerts posted to it
+type sink struct {
+	*httptest.Server
+
+	mutex  sync.Mutex
+	alerts []Alert
+	// statuses are answered in turn before the sink starts accepting
+	statuses []int
+	attempts int
+}
+
+func newSink(t *testing.T, statuses ...int) *sink {
+	s := &sink{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.attempts++
+		if len(s.statuses) > 0 {
+			status := s.statuses[0]
+			s.statuses = s.statuses[1:]
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(status)
+			return
+		}
+
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("webhook received an invalid alert: %v", err)
+		}
+		s.alerts = append(s.alerts, alert)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// titles returns the titles of the received alerts in order
+func (s *sink) titles() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var titles []string
+	for _, alert := range s.alerts {
+		titles = append(titles, alert.Title)
+	}
+	return titles
+}
+
+// newTestManager returns a started manager posting to the sink and the bus it observes
+func newTestManager(s *sink, modify func(cfg *config.Config)) (*Manager, *events.Bus) {
+	cfg := &config.Config{
+		AlertWebhookURL:    s.URL,
+		AlertWebhookFormat: FormatJSON,
+		AlertRules:         strings.Join(config.AlertRules, ","),
+		AlertMinBalanceETH: 1,
+		AlertMaxHeadLag:    20,
+		AlertRepeatMinutes: 60,
+		AlertMaxPerHour:    20,
+	}
+	if modify != nil {
+		modify(cfg)
+	}
+
+	bus := events.NewBus()
+	m := New(cfg, "0xnode")
+	m.Start(bus)
+
+	return m, bus
+}
+
+func reverted(id string) *events.TxReverted {
+	return &events.TxReverted{Header: events.Header{RequestID: id, TxHash: "0x7a"}, Reason: "already submitted"}
+}
+
+func balance(eth int64) *events.BalanceChecked {
+	return &events.BalanceChecked{Balance: new(big.Int).Mul(big.NewInt(eth), big.NewInt(1e18))}
+}
+
+func TestManager(t *testing.T) {
+	const (
+		lowBalance = "Wallet balance is low"
+		revert     = "Verification result transaction reverted"
+		headLag    = "Validator is falling behind the chain"
+	)
+
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+		events []events.Event
+		want   []string
+	}{
+		{
+			name:   "a condition is alerted once while it lasts",
+			events: []events.Event{balance(0), balance(0), balance(0)},
+			want:   []string{lowBalance},
+		},
+		{
+			name:   "a cleared condition is resolved",
+			events: []events.Event{balance(0), balance(2), balance(2)},
+			want:   []string{lowBalance, "Resolved: " + lowBalance},
+		},
+		{
+			name:   "a condition that fires again after clearing is alerted again",
+			events: []events.Event{balance(0), balance(2), balance(0)},
+			want:   []string{lowBalance, "Resolved: " + lowBalance, lowBalance},
+		},
+		{
+			name:   "nothing is resolved that was not firing",
+			events: []events.Event{balance(2), &events.RegistrationChecked{Registered: true}},
+		},
+		{
+			name:   "every revert is alerted",
+			events: []events.Event{reverted("1"), reverted("1"), reverted("")},
+			want:   []string{revert, revert, "Registration transaction reverted"},
+		},
+		{
+			name: "head lag above the limit",
+			events: []events.Event{
+				&events.HeadChecked{Head: 120, LastProcessed: 100},
+				&events.HeadChecked{Head: 130, LastProcessed: 100},
+				&events.HeadChecked{Head: 130, LastProcessed: 125},
+			},
+			want: []string{headLag, "Resolved: " + headLag},
+		},
+		{
+			name:   "disabled rules are not alerted",
+			modify: func(cfg *config.Config) { cfg.AlertRules = "head_lag" },
+			events: []events.Event{balance(0), reverted("1"), &events.RegistrationChecked{}},
+		},
+		{
+			name:   "the hourly limit suppresses further alerts",
+			modify: func(cfg *config.Config) { cfg.AlertMaxPerHour = 2 },
+			events: []events.Event{reverted("1"), reverted("2"), balance(0), reverted("3")},
+			want:   []string{revert, revert},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSink(t)
+			m, bus := newTestManager(s, tt.modify)
+			for _, event := range tt.events {
+				bus.Publish(event)
+			}
+			m.Close(5 * time.Second)
+
+			got := s.titles()
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("received %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerRepeatAndRateLimitWindow(t *testing.T) {
+	s := newSink(t)
+	m, bus := newTestManager(s, func(cfg *config.Config) { cfg.AlertMaxPerHour = 1 })
+
+	bus.Publish(balance(0))
+	bus.Publish(reverted("1"))
+
+	// Once the repeat interval and the rate limit window have passed, the
+	// condition is alerted again with a note about what was suppressed
+	m.mutex.Lock()
+	for i := range m.sent {
+		m.sent[i] = m.sent[i].Add(-time.Hour)
+	}
+	firing := m.firing[RuleLowBalance]
+	firing.last = firing.last.Add(-time.Hour)
+	m.firing[RuleLowBalance] = firing
+	m.mutex.Unlock()
+
+	bus.Publish(balance(0))
+	m.Close(5 * time.Second)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.alerts) != 2 {
+		t.Fatalf("received %d alerts, want 2", len(s.alerts))
+	}
+	if !strings.Contains(s.alerts[1].Text, "(1 earlier alerts were suppressed by the rate limit)") {
+		t.Errorf("repeated alert text %q does not mention the suppressed alert", s.alerts[1].Text)
+	}
+	for _, alert := range s.alerts {
+		if alert.Node != "0xnode" || alert.Time.IsZero() {
+			t.Errorf("alert from node %q at %v, want the manager's node and a time", alert.Node, alert.Time)
+		}
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+
+		wantErr      string
+		wantAttempts int
+	}{
+		{
+			name:         "delivered",
+			wantAttempts: 1,
+		},
+		{
+			name:         "rate limiting is retried",
+			statuses:     []int{http.StatusTooManyRequests},
+			retries:      1,
+			wantAttempts: 2,
+		},
+		{
+			name:         "server errors are retried until the retries run out",
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway},
+			retries:      1,
+			wantErr:      "webhook failed: 502",
+			wantAttempts: 2,
+		},
+		{
+			name:         "client errors are not retried",
+			statuses:     []int{http.StatusNotFound},
+			retries:      3,
+			wantErr:      "webhook rejected the alert: 404",
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSink(t, tt.statuses...)
+			err := NewWebhook(s.URL, FormatJSON, tt.retries).Send(context.Background(), NewTestAlert("0xnode"))
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+			}
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			if s.attempts != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", s.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
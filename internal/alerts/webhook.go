@@ -0,0 +1,129 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Payload formats
+const (
+	FormatJSON    = "json"
+	FormatSlack   = "slack"
+	FormatDiscord = "discord"
+)
+
+// Delivery timing
+const (
+	requestTimeout = 10 * time.Second
+	firstBackoff   = time.Second
+	maxBackoff     = time.Minute
+)
+
+// discordLimit is the maximum length of a Discord message
+const discordLimit = 2000
+
+// Webhook posts alerts to an HTTP endpoint
+type Webhook struct {
+	url     string
+	format  string
+	retries int
+	client  *http.Client
+}
+
+// NewWebhook creates a webhook posting alerts in the given format, trying
+// each one retries more times if delivery fails
+func NewWebhook(url, format string, retries int) *Webhook {
+	return &Webhook{
+		url:     url,
+		format:  format,
+		retries: retries,
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Send posts an alert, retrying with exponential backoff on network errors,
+// rate limiting (429) and server errors (5xx). Other client errors are not retried.
+func (w *Webhook) Send(ctx context.Context, alert Alert) error {
+	body, err := w.payload(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %v", err)
+	}
+
+	backoff := firstBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= w.retries {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%v (gave up: %v)", err, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// post makes a single delivery attempt. On failure it returns how long the
+// endpoint asked to wait before retrying, or -1 if retrying is pointless.
+func (w *Webhook) post(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return -1, fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(seconds) * time.Second, fmt.Errorf("webhook is rate limiting: %s", resp.Status)
+	case resp.StatusCode >= 500:
+		return 0, fmt.Errorf("webhook failed: %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("webhook rejected the alert: %s", resp.Status)
+	}
+}
+
+// payload encodes an alert in the webhook's format
+func (w *Webhook) payload(alert Alert) ([]byte, error) {
+	switch w.format {
+	case FormatSlack:
+		return json.Marshal(map[string]string{
+			"text": fmt.Sprintf("*[%s] %s*\n%s", alert.Severity, alert.Title, alert.details()),
+		})
+	case FormatDiscord:
+		content := fmt.Sprintf("**[%s] %s**\n%s", alert.Severity, alert.Title, alert.details())
+		if runes := []rune(content); len(runes) > discordLimit {
+			content = string(runes[:discordLimit])
+		}
+		return json.Marshal(map[string]string{"content": content})
+	default:
+		return json.Marshal(alert)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/dexponent/geth-validator/internal/logging"
 )
 
 // Task represents a computation task
@@ -99,6 +101,8 @@ func (e *Engine) WaitForResult(taskID string, timeout time.Duration) ([]byte, er
 			return e.GetTaskResult(taskID)
 		} else if status == "failed" {
 			return nil, errors.New("task failed")
+		} else if status == "cancelled" {
+			return nil, errors.New("task was cancelled")
 		}
 
 		// Wait a bit before checking again
@@ -108,6 +112,17 @@ func (e *Engine) WaitForResult(taskID string, timeout time.Duration) ([]byte, er
 	return nil, errors.New("timeout waiting for task completion")
 }
 
+// CancelTask abandons a task that has not completed; its result is never computed
+func (e *Engine) CancelTask(taskID string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if task, ok := e.tasks[taskID]; ok && task.Status == "pending" {
+		task.Status = "cancelled"
+		task.Finished = time.Now()
+	}
+}
+
 // processTask processes a computation task
 func (e *Engine) processTask(taskID string) {
 	// Simulate computation time
@@ -117,7 +132,7 @@ func (e *Engine) processTask(taskID string) {
 	defer e.mutex.Unlock()
 
 	task, ok := e.tasks[taskID]
-	if !ok {
+	if !ok || task.Status == "cancelled" {
 		return
 	}
 
@@ -130,4 +145,6 @@ func (e *Engine) processTask(taskID string) {
 	task.Status = "completed"
 	task.Result = result
 	task.Finished = time.Now()
+
+	logging.Logger().Debug("Computation completed", "taskID", taskID, "duration", task.Finished.Sub(task.Created))
 }
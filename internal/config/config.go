@@ -1,75 +1,255 @@
 package config
 
 import (
-	"errors"
-	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
 )
 
-// Config holds the configuration for the validator node
+// Config holds the configuration for the validator node. The yaml keys match
+// the config file keys, which are the lower-case environment variable names.
 type Config struct {
-	BaseRPCURL        string
-	DXPContractAddress string
-	WalletPrivateKey  string
-	GasPriceMultiplier float64
-	GasLimit          uint64
-	ChainID           int64
-	LogLevel          string
-	DataDir           string
+	Network    string `yaml:"network"`
+	BaseRPCURL string `yaml:"base_rpc_url"`
+	// FallbackRPCURLs is a comma-separated list of endpoints the node fails
+	// over to; see RPCURLs
+	FallbackRPCURLs    string `yaml:"fallback_rpc_urls"`
+	DXPContractAddress string `yaml:"dxp_contract_address"`
+	DXPTokenAddress    string `yaml:"dxp_token_address"`
+	ExplorerURL        string `yaml:"explorer_url"`
+	WalletPrivateKey   string `yaml:"wallet_private_key"`
+	// Encrypted keystore, preferred over WalletPrivateKey. The passphrase is
+	// read from KeystorePasswordFile, KeystorePassword or an interactive prompt.
+	KeystoreFile         string `yaml:"keystore_file"`
+	KeystoreDir          string `yaml:"keystore_dir"`
+	KeystorePasswordFile string `yaml:"keystore_password_file"`
+	KeystorePassword     string `yaml:"keystore_password"`
+	// External signer, used instead of a local key when SignerURL is set.
+	// SignerAPI is "clef" (account_signTransaction) or "web3signer"
+	// (eth_signTransaction).
+	SignerURL     string `yaml:"signer_url"`
+	SignerAddress string `yaml:"signer_address"`
+	SignerAPI     string `yaml:"signer_api"`
+	// RewardAddress is the cold account that claims rewards. The signing key
+	// above is then only the hot operator key that registers and submits
+	// proofs, and claims are signed offline.
+	RewardAddress      string  `yaml:"reward_address"`
+	GasPriceMultiplier float64 `yaml:"gas_price_multiplier"`
+	GasLimit           uint64  `yaml:"gas_limit"`
+	// GasLimitMargin is the fraction added on top of estimated gas; GasLimit
+	// is only used when estimation fails
+	GasLimitMargin float64 `yaml:"gas_limit_margin"`
+	// EIP-1559 fee settings, in gwei. Zero disables the corresponding cap.
+	MaxFeePerGasGwei         float64 `yaml:"max_fee_per_gas_gwei"`
+	MaxPriorityFeePerGasGwei float64 `yaml:"max_priority_fee_per_gas_gwei"`
+	GasFeeCeilingGwei        float64 `yaml:"gas_fee_ceiling_gwei"`
+	ChainID                  int64   `yaml:"chain_id"`
+	LogLevel                 string  `yaml:"log_level"`
+	// LogFormat is text or json. LogFile is rotated at LogMaxSizeMB, keeping
+	// LogMaxBackups old files; the node logs to stderr when it is empty.
+	LogFormat     string `yaml:"log_format"`
+	LogFile       string `yaml:"log_file"`
+	LogMaxSizeMB  int64  `yaml:"log_max_size_mb"`
+	LogMaxBackups int64  `yaml:"log_max_backups"`
+	DataDir       string `yaml:"data_dir"`
+	// MetricsAddr is the host:port serving Prometheus metrics; empty disables them
+	MetricsAddr string `yaml:"metrics_addr"`
+	// Alerts are posted to AlertWebhookURL, in the generic json format or as a
+	// slack or discord message; no alerts are sent when it is empty. AlertRules
+	// is a comma-separated list of the rules to apply.
+	AlertWebhookURL    string  `yaml:"alert_webhook_url"`
+	AlertWebhookFormat string  `yaml:"alert_webhook_format"`
+	AlertRules         string  `yaml:"alert_rules"`
+	AlertMinBalanceETH float64 `yaml:"alert_min_balance_eth"`
+	AlertMaxHeadLag    uint64  `yaml:"alert_max_head_lag"`
+	// An alert that keeps firing is repeated every AlertRepeatMinutes; at most
+	// AlertMaxPerHour alerts are sent, each tried AlertRetries more times on failure
+	AlertRepeatMinutes int64 `yaml:"alert_repeat_minutes"`
+	AlertMaxPerHour    int64 `yaml:"alert_max_per_hour"`
+	AlertRetries       int64 `yaml:"alert_retries"`
+	// At most SchedulerMaxConcurrent verifications run at a time, and at most
+	// SchedulerMaxPerRequester for the same requester. A queued request is
+	// dropped once less than SchedulerMinTimeLeftSeconds remain before its deadline.
+	SchedulerMaxConcurrent      int64 `yaml:"scheduler_max_concurrent"`
+	SchedulerMaxPerRequester    int64 `yaml:"scheduler_max_per_requester"`
+	SchedulerMinTimeLeftSeconds int64 `yaml:"scheduler_min_time_left_seconds"`
+	// Requests whose event carries no deadline expire RequestTTLSeconds after
+	// their block, or never when it is 0. A result is not submitted with less
+	// than RequestSubmitMarginSeconds left before the deadline.
+	RequestTTLSeconds          int64 `yaml:"request_ttl_seconds"`
+	RequestSubmitMarginSeconds int64 `yaml:"request_submit_margin_seconds"`
+	// RPC endpoints are checked every RPCHealthCheckSeconds and count as
+	// unhealthy when RPCMaxBlockLag blocks behind the others. Registration,
+	// request blocks and receipts are only believed when RPCQuorum endpoints agree.
+	RPCHealthCheckSeconds int64  `yaml:"rpc_health_check_seconds"`
+	RPCMaxBlockLag        uint64 `yaml:"rpc_max_block_lag"`
+	RPCQuorum             int64  `yaml:"rpc_quorum"`
+
+	// problems holds settings that could not be parsed, reported by Validate
+	problems []string
+	// accountOnly limits Validate to the settings the account commands use
+	accountOnly bool
+	// keyOptional skips the signing key checks in Validate
+	keyOptional bool
+}
+
+// Options holds settings given on the command line, which take precedence
+// over environment variables and the config file
+type Options struct {
+	// ConfigFile is the path of a YAML or TOML config file
+	ConfigFile string
+	// Network selects a network profile by name
+	Network string
+	// LogLevel overrides the configured log level
+	LogLevel string
+	// AccountOnly allows loading without a network, contract or signing key,
+	// for the commands that manage keys
+	AccountOnly bool
+	// KeyOptional allows loading without a signing key, for the commands that
+	// only build unsigned transactions or broadcast signed ones
+	KeyOptional bool
+}
+
+// EnabledAlertRules returns the alert rules listed in AlertRules
+func (c *Config) EnabledAlertRules() []string {
+	var rules []string
+	for _, rule := range strings.Split(c.AlertRules, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// RPCURLs returns the RPC endpoints to use: BaseRPCURL first, then the
+// fallbacks, without duplicates
+func (c *Config) RPCURLs() []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, rpcURL := range append([]string{c.BaseRPCURL}, strings.Split(c.FallbackRPCURLs, ",")...) {
+		if rpcURL = strings.TrimSpace(rpcURL); rpcURL != "" && !seen[rpcURL] {
+			seen[rpcURL] = true
+			urls = append(urls, rpcURL)
+		}
+	}
+
+	return urls
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
-	// Get required environment variables
-	baseRPCURL := os.Getenv("BASE_RPC_URL")
-	dxpContractAddress := os.Getenv("DXP_CONTRACT_ADDRESS")
-	walletPrivateKey := os.Getenv("WALLET_PRIVATE_KEY")
-
-	// Check required variables
-	if baseRPCURL == "" || dxpContractAddress == "" || walletPrivateKey == "" {
-		return nil, errors.New("missing required environment variables: BASE_RPC_URL, DXP_CONTRACT_ADDRESS, WALLET_PRIVATE_KEY")
+	return Load(Options{})
+}
+
+// Load loads configuration with the precedence command line options,
+// environment variables, config file and then defaults
+func Load(opts Options) (*Config, error) {
+	src := &source{
+		flags: map[string]string{
+			"NETWORK":   opts.Network,
+			"LOG_LEVEL": opts.LogLevel,
+		},
 	}
 
-	// Get optional variables with defaults
-	gasPriceMultiplier := 1.0
-	if value := os.Getenv("GAS_PRICE_MULTIPLIER"); value != "" {
-		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
-			gasPriceMultiplier = parsed
+	var fileNetworks map[string]Network
+	if opts.ConfigFile != "" {
+		values, networks, err := readConfigFile(opts.ConfigFile)
+		if err != nil {
+			return nil, err
 		}
+		src.file = values
+		src.fileName = opts.ConfigFile
+		fileNetworks = networks
 	}
 
-	gasLimit := uint64(3000000)
-	if value := os.Getenv("GAS_LIMIT"); value != "" {
-		if parsed, err := strconv.ParseUint(value, 10, 64); err == nil {
-			gasLimit = parsed
-		}
+	// Resolve the network profile, which provides defaults for the chain ID,
+	// RPC endpoint and contract addresses
+	chainID := src.int("CHAIN_ID", 0)
+
+	networks, err := LoadNetworks(src.getOr("NETWORKS_FILE", DefaultNetworksFile))
+	if err != nil {
+		return nil, err
+	}
+	for name, network := range fileNetworks {
+		networks[name] = mergeNetwork(networks[name], network)
 	}
 
-	chainID := int64(8453) // Default to Base chain ID
-	if value := os.Getenv("CHAIN_ID"); value != "" {
-		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
-			chainID = parsed
+	network, err := resolveNetwork(networks, src.get("NETWORK"), chainID)
+	if err != nil {
+		src.problemf("%v", err)
+		network = Network{Name: src.get("NETWORK"), ChainID: chainID}
+	}
+
+	defaultRPCURL := ""
+	if len(network.RPCURLs) > 0 {
+		defaultRPCURL = network.RPCURLs[0]
+	}
+	baseRPCURL := src.getOr("BASE_RPC_URL", defaultRPCURL)
+
+	// The network's other endpoints are the default fallbacks
+	var fallbackRPCURLs []string
+	for _, rpcURL := range network.RPCURLs {
+		if rpcURL != baseRPCURL {
+			fallbackRPCURLs = append(fallbackRPCURLs, rpcURL)
 		}
 	}
 
-	logLevel := "info"
-	if value := os.Getenv("LOG_LEVEL"); value != "" {
-		logLevel = value
+	cfg := &Config{
+		Network:                     network.Name,
+		BaseRPCURL:                  baseRPCURL,
+		FallbackRPCURLs:             src.getOr("FALLBACK_RPC_URLS", strings.Join(fallbackRPCURLs, ",")),
+		DXPContractAddress:          src.getOr("DXP_CONTRACT_ADDRESS", network.DXPContractAddress),
+		DXPTokenAddress:             src.getOr("DXP_TOKEN_ADDRESS", network.DXPTokenAddress),
+		ExplorerURL:                 network.ExplorerURL,
+		WalletPrivateKey:            src.get("WALLET_PRIVATE_KEY"),
+		KeystoreFile:                src.get("KEYSTORE_FILE"),
+		KeystorePasswordFile:        src.get("KEYSTORE_PASSWORD_FILE"),
+		KeystorePassword:            src.get("KEYSTORE_PASSWORD"),
+		SignerURL:                   src.get("SIGNER_URL"),
+		SignerAddress:               src.get("SIGNER_ADDRESS"),
+		SignerAPI:                   src.getOr("SIGNER_API", "clef"),
+		RewardAddress:               src.get("REWARD_ADDRESS"),
+		GasPriceMultiplier:          src.float("GAS_PRICE_MULTIPLIER", 1.0),
+		GasLimit:                    src.uint("GAS_LIMIT", 3000000),
+		GasLimitMargin:              src.float("GAS_LIMIT_MARGIN", 0.2),
+		MaxFeePerGasGwei:            src.float("MAX_FEE_PER_GAS_GWEI", 0),
+		MaxPriorityFeePerGasGwei:    src.float("MAX_PRIORITY_FEE_PER_GAS_GWEI", 0),
+		GasFeeCeilingGwei:           src.float("GAS_FEE_CEILING_GWEI", 0),
+		ChainID:                     network.ChainID,
+		LogLevel:                    src.getOr("LOG_LEVEL", "info"),
+		LogFormat:                   src.getOr("LOG_FORMAT", "text"),
+		LogFile:                     src.get("LOG_FILE"),
+		LogMaxSizeMB:                src.int("LOG_MAX_SIZE_MB", 100),
+		LogMaxBackups:               src.int("LOG_MAX_BACKUPS", 5),
+		DataDir:                     src.getOr("DATA_DIR", "./data"),
+		MetricsAddr:                 src.get("METRICS_ADDR"),
+		AlertWebhookURL:             src.get("ALERT_WEBHOOK_URL"),
+		AlertWebhookFormat:          src.getOr("ALERT_WEBHOOK_FORMAT", "json"),
+		AlertRules:                  src.getOr("ALERT_RULES", strings.Join(AlertRules, ",")),
+		AlertMinBalanceETH:          src.float("ALERT_MIN_BALANCE_ETH", 0.001),
+		AlertMaxHeadLag:             src.uint("ALERT_MAX_HEAD_LAG", 20),
+		AlertRepeatMinutes:          src.int("ALERT_REPEAT_MINUTES", 60),
+		AlertMaxPerHour:             src.int("ALERT_MAX_PER_HOUR", 20),
+		AlertRetries:                src.int("ALERT_RETRIES", 3),
+		SchedulerMaxConcurrent:      src.int("SCHEDULER_MAX_CONCURRENT", 4),
+		SchedulerMaxPerRequester:    src.int("SCHEDULER_MAX_PER_REQUESTER", 2),
+		SchedulerMinTimeLeftSeconds: src.int("SCHEDULER_MIN_TIME_LEFT_SECONDS", 60),
+		RequestTTLSeconds:           src.int("REQUEST_TTL_SECONDS", 3600),
+		RequestSubmitMarginSeconds:  src.int("REQUEST_SUBMIT_MARGIN_SECONDS", 30),
+		RPCHealthCheckSeconds:       src.int("RPC_HEALTH_CHECK_SECONDS", 15),
+		RPCMaxBlockLag:              src.uint("RPC_MAX_BLOCK_LAG", 5),
+		RPCQuorum:                   src.int("RPC_QUORUM", 1),
+		accountOnly:                 opts.AccountOnly,
+		keyOptional:                 opts.KeyOptional,
 	}
+	cfg.KeystoreDir = src.getOr("KEYSTORE_DIR", filepath.Join(cfg.DataDir, "keystore"))
+
+	src.unusedFileKeys()
+	cfg.problems = src.problems
 
-	dataDir := "./data"
-	if value := os.Getenv("DATA_DIR"); value != "" {
-		dataDir = value
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	return &Config{
-		BaseRPCURL:        baseRPCURL,
-		DXPContractAddress: dxpContractAddress,
-		WalletPrivateKey:  walletPrivateKey,
-		GasPriceMultiplier: gasPriceMultiplier,
-		GasLimit:          gasLimit,
-		ChainID:           chainID,
-		LogLevel:          logLevel,
-		DataDir:           dataDir,
-	}, nil
+	return cfg, nil
 }
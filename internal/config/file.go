@@ -0,0 +1,194 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// source looks up settings by their environment variable name. Command line
+// flags take precedence over the environment, which takes precedence over the
+// config file; callers supply the defaults. Values that fail to parse are
+// collected as problems instead of being silently replaced by defaults.
+type source struct {
+	flags    map[string]string
+	file     map[string]string
+	fileName string
+	used     map[string]bool
+	problems []string
+}
+
+// lookup returns the value of a setting and where it came from, or an empty
+// value if it is not set anywhere
+func (s *source) lookup(name string) (string, string) {
+	if s.used == nil {
+		s.used = make(map[string]bool)
+	}
+	s.used[name] = true
+
+	if value := s.flags[name]; value != "" {
+		return value, "command line flag"
+	}
+	if value := os.Getenv(name); value != "" {
+		return value, "environment"
+	}
+	if value := s.file[name]; value != "" {
+		return value, "config file " + s.fileName
+	}
+
+	return "", ""
+}
+
+// get returns the value of a setting, or an empty string if it is not set anywhere
+func (s *source) get(name string) string {
+	value, _ := s.lookup(name)
+	return value
+}
+
+// getOr returns the value of a setting, or the fallback if it is not set anywhere
+func (s *source) getOr(name, fallback string) string {
+	if value := s.get(name); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+// float returns a setting parsed as a decimal number
+func (s *source) float(name string, fallback float64) float64 {
+	value, origin := s.lookup(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.problemf("%s from %s: %q is not a number", name, origin, value)
+		return fallback
+	}
+
+	return parsed
+}
+
+// uint returns a setting parsed as a non-negative whole number
+func (s *source) uint(name string, fallback uint64) uint64 {
+	value, origin := s.lookup(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		s.problemf("%s from %s: %q is not a non-negative whole number", name, origin, value)
+		return fallback
+	}
+
+	return parsed
+}
+
+// int returns a setting parsed as a whole number
+func (s *source) int(name string, fallback int64) int64 {
+	value, origin := s.lookup(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		s.problemf("%s from %s: %q is not a whole number", name, origin, value)
+		return fallback
+	}
+
+	return parsed
+}
+
+// problemf records a problem with a setting
+func (s *source) problemf(format string, args ...interface{}) {
+	s.problems = append(s.problems, fmt.Sprintf(format, args...))
+}
+
+// unusedFileKeys reports config file keys that no setting looked up, which
+// are usually typos
+func (s *source) unusedFileKeys() {
+	keys := make([]string, 0, len(s.file))
+	for name := range s.file {
+		if !s.used[name] {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		s.problemf("unknown key %q in config file %s", strings.ToLower(name), s.fileName)
+	}
+}
+
+// readConfigFile reads a YAML or TOML config file. Keys are the lower-case
+// names of the environment variables they replace, e.g. gas_limit for
+// GAS_LIMIT; the networks section holds network profiles.
+func readConfigFile(path string) (map[string]string, map[string]Network, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var raw map[string]interface{}
+	var profiles networksFile
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+		if err := yaml.Unmarshal(data, &profiles); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse networks in config file %s: %v", path, err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+		if err := toml.Unmarshal(data, &profiles); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse networks in config file %s: %v", path, err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", ext)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if key == "networks" {
+			continue
+		}
+		values[strings.ToUpper(key)] = formatValue(value)
+	}
+
+	for name, network := range profiles.Networks {
+		network.Name = name
+		profiles.Networks[name] = network
+	}
+
+	return values, profiles.Networks, nil
+}
+
+// formatValue renders a scalar or list from a config file the way it would be
+// written in an environment variable
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, formatValue(item))
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testKey      = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testContract = "0x5FbDB2315678afecb367f032d93F642f64180aa3"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		env   map[string]string
+		file  string
+		check func(t *testing.T, c *Config)
+		// wantErr is a fragment of the expected error, if loading should fail
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, c *Config) {
+				if c.GasLimit != 3000000 || c.LogLevel != "info" {
+					t.Errorf("gas limit %d, log level %q, want the defaults", c.GasLimit, c.LogLevel)
+				}
+			},
+		},
+		{
+			name: "config file overrides defaults",
+			file: "gas_limit: 400000\nlog_level: error\n",
+			check: func(t *testing.T, c *Config) {
+				if c.GasLimit != 400000 || c.LogLevel != "error" {
+					t.Errorf("gas limit %d, log level %q, want the config file's", c.GasLimit, c.LogLevel)
+				}
+			},
+		},
+		{
+			name: "environment overrides the config file",
+			env:  map[string]string{"GAS_LIMIT": "500000", "LOG_LEVEL": "warn"},
+			file: "gas_limit: 400000\nlog_level: error\n",
+			check: func(t *testing.T, c *Config) {
+				if c.GasLimit != 500000 || c.LogLevel != "warn" {
+					t.Errorf("gas limit %d, log level %q, want the environment's", c.GasLimit, c.LogLevel)
+				}
+			},
+		},
+		{
+			name: "flags override the environment",
+			opts: Options{LogLevel: "debug"},
+			env:  map[string]string{"LOG_LEVEL": "warn"},
+			file: "log_level: error\n",
+			check: func(t *testing.T, c *Config) {
+				if c.LogLevel != "debug" {
+					t.Errorf("log level %q, want the flag's", c.LogLevel)
+				}
+			},
+		},
+		{
+			name: "network profile supplies the endpoint and fallbacks",
+			file: "networks:\n  dev:\n    rpc_urls: [\"http://127.0.0.1:9545\", \"http://127.0.0.1:9546\"]\n",
+			check: func(t *testing.T, c *Config) {
+				if c.ChainID != 31337 || c.BaseRPCURL != "http://127.0.0.1:9545" || c.FallbackRPCURLs != "http://127.0.0.1:9546" {
+					t.Errorf("chain %d, endpoint %q, fallbacks %q, want the profile's", c.ChainID, c.BaseRPCURL, c.FallbackRPCURLs)
+				}
+			},
+		},
+		{
+			name: "environment overrides the network profile",
+			env:  map[string]string{"BASE_RPC_URL": "http://127.0.0.1:7545"},
+			check: func(t *testing.T, c *Config) {
+				if c.BaseRPCURL != "http://127.0.0.1:7545" || c.FallbackRPCURLs != "http://127.0.0.1:8545" {
+					t.Errorf("endpoint %q, fallbacks %q, want the environment's endpoint first", c.BaseRPCURL, c.FallbackRPCURLs)
+				}
+			},
+		},
+		{
+			name:    "unparsable values name their source",
+			file:    "gas_limit: lots\n",
+			wantErr: `GAS_LIMIT from config file`,
+		},
+		{
+			name:    "environment values are blamed on the environment",
+			env:     map[string]string{"GAS_PRICE_MULTIPLIER": "high"},
+			wantErr: `GAS_PRICE_MULTIPLIER from environment: "high" is not a number`,
+		},
+		{
+			name:    "unknown config file keys are reported",
+			file:    "gas_limt: 400000\n",
+			wantErr: `unknown key "gas_limt"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Start from an environment with only the required settings
+			for _, name := range []string{"GAS_LIMIT", "GAS_PRICE_MULTIPLIER", "LOG_LEVEL", "BASE_RPC_URL", "FALLBACK_RPC_URLS", "CHAIN_ID"} {
+				t.Setenv(name, "")
+			}
+			t.Setenv("NETWORK", "dev")
+			t.Setenv("DXP_CONTRACT_ADDRESS", testContract)
+			t.Setenv("WALLET_PRIVATE_KEY", testKey)
+			t.Setenv("NETWORKS_FILE", filepath.Join(t.TempDir(), "none.yaml"))
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			opts := tt.opts
+			if tt.file != "" {
+				opts.ConfigFile = filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(opts.ConfigFile, []byte(tt.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c, err := Load(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tt.check(t, c)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultNetwork is the network used when neither NETWORK nor CHAIN_ID is set
+const DefaultNetwork = "base"
+
+// DefaultNetworksFile is the network profiles file read when NETWORKS_FILE is not set
+const DefaultNetworksFile = "networks.yaml"
+
+// Network is a named deployment of the Dexponent protocol
+type Network struct {
+	Name               string   `yaml:"-" toml:"-"`
+	ChainID            int64    `yaml:"chain_id" toml:"chain_id"`
+	RPCURLs            []string `yaml:"rpc_urls" toml:"rpc_urls"`
+	DXPContractAddress string   `yaml:"contract_address" toml:"contract_address"`
+	DXPTokenAddress    string   `yaml:"token_address" toml:"token_address"`
+	ExplorerURL        string   `yaml:"explorer_url" toml:"explorer_url"`
+}
+
+// networksFile is the layout of the networks section of a profiles or config file
+type networksFile struct {
+	Networks map[string]Network `yaml:"networks" toml:"networks"`
+}
+
+// Networks holds the built-in network profiles. Profiles in a networks file
+// are merged on top of these by LoadNetworks.
+var Networks = map[string]Network{
+	"base": {
+		Name:        "base",
+		ChainID:     8453,
+		RPCURLs:     []string{"https://mainnet.base.org"},
+		ExplorerURL: "https://basescan.org",
+	},
+	"base-sepolia": {
+		Name:        "base-sepolia",
+		ChainID:     84532,
+		RPCURLs:     []string{"https://sepolia.base.org"},
+		ExplorerURL: "https://sepolia.basescan.org",
+	},
+	"sepolia": {
+		Name:               "sepolia",
+		ChainID:            11155111,
+		DXPContractAddress: "0x8437ab3cCb485D2a3793F97f58c6e3F926039684",
+		DXPTokenAddress:    "0x4ed4E862860beD51a9570b96d89aF5E1B0Efefed",
+		ExplorerURL:        "https://sepolia.etherscan.io",
+	},
+	"dev": {
+		Name:    "dev",
+		ChainID: 31337,
+		RPCURLs: []string{"http://127.0.0.1:8545"},
+	},
+}
+
+// ChainIDReader is implemented by clients that can report the chain they serve
+type ChainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// LoadNetworks returns the built-in network profiles merged with the profiles
+// defined in the given YAML file. A missing file leaves the built-ins unchanged.
+func LoadNetworks(path string) (map[string]Network, error) {
+	networks := make(map[string]Network, len(Networks))
+	for name, network := range Networks {
+		networks[name] = network
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return networks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read networks file: %v", err)
+	}
+
+	var file networksFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse networks file %s: %v", path, err)
+	}
+
+	for name, network := range file.Networks {
+		network.Name = name
+		networks[name] = mergeNetwork(networks[name], network)
+	}
+
+	return networks, nil
+}
+
+// mergeNetwork overlays the fields set in override on top of base
+func mergeNetwork(base, override Network) Network {
+	base.Name = override.Name
+	if override.ChainID != 0 {
+		base.ChainID = override.ChainID
+	}
+	if len(override.RPCURLs) > 0 {
+		base.RPCURLs = override.RPCURLs
+	}
+	if override.DXPContractAddress != "" {
+		base.DXPContractAddress = override.DXPContractAddress
+	}
+	if override.DXPTokenAddress != "" {
+		base.DXPTokenAddress = override.DXPTokenAddress
+	}
+	if override.ExplorerURL != "" {
+		base.ExplorerURL = override.ExplorerURL
+	}
+
+	return base
+}
+
+// networkNames returns the names of the given network profiles in sorted order
+func networkNames(networks map[string]Network) []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// resolveNetwork picks the network profile from an explicit name or, failing
+// that, from a chain ID. Chain IDs without a profile get an empty custom network.
+func resolveNetwork(networks map[string]Network, name string, chainID int64) (Network, error) {
+	if name != "" {
+		network, ok := networks[name]
+		if !ok {
+			return Network{}, fmt.Errorf("unknown network %q, available networks: %s", name, strings.Join(networkNames(networks), ", "))
+		}
+		if network.ChainID == 0 {
+			return Network{}, fmt.Errorf("network %s has no chain ID", name)
+		}
+		if chainID != 0 && chainID != network.ChainID {
+			return Network{}, fmt.Errorf("CHAIN_ID %d does not match network %s (chain ID %d)", chainID, network.Name, network.ChainID)
+		}
+		return network, nil
+	}
+
+	if chainID == 0 {
+		return networks[DefaultNetwork], nil
+	}
+
+	for _, name := range networkNames(networks) {
+		if networks[name].ChainID == chainID {
+			return networks[name], nil
+		}
+	}
+
+	return Network{Name: "custom", ChainID: chainID}, nil
+}
+
+// VerifyChainID checks that the RPC endpoint serves the configured chain, so
+// that transactions are never signed for the wrong network
+func (c *Config) VerifyChainID(ctx context.Context, client ChainIDReader) error {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain ID from RPC: %v", err)
+	}
+
+	if chainID.Cmp(big.NewInt(c.ChainID)) != 0 {
+		return &ChainIDMismatchError{Network: c.Network, Expected: c.ChainID, Served: chainID}
+	}
+
+	return nil
+}
+
+// ChainIDMismatchError is returned by VerifyChainID when the RPC endpoint
+// serves a different chain
+type ChainIDMismatchError struct {
+	Network  string
+	Expected int64
+	Served   *big.Int
+}
+
+func (e *ChainIDMismatchError) Error() string {
+	return fmt.Sprintf("RPC endpoint serves chain ID %s but network %s expects %d", e.Served, e.Network, e.Expected)
+}
+
+// ExplorerTxURL returns a block explorer link for a transaction, or an empty
+// string if the network has no known explorer
+func (c *Config) ExplorerTxURL(txHash string) string {
+	if c.ExplorerURL == "" {
+		return ""
+	}
+
+	return c.ExplorerURL + "/tx/" + txHash
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		chainID int64
+
+		want        string
+		wantChainID int64
+		wantErr     string
+	}{
+		{name: "default", want: DefaultNetwork, wantChainID: 8453},
+		{name: "by name", network: "base-sepolia", want: "base-sepolia", wantChainID: 84532},
+		{name: "by chain ID", chainID: 11155111, want: "sepolia", wantChainID: 11155111},
+		{name: "name and matching chain ID", network: "dev", chainID: 31337, want: "dev", wantChainID: 31337},
+		{name: "unknown chain ID", chainID: 1, want: "custom", wantChainID: 1},
+		{name: "unknown name", network: "mainnet", wantErr: `unknown network "mainnet"`},
+		{name: "chain ID does not match the name", network: "base", chainID: 84532, wantErr: "CHAIN_ID 84532 does not match network base"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, err := resolveNetwork(Networks, tt.network, tt.chainID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveNetwork: %v", err)
+			}
+			if network.Name != tt.want || network.ChainID != tt.wantChainID {
+				t.Errorf("got %s (chain ID %d), want %s (chain ID %d)", network.Name, network.ChainID, tt.want, tt.wantChainID)
+			}
+		})
+	}
+}
+
+func TestLoadNetworks(t *testing.T) {
+	const profiles = `
+networks:
+  sepolia:
+    rpc_urls:
+      - https://sepolia.example.org
+  staging:
+    chain_id: 84532
+    contract_address: "0x5FbDB2315678afecb367f032d93F642f64180aa3"
+`
+
+	tests := []struct {
+		name string
+		// file is the content of the profiles file, which is missing when empty
+		file string
+
+		check   func(t *testing.T, networks map[string]Network)
+		wantErr string
+	}{
+		{
+			name: "missing file keeps the built-in profiles",
+			check: func(t *testing.T, networks map[string]Network) {
+				if len(networks) != len(Networks) {
+					t.Errorf("got %d profiles, want the %d built-in ones", len(networks), len(Networks))
+				}
+			},
+		},
+		{
+			name: "profiles override only the fields they set",
+			file: profiles,
+			check: func(t *testing.T, networks map[string]Network) {
+				sepolia := networks["sepolia"]
+				if sepolia.ChainID != 11155111 || sepolia.DXPContractAddress != Networks["sepolia"].DXPContractAddress {
+					t.Errorf("sepolia lost its built-in fields: %+v", sepolia)
+				}
+				if len(sepolia.RPCURLs) != 1 || sepolia.RPCURLs[0] != "https://sepolia.example.org" {
+					t.Errorf("sepolia RPC URLs %v, want the ones from the file", sepolia.RPCURLs)
+				}
+				if Networks["sepolia"].RPCURLs != nil {
+					t.Error("loading profiles changed the built-in ones")
+				}
+			},
+		},
+		{
+			name: "new profiles are added",
+			file: profiles,
+			check: func(t *testing.T, networks map[string]Network) {
+				staging, ok := networks["staging"]
+				if !ok || staging.Name != "staging" || staging.ChainID != 84532 {
+					t.Errorf("staging profile %+v, want chain ID 84532", staging)
+				}
+			},
+		},
+		{
+			name:    "invalid file",
+			file:    "networks: [",
+			wantErr: "failed to parse networks file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultNetworksFile)
+			if tt.file != "" {
+				if err := os.WriteFile(path, []byte(tt.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			networks, err := LoadNetworks(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadNetworks: %v", err)
+			}
+			tt.check(t, networks)
+		})
+	}
+}
+
+// chainIDReader reports a fixed chain ID
+type chainIDReader struct {
+	chainID int64
+	err     error
+}
+
+func (r chainIDReader) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(r.chainID), r.err
+}
+
+func TestVerifyChainID(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  chainIDReader
+		wantErr string
+	}{
+		{name: "same chain", client: chainIDReader{chainID: 84532}},
+		{name: "different chain", client: chainIDReader{chainID: 8453}, wantErr: "RPC endpoint serves chain ID 8453 but network base-sepolia expects 84532"},
+		{name: "chain ID unavailable", client: chainIDReader{err: errors.New("connection refused")}, wantErr: "failed to get chain ID from RPC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Network: "base-sepolia", ChainID: 84532}
+
+			err := cfg.VerifyChainID(context.Background(), tt.client)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
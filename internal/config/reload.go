@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/logging"
+)
+
+// redacted replaces secret values in printed configuration
+const redacted = "<redacted>"
+
+// WithReloadable returns a copy of the configuration with the settings that
+// are safe to change while the validator is running taken from next. Network,
+// addresses and keys are never reloaded.
+func (c *Config) WithReloadable(next *Config) *Config {
+	updated := *c
+	updated.GasPriceMultiplier = next.GasPriceMultiplier
+	updated.GasLimit = next.GasLimit
+	updated.GasLimitMargin = next.GasLimitMargin
+	updated.MaxFeePerGasGwei = next.MaxFeePerGasGwei
+	updated.MaxPriorityFeePerGasGwei = next.MaxPriorityFeePerGasGwei
+	updated.GasFeeCeilingGwei = next.GasFeeCeilingGwei
+	updated.LogLevel = next.LogLevel
+
+	return &updated
+}
+
+// Redacted returns a copy of the configuration that is safe to print, with
+// keys removed and credentials stripped from URLs
+func (c *Config) Redacted() *Config {
+	safe := *c
+	if safe.WalletPrivateKey != "" {
+		safe.WalletPrivateKey = redacted
+	}
+	if safe.KeystorePassword != "" {
+		safe.KeystorePassword = redacted
+	}
+	safe.BaseRPCURL = RedactURL(safe.BaseRPCURL)
+	fallbacks := strings.Split(safe.FallbackRPCURLs, ",")
+	for i, rpcURL := range fallbacks {
+		fallbacks[i] = RedactURL(strings.TrimSpace(rpcURL))
+	}
+	safe.FallbackRPCURLs = strings.Join(fallbacks, ",")
+	safe.SignerURL = RedactURL(safe.SignerURL)
+	safe.AlertWebhookURL = RedactURL(safe.AlertWebhookURL)
+
+	return &safe
+}
+
+// RedactURL hides user info, path and query of a URL, where RPC providers
+// usually put API keys
+func RedactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+
+	if parsed.User == nil && (parsed.Path == "" || parsed.Path == "/") && parsed.RawQuery == "" {
+		return raw
+	}
+
+	return parsed.Scheme + "://" + parsed.Host + "/" + redacted
+}
+
+// Watch polls the config file named in opts and calls onReload with the newly
+// loaded configuration whenever the file changes, until ctx is cancelled.
+// Invalid files are logged and ignored so a typo never stops the validator.
+func Watch(ctx context.Context, opts Options, interval time.Duration, onReload func(*Config)) {
+	if opts.ConfigFile == "" {
+		return
+	}
+
+	lastModified := modTime(opts.ConfigFile)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modified := modTime(opts.ConfigFile)
+			if modified.Equal(lastModified) {
+				continue
+			}
+			lastModified = modified
+
+			cfg, err := Load(opts)
+			if err != nil {
+				logging.Logger().Warn("Ignoring changes to config file", "file", opts.ConfigFile, "err", err)
+				continue
+			}
+
+			onReload(cfg)
+		}
+	}
+}
+
+// modTime returns the modification time of a file, or the zero time if it cannot be read
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
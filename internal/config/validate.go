@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Limits used to catch settings that are syntactically valid but almost certainly wrong
+const (
+	maxGasPriceMultiplier = 10.0
+	minGasLimit           = 21000
+	maxGasLimit           = 30000000
+	maxGasLimitMargin     = 2.0
+)
+
+// logLevels lists the accepted log levels
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// logFormats lists the accepted log formats
+var logFormats = []string{"text", "json"}
+
+// AlertRules lists the alert rules, all of which are enabled by default
+var AlertRules = []string{"tx_reverted", "low_balance", "head_lag", "deregistered"}
+
+// alertFormats lists the accepted alert webhook payload formats
+var alertFormats = []string{"json", "slack", "discord"}
+
+// maxAlertRetries caps the delivery attempts of a single alert
+const maxAlertRetries = 10
+
+// signerAPIs lists the supported external signer APIs
+var signerAPIs = []string{"clef", "web3signer"}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface with one problem per line
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problem(s)):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks every setting and returns a *ValidationError listing all
+// problems found, or nil if the configuration is usable
+func (c *Config) Validate() error {
+	problems := append([]string(nil), c.problems...)
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Network and endpoints
+	if c.ChainID <= 0 && !c.accountOnly {
+		add("chain ID must be positive, set NETWORK to one of the network profiles or CHAIN_ID")
+	}
+	if c.BaseRPCURL == "" && !c.accountOnly {
+		add("BASE_RPC_URL is not set and network %s has no default RPC URL", c.Network)
+	} else if c.BaseRPCURL != "" {
+		if err := checkRPCURL(c.BaseRPCURL); err != nil {
+			add("BASE_RPC_URL %v", err)
+		}
+	}
+	for _, rpcURL := range strings.Split(c.FallbackRPCURLs, ",") {
+		if rpcURL = strings.TrimSpace(rpcURL); rpcURL == "" {
+			continue
+		}
+		if err := checkRPCURL(rpcURL); err != nil {
+			add("FALLBACK_RPC_URLS %v", err)
+		}
+	}
+	if c.RPCHealthCheckSeconds <= 0 {
+		add("RPC_HEALTH_CHECK_SECONDS must be positive, got %d", c.RPCHealthCheckSeconds)
+	}
+	if endpoints := len(c.RPCURLs()); c.RPCQuorum < 1 || (c.RPCQuorum > int64(endpoints) && !c.accountOnly) {
+		add("RPC_QUORUM must be between 1 and the number of RPC endpoints (%d), got %d", endpoints, c.RPCQuorum)
+	}
+
+	// Contract addresses
+	if c.DXPContractAddress == "" && !c.accountOnly {
+		add("DXP_CONTRACT_ADDRESS is not set and network %s has no default contract address", c.Network)
+	} else if c.DXPContractAddress != "" {
+		if err := checkAddress(c.DXPContractAddress); err != nil {
+			add("DXP_CONTRACT_ADDRESS %v", err)
+		}
+	}
+	if c.DXPTokenAddress != "" {
+		if err := checkAddress(c.DXPTokenAddress); err != nil {
+			add("DXP_TOKEN_ADDRESS %v", err)
+		}
+	}
+
+	// Keys
+	if !c.accountOnly && !c.keyOptional {
+		switch {
+		case c.SignerURL != "" && (c.KeystoreFile != "" || c.WalletPrivateKey != ""):
+			add("SIGNER_URL is set together with a local key, remove KEYSTORE_FILE and WALLET_PRIVATE_KEY")
+		case c.SignerURL != "":
+			if err := checkRPCURL(c.SignerURL); err != nil {
+				add("SIGNER_URL %v", err)
+			}
+			if c.SignerAddress == "" {
+				add("SIGNER_ADDRESS must be set to the account the external signer signs for")
+			} else if err := checkAddress(c.SignerAddress); err != nil {
+				add("SIGNER_ADDRESS %v", err)
+			}
+			if !contains(signerAPIs, c.SignerAPI) {
+				add("SIGNER_API must be one of %s, got %q", strings.Join(signerAPIs, ", "), c.SignerAPI)
+			}
+		case c.KeystoreFile != "" && c.WalletPrivateKey != "":
+			add("both KEYSTORE_FILE and WALLET_PRIVATE_KEY are set, remove WALLET_PRIVATE_KEY")
+		case c.KeystoreFile != "":
+			if _, err := os.Stat(c.KeystoreFile); err != nil {
+				add("KEYSTORE_FILE cannot be read: %v", err)
+			}
+		case c.WalletPrivateKey != "":
+			if err := checkPrivateKey(c.WalletPrivateKey); err != nil {
+				add("WALLET_PRIVATE_KEY %v", err)
+			}
+		default:
+			add("no signing key configured, set KEYSTORE_FILE (see 'account import'), SIGNER_URL or WALLET_PRIVATE_KEY")
+		}
+	}
+	if c.RewardAddress != "" {
+		if err := checkAddress(c.RewardAddress); err != nil {
+			add("REWARD_ADDRESS %v", err)
+		} else if operator, ok := c.operatorAddress(); ok && operator == common.HexToAddress(c.RewardAddress) {
+			add("REWARD_ADDRESS must be a different account from the operator key, so a compromised validator host cannot claim rewards")
+		}
+	}
+	if c.KeystorePasswordFile != "" {
+		if _, err := os.Stat(c.KeystorePasswordFile); err != nil {
+			add("KEYSTORE_PASSWORD_FILE cannot be read: %v", err)
+		}
+	}
+
+	// Gas settings
+	if c.GasPriceMultiplier <= 0 || c.GasPriceMultiplier > maxGasPriceMultiplier {
+		add("GAS_PRICE_MULTIPLIER must be greater than 0 and at most %g, got %g", maxGasPriceMultiplier, c.GasPriceMultiplier)
+	}
+	if c.GasLimit < minGasLimit || c.GasLimit > maxGasLimit {
+		add("GAS_LIMIT must be between %d and %d, got %d", minGasLimit, maxGasLimit, c.GasLimit)
+	}
+	if c.GasLimitMargin < 0 || c.GasLimitMargin > maxGasLimitMargin {
+		add("GAS_LIMIT_MARGIN must be between 0 and %g, got %g", maxGasLimitMargin, c.GasLimitMargin)
+	}
+	if c.MaxFeePerGasGwei < 0 {
+		add("MAX_FEE_PER_GAS_GWEI must not be negative, got %g (use 0 to disable)", c.MaxFeePerGasGwei)
+	}
+	if c.MaxPriorityFeePerGasGwei < 0 {
+		add("MAX_PRIORITY_FEE_PER_GAS_GWEI must not be negative, got %g (use 0 to disable)", c.MaxPriorityFeePerGasGwei)
+	}
+	if c.GasFeeCeilingGwei < 0 {
+		add("GAS_FEE_CEILING_GWEI must not be negative, got %g (use 0 to disable)", c.GasFeeCeilingGwei)
+	}
+	if c.MaxFeePerGasGwei > 0 && c.MaxPriorityFeePerGasGwei > c.MaxFeePerGasGwei {
+		add("MAX_PRIORITY_FEE_PER_GAS_GWEI (%g) must not exceed MAX_FEE_PER_GAS_GWEI (%g)", c.MaxPriorityFeePerGasGwei, c.MaxFeePerGasGwei)
+	}
+
+	// Node settings
+	if !contains(logLevels, c.LogLevel) {
+		add("LOG_LEVEL must be one of %s, got %q", strings.Join(logLevels, ", "), c.LogLevel)
+	}
+	if !contains(logFormats, c.LogFormat) {
+		add("LOG_FORMAT must be one of %s, got %q", strings.Join(logFormats, ", "), c.LogFormat)
+	}
+	if c.LogMaxSizeMB < 0 {
+		add("LOG_MAX_SIZE_MB must not be negative, got %d (use 0 to disable rotation)", c.LogMaxSizeMB)
+	}
+	if c.LogMaxBackups < 0 {
+		add("LOG_MAX_BACKUPS must not be negative, got %d", c.LogMaxBackups)
+	}
+	if c.DataDir == "" {
+		add("DATA_DIR must not be empty")
+	}
+	if c.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(c.MetricsAddr); err != nil {
+			add("METRICS_ADDR must be host:port, got %q", c.MetricsAddr)
+		}
+	}
+
+	// Alerts
+	if c.AlertWebhookURL != "" {
+		if parsed, err := url.Parse(c.AlertWebhookURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add("ALERT_WEBHOOK_URL must be an http or https URL, got %q", RedactURL(c.AlertWebhookURL))
+		}
+	}
+	if !contains(alertFormats, c.AlertWebhookFormat) {
+		add("ALERT_WEBHOOK_FORMAT must be one of %s, got %q", strings.Join(alertFormats, ", "), c.AlertWebhookFormat)
+	}
+	for _, rule := range c.EnabledAlertRules() {
+		if !contains(AlertRules, rule) {
+			add("ALERT_RULES must only list %s, got %q", strings.Join(AlertRules, ", "), rule)
+		}
+	}
+	if c.AlertMinBalanceETH < 0 {
+		add("ALERT_MIN_BALANCE_ETH must not be negative, got %g", c.AlertMinBalanceETH)
+	}
+	if c.AlertRepeatMinutes <= 0 {
+		add("ALERT_REPEAT_MINUTES must be positive, got %d", c.AlertRepeatMinutes)
+	}
+	if c.AlertMaxPerHour <= 0 {
+		add("ALERT_MAX_PER_HOUR must be positive, got %d", c.AlertMaxPerHour)
+	}
+	if c.AlertRetries < 0 || c.AlertRetries > maxAlertRetries {
+		add("ALERT_RETRIES must be between 0 and %d, got %d", maxAlertRetries, c.AlertRetries)
+	}
+
+	// Scheduler
+	if c.SchedulerMaxConcurrent <= 0 {
+		add("SCHEDULER_MAX_CONCURRENT must be positive, got %d", c.SchedulerMaxConcurrent)
+	}
+	if c.SchedulerMaxPerRequester <= 0 {
+		add("SCHEDULER_MAX_PER_REQUESTER must be positive, got %d", c.SchedulerMaxPerRequester)
+	}
+	if c.SchedulerMinTimeLeftSeconds < 0 {
+		add("SCHEDULER_MIN_TIME_LEFT_SECONDS must not be negative, got %d", c.SchedulerMinTimeLeftSeconds)
+	}
+
+	// Request expiry
+	if c.RequestTTLSeconds < 0 {
+		add("REQUEST_TTL_SECONDS must not be negative, got %d", c.RequestTTLSeconds)
+	}
+	if c.RequestSubmitMarginSeconds < 0 {
+		add("REQUEST_SUBMIT_MARGIN_SECONDS must not be negative, got %d", c.RequestSubmitMarginSeconds)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// checkRPCURL accepts http(s) and websocket URLs as well as IPC socket paths
+func checkRPCURL(raw string) error {
+	if strings.HasSuffix(raw, ".ipc") && !strings.Contains(raw, "://") {
+		return nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %v", err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return fmt.Errorf("must use http, https, ws or wss (or be an .ipc path), got %q", RedactURL(raw))
+	}
+
+	if parsed.Host == "" {
+		return fmt.Errorf("has no host: %q", RedactURL(raw))
+	}
+
+	return nil
+}
+
+// checkAddress requires a 20-byte hex address that, if written in mixed case,
+// carries a valid EIP-55 checksum
+func checkAddress(address string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("is not a valid address: %q", address)
+	}
+
+	parsed := common.HexToAddress(address)
+	if parsed == (common.Address{}) {
+		return fmt.Errorf("must not be the zero address")
+	}
+
+	hex := strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X")
+	mixedCase := strings.ToLower(hex) != hex && strings.ToUpper(hex) != hex
+	if mixedCase && parsed.Hex() != "0x"+hex {
+		return fmt.Errorf("has an invalid checksum: %q, expected %s", address, parsed.Hex())
+	}
+
+	return nil
+}
+
+// checkPrivateKey requires 64 hex characters forming a valid secp256k1 key
+func checkPrivateKey(key string) error {
+	if strings.HasPrefix(key, "0x") || strings.HasPrefix(key, "0X") {
+		return fmt.Errorf("must not have a 0x prefix")
+	}
+
+	if len(key) != 64 {
+		return fmt.Errorf("must be 64 hex characters, got %d characters", len(key))
+	}
+
+	if _, err := crypto.HexToECDSA(key); err != nil {
+		return fmt.Errorf("is not a valid private key: %v", err)
+	}
+
+	return nil
+}
+
+// operatorAddress returns the address of the operator key when it is known
+// without unlocking a keystore
+func (c *Config) operatorAddress() (common.Address, bool) {
+	switch {
+	case c.SignerURL != "" && common.IsHexAddress(c.SignerAddress):
+		return common.HexToAddress(c.SignerAddress), true
+	case c.WalletPrivateKey != "":
+		key, err := crypto.HexToECDSA(c.WalletPrivateKey)
+		if err != nil {
+			return common.Address{}, false
+		}
+		return crypto.PubkeyToAddress(key.PublicKey), true
+	}
+
+	return common.Address{}, false
+}
+
+// contains reports whether the list includes the value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validConfig returns a configuration that passes validation
+func validConfig() *Config {
+	return &Config{
+		Network:                     "dev",
+		ChainID:                     31337,
+		BaseRPCURL:                  "http://127.0.0.1:8545",
+		DXPContractAddress:          testContract,
+		WalletPrivateKey:            testKey,
+		SignerAPI:                   "clef",
+		GasPriceMultiplier:          1,
+		GasLimit:                    3000000,
+		GasLimitMargin:              0.2,
+		LogLevel:                    "info",
+		LogFormat:                   "text",
+		DataDir:                     "./data",
+		AlertWebhookFormat:          "json",
+		AlertRules:                  strings.Join(AlertRules, ","),
+		AlertRepeatMinutes:          60,
+		AlertMaxPerHour:             20,
+		AlertRetries:                3,
+		SchedulerMaxConcurrent:      4,
+		SchedulerMaxPerRequester:    2,
+		SchedulerMinTimeLeftSeconds: 60,
+		RequestTTLSeconds:           3600,
+		RequestSubmitMarginSeconds:  30,
+		RPCHealthCheckSeconds:       15,
+		RPCMaxBlockLag:              5,
+		RPCQuorum:                   1,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		// want lists a fragment of each expected problem; none means valid
+		want []string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "missing chain ID",
+			modify: func(c *Config) { c.ChainID = 0 },
+			want:   []string{"chain ID must be positive"},
+		},
+		{
+			name:   "unsupported RPC scheme",
+			modify: func(c *Config) { c.BaseRPCURL = "ftp://node" },
+			want:   []string{"BASE_RPC_URL must use http"},
+		},
+		{
+			name:   "IPC path is accepted",
+			modify: func(c *Config) { c.BaseRPCURL = "/var/run/geth.ipc" },
+		},
+		{
+			name:   "quorum above the number of endpoints",
+			modify: func(c *Config) { c.RPCQuorum = 2 },
+			want:   []string{"RPC_QUORUM must be between 1 and the number of RPC endpoints (1)"},
+		},
+		{
+			name: "quorum with fallbacks",
+			modify: func(c *Config) {
+				c.FallbackRPCURLs = "http://127.0.0.1:8546, http://127.0.0.1:8547"
+				c.RPCQuorum = 2
+			},
+		},
+		{
+			name:   "bad checksum",
+			modify: func(c *Config) { c.DXPContractAddress = strings.Replace(testContract, "F", "f", 1) },
+			want:   []string{"DXP_CONTRACT_ADDRESS has an invalid checksum"},
+		},
+		{
+			name:   "zero address",
+			modify: func(c *Config) { c.DXPTokenAddress = "0x0000000000000000000000000000000000000000" },
+			want:   []string{"DXP_TOKEN_ADDRESS must not be the zero address"},
+		},
+		{
+			name:   "private key with 0x prefix",
+			modify: func(c *Config) { c.WalletPrivateKey = "0x" + testKey },
+			want:   []string{"WALLET_PRIVATE_KEY must not have a 0x prefix"},
+		},
+		{
+			name:   "no signing key",
+			modify: func(c *Config) { c.WalletPrivateKey = "" },
+			want:   []string{"no signing key configured"},
+		},
+		{
+			name:   "keystore file and private key",
+			modify: func(c *Config) { c.KeystoreFile = "key.json" },
+			want:   []string{"both KEYSTORE_FILE and WALLET_PRIVATE_KEY are set"},
+		},
+		{
+			name: "unreadable keystore file",
+			modify: func(c *Config) {
+				c.WalletPrivateKey = ""
+				c.KeystoreFile = filepath.Join("missing", "key.json")
+			},
+			want: []string{"KEYSTORE_FILE cannot be read"},
+		},
+		{
+			name: "no signing key for unsigned transactions",
+			modify: func(c *Config) {
+				c.WalletPrivateKey = ""
+				c.keyOptional = true
+			},
+		},
+		{
+			name:   "external signer with a local key",
+			modify: func(c *Config) { c.SignerURL = "http://127.0.0.1:8550" },
+			want:   []string{"SIGNER_URL is set together with a local key"},
+		},
+		{
+			name: "external signer without an address",
+			modify: func(c *Config) {
+				c.WalletPrivateKey = ""
+				c.SignerURL = "http://127.0.0.1:8550"
+				c.SignerAPI = "vault"
+			},
+			want: []string{"SIGNER_ADDRESS must be set", "SIGNER_API must be one of clef, web3signer"},
+		},
+		{
+			name:   "reward address is the operator",
+			modify: func(c *Config) { c.RewardAddress = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23" },
+			want:   []string{"REWARD_ADDRESS must be a different account"},
+		},
+		{
+			name: "priority fee above max fee",
+			modify: func(c *Config) {
+				c.MaxFeePerGasGwei = 10
+				c.MaxPriorityFeePerGasGwei = 20
+			},
+			want: []string{"MAX_PRIORITY_FEE_PER_GAS_GWEI (20) must not exceed MAX_FEE_PER_GAS_GWEI (10)"},
+		},
+		{
+			name:   "unknown log format",
+			modify: func(c *Config) { c.LogFormat = "xml" },
+			want:   []string{`LOG_FORMAT must be one of text, json, got "xml"`},
+		},
+		{
+			name:   "metrics address without a port",
+			modify: func(c *Config) { c.MetricsAddr = "localhost" },
+			want:   []string{`METRICS_ADDR must be host:port, got "localhost"`},
+		},
+		{
+			name:   "alert webhook is not http",
+			modify: func(c *Config) { c.AlertWebhookURL = "ftp://alerts.example.org" },
+			want:   []string{"ALERT_WEBHOOK_URL must be an http or https URL"},
+		},
+		{
+			name:   "scheduler without slots",
+			modify: func(c *Config) { c.SchedulerMaxConcurrent = 0 },
+			want:   []string{"SCHEDULER_MAX_CONCURRENT must be positive, got 0"},
+		},
+		{
+			name:   "negative request TTL",
+			modify: func(c *Config) { c.RequestTTLSeconds = -1 },
+			want:   []string{"REQUEST_TTL_SECONDS must not be negative, got -1"},
+		},
+		{
+			name: "every problem is reported",
+			modify: func(c *Config) {
+				c.GasPriceMultiplier = 0
+				c.GasLimit = 100
+				c.LogLevel = "verbose"
+				c.AlertRules = "tx_reverted,disk_full"
+			},
+			want: []string{"GAS_PRICE_MULTIPLIER", "GAS_LIMIT must be between", "LOG_LEVEL", `ALERT_RULES must only list tx_reverted, low_balance, head_lag, deregistered, got "disk_full"`},
+		},
+		{
+			name: "parse problems from loading are kept",
+			modify: func(c *Config) {
+				c.problems = []string{`GAS_LIMIT from environment: "lots" is not a non-negative whole number`}
+			},
+			want: []string{`"lots" is not a non-negative whole number`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("got error %v, want a *ValidationError", err)
+			}
+			if len(validationErr.Problems) != len(tt.want) {
+				t.Errorf("got %d problem(s), want %d:\n%v", len(validationErr.Problems), len(tt.want), err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package consensus
 
 import (
 	"sync"
+
+	"github.com/dexponent/geth-validator/internal/logging"
 )
 
 // Engine represents a consensus engine for validators
@@ -30,6 +32,14 @@ func (e *Engine) RegisterParticipant(participantID string) {
 	e.participants[participantID] = true
 }
 
+// ParticipantCount returns the number of registered participants
+func (e *Engine) ParticipantCount() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return len(e.participants)
+}
+
 // SubmitResult submits a result for consensus
 func (e *Engine) SubmitResult(requestID string, participantID string, result []byte) {
 	e.mutex.Lock()
@@ -47,9 +57,25 @@ func (e *Engine) SubmitResult(requestID string, participantID string, result []b
 
 	// Update the count for this result
 	e.resultCounts[requestID][resultKey]++
+
+	logging.Logger().Debug("Consensus result submitted", logging.KeyRequestID, requestID, "participant", participantID, "votes", e.resultCounts[requestID][resultKey])
+}
+
+// Votes returns the result submitted by each participant for a request
+func (e *Engine) Votes(requestID string) map[string][]byte {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	votes := make(map[string][]byte, len(e.consensusResults[requestID]))
+	for participantID, result := range e.consensusResults[requestID] {
+		votes[participantID] = result
+	}
+
+	return votes
 }
 
-// CheckConsensus checks if consensus has been reached for a request
+// CheckConsensus checks if consensus has been reached for a request among
+// the results submitted so far; it does not wait for more to arrive
 func (e *Engine) CheckConsensus(requestID string) (bool, []byte) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
@@ -83,7 +109,9 @@ func (e *Engine) CheckConsensus(requestID string) (bool, []byte) {
 	}
 
 	// Check if we have a 2/3 majority
-	if maxCount*3 >= totalParticipants*2 {
+	reached := maxCount*3 >= totalParticipants*2
+	logging.Logger().Debug("Consensus checked", logging.KeyRequestID, requestID, "reached", reached, "votes", maxCount, "participants", totalParticipants)
+	if reached {
 		return true, consensusResult
 	}
 
@@ -56,7 +56,12 @@ func NewDexponentProtocol(address common.Address, backend bind.ContractBackend)
 func (_DexponentProtocol *DexponentProtocolCaller) RegisteredVerifiers(opts *bind.CallOpts, verifier common.Address) (bool, error) {
 	var out []interface{}
 	err := _DexponentProtocol.contract.Call(opts, &out, "registeredVerifiers", verifier)
-	return *abi.ConvertType(out[0], new(bool)).(*bool), err
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+	return out0, err
 }
 
 // RegisterVerifier is a paid mutator transaction binding the contract method 0xb7b4a0e2.
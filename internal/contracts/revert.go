@@ -0,0 +1,154 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// tokenErrorsABI lists the OpenZeppelin ERC20 custom errors the protocol
+// contract can bubble up from the DXP token while staking
+const tokenErrorsABI = `[
+	{"type":"error","name":"ERC20InsufficientBalance","inputs":[{"name":"sender","type":"address"},{"name":"balance","type":"uint256"},{"name":"needed","type":"uint256"}]},
+	{"type":"error","name":"ERC20InsufficientAllowance","inputs":[{"name":"spender","type":"address"},{"name":"allowance","type":"uint256"},{"name":"needed","type":"uint256"}]},
+	{"type":"error","name":"ERC20InvalidSender","inputs":[{"name":"sender","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidReceiver","inputs":[{"name":"receiver","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidApprover","inputs":[{"name":"approver","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidSpender","inputs":[{"name":"spender","type":"address"}]}
+]`
+
+// customErrors holds the custom errors that DecodeRevert can recognise
+var customErrors = mustParseErrors(DexponentProtocolABI, tokenErrorsABI)
+
+// ReplayBackend is the subset of the Ethereum client needed to replay a transaction
+type ReplayBackend interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// RevertData extracts the raw revert data carried by an RPC error, if any
+func RevertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+
+	switch data := dataErr.ErrorData().(type) {
+	case string:
+		decoded, err := hexutil.Decode(data)
+		if err != nil {
+			return nil, false
+		}
+		return decoded, true
+	case []byte:
+		return data, true
+	}
+
+	return nil, false
+}
+
+// DecodeRevert turns revert data into a human-readable reason
+func DecodeRevert(data []byte) string {
+	if len(data) == 0 {
+		return "execution reverted without a reason"
+	}
+
+	// Error(string) and Panic(uint256)
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+
+	// Custom errors declared in the known ABIs
+	if len(data) >= 4 {
+		for _, customErr := range customErrors {
+			if !bytes.Equal(customErr.ID[:4], data[:4]) {
+				continue
+			}
+			args, err := customErr.Unpack(data)
+			if err != nil {
+				break
+			}
+			return formatCustomError(customErr, args)
+		}
+	}
+
+	return "unknown revert data: " + common.Bytes2Hex(data)
+}
+
+// RevertReason returns a readable reason for a failed call, falling back to the
+// error message when the node returned no revert data
+func RevertReason(err error) string {
+	if data, ok := RevertData(err); ok {
+		return DecodeRevert(data)
+	}
+
+	return strings.TrimPrefix(err.Error(), "execution reverted: ")
+}
+
+// FailureReason replays a mined transaction that failed with CallContract at
+// its block and returns the decoded revert reason
+func FailureReason(ctx context.Context, backend ReplayBackend, tx *types.Transaction, receipt *types.Receipt) (string, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return "", fmt.Errorf("failed to recover transaction sender: %v", err)
+	}
+
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+
+	_, err = backend.CallContract(ctx, msg, receipt.BlockNumber)
+	if err == nil {
+		return "", errors.New("replay did not revert, the state the transaction depended on has changed")
+	}
+
+	return RevertReason(err), nil
+}
+
+// formatCustomError renders a decoded custom error as Name(arg=value, ...)
+func formatCustomError(customErr abi.Error, args interface{}) string {
+	values, ok := args.([]interface{})
+	if !ok {
+		return customErr.Name
+	}
+
+	parts := make([]string, 0, len(values))
+	for i, value := range values {
+		name := fmt.Sprintf("arg%d", i)
+		if i < len(customErr.Inputs) && customErr.Inputs[i].Name != "" {
+			name = customErr.Inputs[i].Name
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", name, value))
+	}
+
+	return fmt.Sprintf("%s(%s)", customErr.Name, strings.Join(parts, ", "))
+}
+
+// mustParseErrors collects the custom errors declared in the given ABI definitions
+func mustParseErrors(definitions ...string) []abi.Error {
+	var result []abi.Error
+	for _, definition := range definitions {
+		parsed, err := abi.JSON(strings.NewReader(definition))
+		if err != nil {
+			panic(fmt.Sprintf("invalid ABI: %v", err))
+		}
+		for _, customErr := range parsed.Errors {
+			result = append(result, customErr)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,215 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// revertError is a node's answer to a call that reverted
+type revertError struct {
+	message string
+	data    interface{}
+}
+
+func (e *revertError) Error() string          { return e.message }
+func (e *revertError) ErrorCode() int         { return 3 }
+func (e *revertError) ErrorData() interface{} { return e.data }
+
+// encodeRevert returns the revert data of the error called name with args
+func encodeRevert(t *testing.T, name string, args ...interface{}) []byte {
+	t.Helper()
+
+	// Error(string) and Panic(uint256) are not declared in any ABI
+	builtin := map[string]abi.Arguments{
+		"Error": {{Type: mustType(t, "string")}},
+		"Panic": {{Type: mustType(t, "uint256")}},
+	}
+	if inputs, ok := builtin[name]; ok {
+		packed, err := inputs.Pack(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature := map[string]string{"Error": "Error(string)", "Panic": "Panic(uint256)"}[name]
+		return append(crypto.Keccak256([]byte(signature))[:4], packed...)
+	}
+
+	for _, customErr := range customErrors {
+		if customErr.Name != name {
+			continue
+		}
+		packed, err := customErr.Inputs.Pack(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(customErr.ID[:4:4], packed...)
+	}
+
+	t.Fatalf("no error called %s", name)
+	return nil
+}
+
+func mustType(t *testing.T, name string) abi.Type {
+	t.Helper()
+
+	typ, err := abi.NewType(name, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typ
+}
+
+func TestDecodeRevert(t *testing.T) {
+	spender := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "reason string",
+			data: encodeRevert(t, "Error", "Verifier already registered"),
+			want: "Verifier already registered",
+		},
+		{
+			name: "panic",
+			data: encodeRevert(t, "Panic", big.NewInt(0x11)),
+			want: "arithmetic underflow or overflow",
+		},
+		{
+			name: "token custom error",
+			data: encodeRevert(t, "ERC20InsufficientAllowance", spender, big.NewInt(0), big.NewInt(100)),
+			want: "ERC20InsufficientAllowance(spender=" + spender.Hex() + ", allowance=0, needed=100)",
+		},
+		{
+			name: "no data",
+			want: "execution reverted without a reason",
+		},
+		{
+			name: "unknown selector",
+			data: []byte{0xde, 0xad, 0xbe, 0xef},
+			want: "unknown revert data: deadbeef",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeRevert(tt.data); got != tt.want {
+				t.Errorf("DecodeRevert = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevertReason(t *testing.T) {
+	data := encodeRevert(t, "Error", "Not registered")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "hex data",
+			err:  &revertError{message: "execution reverted", data: hexutil.Encode(data)},
+			want: "Not registered",
+		},
+		{
+			name: "raw data",
+			err:  &revertError{message: "execution reverted", data: data},
+			want: "Not registered",
+		},
+		{
+			name: "no data",
+			err:  errors.New("execution reverted: Not registered"),
+			want: "Not registered",
+		},
+		{
+			name: "undecodable data falls back to the message",
+			err:  &revertError{message: "execution reverted", data: "not hex"},
+			want: "execution reverted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RevertReason(tt.err); got != tt.want {
+				t.Errorf("RevertReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// replayer answers replayed calls with err, keeping the call it was given
+type replayer struct {
+	err   error
+	msg   ethereum.CallMsg
+	block *big.Int
+}
+
+func (r *replayer) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	r.msg, r.block = msg, blockNumber
+	return nil, r.err
+}
+
+func TestFailureReason(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(31337)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(31337),
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(3e9),
+		Gas:       120000,
+		To:        &to,
+		Data:      []byte{0x01, 0x02},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := &types.Receipt{Status: types.ReceiptStatusFailed, BlockNumber: big.NewInt(42)}
+
+	tests := []struct {
+		name    string
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "replay reverts",
+			err:  &revertError{message: "execution reverted", data: hexutil.Encode(encodeRevert(t, "Error", "Proof already submitted"))},
+			want: "Proof already submitted",
+		},
+		{
+			name:    "replay succeeds",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &replayer{err: tt.err}
+
+			got, err := FailureReason(context.Background(), backend, tx, receipt)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("FailureReason = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+
+			// The transaction is replayed as sent, at the block it was mined in
+			if backend.msg.From != crypto.PubkeyToAddress(key.PublicKey) || backend.msg.Gas != tx.Gas() || backend.block.Int64() != 42 {
+				t.Errorf("replayed from %s with gas %d at block %v", backend.msg.From.Hex(), backend.msg.Gas, backend.block)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package contracts
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// tokenABI holds the ERC-20 balanceOf method
+const tokenABI = `[{"constant":true,"inputs":[{"name":"_owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"balance","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"}]`
+
+// DXPToken reads balances of the DXP token
+type DXPToken struct {
+	contract *bind.BoundContract
+}
+
+// NewDXPToken binds the DXP token at address
+func NewDXPToken(address common.Address, backend bind.ContractCaller) (*DXPToken, error) {
+	parsed, err := abi.JSON(strings.NewReader(tokenABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token ABI: %v", err)
+	}
+
+	return &DXPToken{
+		contract: bind.NewBoundContract(address, parsed, backend, nil, nil),
+	}, nil
+}
+
+// BalanceOf returns the token balance of owner, in the token's smallest unit
+func (t *DXPToken) BalanceOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error) {
+	var out []interface{}
+	if err := t.contract.Call(opts, &out, "balanceOf", owner); err != nil {
+		return nil, err
+	}
+
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
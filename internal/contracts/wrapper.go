@@ -1,13 +1,27 @@
 package contracts
 
 import (
+	"errors"
 	"math/big"
 
+	"github.com/dexponent/geth-validator/internal/logging"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// ErrClaimUnsupported is returned when building a rewards claim against a
+// contract that does not offer one
+var ErrClaimUnsupported = errors.New("the Dexponent Protocol contract has no rewards claim method")
+
+// ErrRewardsUnsupported is returned when reading pending rewards from a
+// contract that does not track them
+var ErrRewardsUnsupported = errors.New("the Dexponent Protocol contract does not track pending rewards")
+
+// ClaimSupported reports whether the Dexponent Protocol contract has a
+// rewards claim method; until it does, ClaimRewards returns ErrClaimUnsupported
+const ClaimSupported = false
+
 // DexponentContractWrapper implements the validator.DXPContract interface
 // for the Dexponent Protocol contract
 type DexponentContractWrapper struct {
@@ -37,17 +51,15 @@ func (w *DexponentContractWrapper) IsRegistered(opts *bind.CallOpts, address com
 }
 
 // GetPendingRewards gets the pending rewards for the validator
-// Note: This is a mock implementation as the actual contract doesn't have this method
+// Note: The actual contract doesn't track rewards yet, so there is no amount to read
 func (w *DexponentContractWrapper) GetPendingRewards(opts *bind.CallOpts, address common.Address) (*big.Int, error) {
-	// This is a mock implementation since the contract doesn't have this method
-	return big.NewInt(0), nil
+	return nil, ErrRewardsUnsupported
 }
 
 // ClaimRewards claims the pending rewards for the validator
-// Note: This is a mock implementation as the actual contract doesn't have this method
+// Note: The actual contract doesn't have this method yet, so no transaction can be built
 func (w *DexponentContractWrapper) ClaimRewards(opts *bind.TransactOpts) (*types.Transaction, error) {
-	// This is a mock implementation since the contract doesn't have this method
-	return nil, nil
+	return nil, ErrClaimUnsupported
 }
 
 // SubmitVerificationResult submits the verification result to the Dexponent Protocol contract
@@ -57,5 +69,6 @@ func (w *DexponentContractWrapper) SubmitVerificationResult(opts *bind.TransactO
 	performanceScore := big.NewInt(100)
 
 	// Submit the proof to the contract
+	logging.FromContext(opts.Context).Debug("Building submitProof transaction", logging.KeyRequestID, requestID.String(), "farmId", requestID.String(), "performanceScore", performanceScore.String())
 	return w.contract.SubmitProof(opts, requestID, performanceScore)
 }
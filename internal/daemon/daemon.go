@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dexponent/geth-validator/internal/config"
+)
+
+// PIDFileName is the name of the PID file written to the data directory
+const PIDFileName = "validator.pid"
+
+// LogFileName is the default log file of a detached node in the data directory
+const LogFileName = "validator.log"
+
+// OutputFileName is the file in the data directory that receives the standard
+// output and error of a detached node
+const OutputFileName = "validator.out"
+
+// ErrNoPIDFile is returned when no node has written a PID file
+var ErrNoPIDFile = errors.New("no PID file found")
+
+// PIDPath returns the PID file of the node using the given configuration
+func PIDPath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, PIDFileName)
+}
+
+// LogPath returns the default log file of a detached node
+func LogPath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, LogFileName)
+}
+
+// OutputPath returns the file that receives the output of a detached node
+func OutputPath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, OutputFileName)
+}
+
+// WritePID records the current process ID in the PID file
+func WritePID(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create PID file directory: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %v", err)
+	}
+
+	return nil
+}
+
+// ReadPID returns the process ID recorded in the PID file
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNoPIDFile
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read PID file: %v", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file %s", path)
+	}
+
+	return pid, nil
+}
+
+// RemovePID removes the PID file if it still belongs to the current process
+func RemovePID(path string) {
+	if pid, err := ReadPID(path); err == nil && pid == os.Getpid() {
+		os.Remove(path)
+	}
+}
+
+// DetachArgs returns the command line for the detached child: the same
+// arguments without the --detached flag
+func DetachArgs(args []string) []string {
+	child := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--detached" || strings.HasPrefix(arg, "--detached=") {
+			continue
+		}
+		child = append(child, arg)
+	}
+
+	return child
+}
@@ -0,0 +1,56 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// Detach starts the current executable with args in a new session, detached
+// from the terminal, with its output appended to outputFile
+func Detach(args []string, outputFile string) (*os.Process, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find executable: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	output, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %v", err)
+	}
+	defer output.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdin = nil
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start detached validator: %v", err)
+	}
+
+	return cmd.Process, nil
+}
+
+// Alive reports whether a process with the given ID exists
+func Alive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+// Terminate asks the process to shut down gracefully
+func Terminate(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// Kill stops the process immediately
+func Kill(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package daemon
+
+import (
+	"errors"
+	"os"
+)
+
+// Detach is not supported on Windows; run the validator as a service instead
+func Detach(args []string, outputFile string) (*os.Process, error) {
+	return nil, errors.New("detached mode is not supported on Windows, run the validator as a service instead")
+}
+
+// Alive reports whether a process with the given ID exists
+func Alive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+
+	return true
+}
+
+// Terminate stops the process; Windows has no graceful termination signal
+func Terminate(pid int) error {
+	return Kill(pid)
+}
+
+// Kill stops the process immediately
+func Kill(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return process.Kill()
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Handler is called with every published event, on the publisher's goroutine
+type Handler func(Event)
+
+// Bus delivers published events to every handler and subscriber
+type Bus struct {
+	mutex       sync.Mutex
+	handlers    map[int]Handler
+	subscribers map[int]chan Event
+	next        int
+}
+
+// NewBus creates an event bus without subscribers
+func NewBus() *Bus {
+	return &Bus{
+		handlers:    make(map[int]Handler),
+		subscribers: make(map[int]chan Event),
+	}
+}
+
+// Handle registers a handler that sees every event published from now on, and
+// returns a function that removes it. Handlers run synchronously and in order
+// of publication, so nothing is missed, but they must be quick and must not
+// publish themselves. Use Subscribe for slow consumers.
+func (b *Bus) Handle(handler Handler) func() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		delete(b.handlers, id)
+	}
+}
+
+// Subscribe returns a channel receiving every event published from now on,
+// buffered for buffer events, and a function that ends the subscription
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.next
+	b.next++
+	ch := make(chan Event, buffer)
+	b.subscribers[id] = ch
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+
+			delete(b.subscribers, id)
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish stamps an event with the current time if it has none, runs the
+// handlers and then sends it to all subscribers without blocking. A subscriber
+// whose buffer is full misses the event rather than stalling the validator.
+func (b *Bus) Publish(event Event) {
+	if header := event.EventHeader(); header.Time.IsZero() {
+		header.Time = time.Now()
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, handler := range b.handlers {
+		handler(event)
+	}
+
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
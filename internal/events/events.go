@@ -0,0 +1,351 @@
+package events
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Type identifies what happened
+type Type string
+
+// Event types published by the validator
+const (
+	TypeBlockProcessed      Type = "block_processed"
+	TypeRequestFound        Type = "request_found"
+	TypeRequestStarted      Type = "request_started"
+	TypeRequestSetAside     Type = "request_set_aside"
+	TypeRequestFinished     Type = "request_finished"
+	TypeRequestDropped      Type = "request_dropped"
+	TypeRequestExpired      Type = "request_expired"
+	TypeComputeDone         Type = "compute_done"
+	TypeComputeFailed       Type = "compute_failed"
+	TypeConsensusReached    Type = "consensus_reached"
+	TypeConsensusSplit      Type = "consensus_split"
+	TypeProofGenerated      Type = "proof_generated"
+	TypeProofFailed         Type = "proof_failed"
+	TypeTxSent              Type = "tx_sent"
+	TypeTxMined             Type = "tx_mined"
+	TypeTxReverted          Type = "tx_reverted"
+	TypeTxFailed            Type = "tx_failed"
+	TypeHeadChecked         Type = "head_checked"
+	TypeBalanceChecked      Type = "balance_checked"
+	TypeRegistrationChecked Type = "registration_checked"
+)
+
+// Event is something that happened in the validator. Events are published as
+// pointers to the structs below; subscribers tell them apart with a type switch.
+type Event interface {
+	// Type returns the kind of event
+	Type() Type
+	// EventHeader returns the fields every event has
+	EventHeader() *Header
+	// Message describes the event for people
+	Message() string
+}
+
+// Header holds the fields common to all events. RequestID is empty for events
+// that are not about a verification request, such as the registration transaction.
+type Header struct {
+	Time      time.Time
+	RequestID string
+	TxHash    string
+	Block     uint64
+}
+
+// EventHeader returns the header itself
+func (h *Header) EventHeader() *Header {
+	return h
+}
+
+// BlockProcessed is published once a block has been scanned for requests
+type BlockProcessed struct {
+	Header
+	// Head is the chain head when the block was processed
+	Head     uint64
+	Requests int
+}
+
+func (e *BlockProcessed) Type() Type { return TypeBlockProcessed }
+
+func (e *BlockProcessed) Message() string {
+	return fmt.Sprintf("Processed block %d, found %d requests", e.Block, e.Requests)
+}
+
+// RequestFound is published when a request is queued, either found in a block
+// or restored from the last shutdown
+type RequestFound struct {
+	Header
+	Requester string
+	// BlockHash and LogIndex identify the log the request was emitted in
+	BlockHash string
+	LogIndex  uint
+	// Deadline is when the request expires, zero if it does not
+	Deadline time.Time
+	Restored bool
+}
+
+func (e *RequestFound) Type() Type { return TypeRequestFound }
+
+func (e *RequestFound) Message() string {
+	if e.Restored {
+		return "Restored from the last shutdown"
+	}
+	return "Found verification request"
+}
+
+// RequestStarted is published when a request is taken off the queue
+type RequestStarted struct {
+	Header
+}
+
+func (e *RequestStarted) Type() Type { return TypeRequestStarted }
+
+func (e *RequestStarted) Message() string { return "Processing verification request" }
+
+// RequestSetAside is published when shutdown interrupts a request, which is
+// then saved for the next start
+type RequestSetAside struct {
+	Header
+	Stage string
+}
+
+func (e *RequestSetAside) Type() Type { return TypeRequestSetAside }
+
+func (e *RequestSetAside) Message() string {
+	return "Set aside at stage " + e.Stage + " for the next start"
+}
+
+// RequestFinished is published when a request has been taken through the
+// pipeline, whatever the outcome
+type RequestFinished struct {
+	Header
+}
+
+func (e *RequestFinished) Type() Type { return TypeRequestFinished }
+
+func (e *RequestFinished) Message() string { return "Finished processing" }
+
+// RequestDropped is published when the scheduler drops a queued request it
+// can no longer finish in time
+type RequestDropped struct {
+	Header
+	Reason string
+}
+
+func (e *RequestDropped) Type() Type { return TypeRequestDropped }
+
+func (e *RequestDropped) Message() string { return "Dropped: " + e.Reason }
+
+// RequestExpired is published when a request reaches its deadline, or gets
+// too close to it to submit a result, before it is answered
+type RequestExpired struct {
+	Header
+	Stage    string
+	Deadline time.Time
+}
+
+func (e *RequestExpired) Type() Type { return TypeRequestExpired }
+
+func (e *RequestExpired) Message() string {
+	return fmt.Sprintf("Expired at stage %s, deadline %s", e.Stage, e.Deadline.Format(time.RFC3339))
+}
+
+// ComputeDone is published when the compute engine returns a result
+type ComputeDone struct {
+	Header
+	TaskID   string
+	Result   []byte
+	Duration time.Duration
+}
+
+func (e *ComputeDone) Type() Type { return TypeComputeDone }
+
+func (e *ComputeDone) Message() string {
+	return fmt.Sprintf("Computed in %s", e.Duration.Round(time.Millisecond))
+}
+
+// ComputeFailed is published when the compute engine fails or times out
+type ComputeFailed struct {
+	Header
+	Err error
+}
+
+func (e *ComputeFailed) Type() Type { return TypeComputeFailed }
+
+func (e *ComputeFailed) Message() string { return "Computation failed: " + e.Err.Error() }
+
+// ConsensusChecked is published once the consensus engine has been asked for
+// the agreed result of a request
+type ConsensusChecked struct {
+	Header
+	Reached bool
+	// Result is the agreed result, empty when consensus was not reached
+	Result []byte
+	// Votes holds the result submitted by each participant
+	Votes map[string][]byte
+}
+
+func (e *ConsensusChecked) Type() Type {
+	if e.Reached {
+		return TypeConsensusReached
+	}
+	return TypeConsensusSplit
+}
+
+func (e *ConsensusChecked) Message() string {
+	if e.Reached {
+		return fmt.Sprintf("Consensus reached with %d votes", len(e.Votes))
+	}
+	return fmt.Sprintf("Consensus not reached with %d votes", len(e.Votes))
+}
+
+// ProofGenerated is published when the proof for the agreed result is ready
+type ProofGenerated struct {
+	Header
+	Proof []byte
+}
+
+func (e *ProofGenerated) Type() Type { return TypeProofGenerated }
+
+func (e *ProofGenerated) Message() string {
+	return fmt.Sprintf("Generated %d byte proof", len(e.Proof))
+}
+
+// ProofFailed is published when no proof could be generated
+type ProofFailed struct {
+	Header
+	Err error
+}
+
+func (e *ProofFailed) Type() Type { return TypeProofFailed }
+
+func (e *ProofFailed) Message() string { return "Proof generation failed: " + e.Err.Error() }
+
+// TxSent is published when a transaction has been broadcast
+type TxSent struct {
+	Header
+	Nonce    uint64
+	GasLimit uint64
+}
+
+func (e *TxSent) Type() Type { return TypeTxSent }
+
+func (e *TxSent) Message() string {
+	return fmt.Sprintf("Sent transaction with nonce %d and gas limit %d", e.Nonce, e.GasLimit)
+}
+
+// TxMined is published when a transaction succeeded on-chain
+type TxMined struct {
+	Header
+	GasUsed uint64
+	// Fee is the fee paid in wei, nil when the node did not report a gas price
+	Fee *big.Int
+}
+
+func (e *TxMined) Type() Type { return TypeTxMined }
+
+func (e *TxMined) Message() string {
+	return fmt.Sprintf("Confirmed in block %d, gas used %d", e.Block, e.GasUsed)
+}
+
+// TxReverted is published when a transaction was mined but reverted
+type TxReverted struct {
+	Header
+	Reason  string
+	GasUsed uint64
+	// Fee is the fee paid in wei, nil when the node did not report a gas price
+	Fee *big.Int
+}
+
+func (e *TxReverted) Type() Type { return TypeTxReverted }
+
+func (e *TxReverted) Message() string { return e.Reason }
+
+// TxFailed is published when a transaction could not be sent or its receipt
+// never arrived
+type TxFailed struct {
+	Header
+	Err error
+}
+
+func (e *TxFailed) Type() Type { return TypeTxFailed }
+
+func (e *TxFailed) Message() string { return "Transaction failed: " + e.Err.Error() }
+
+// HeadChecked is published whenever the node polls the chain head, before it
+// processes the new blocks
+type HeadChecked struct {
+	Header
+	Head          uint64
+	LastProcessed uint64
+}
+
+func (e *HeadChecked) Type() Type { return TypeHeadChecked }
+
+func (e *HeadChecked) Message() string {
+	return fmt.Sprintf("Chain head is %d, %d blocks to process", e.Head, e.Backlog())
+}
+
+// Backlog returns how many blocks the node has yet to process
+func (e *HeadChecked) Backlog() uint64 {
+	if e.Head <= e.LastProcessed {
+		return 0
+	}
+	return e.Head - e.LastProcessed
+}
+
+// BalanceChecked is published whenever the operator account's balances are read
+type BalanceChecked struct {
+	Header
+	// Balance is the ETH balance in wei
+	Balance *big.Int
+	// DXP is the DXP token balance in its smallest unit, nil when no token is configured
+	DXP *big.Int
+}
+
+func (e *BalanceChecked) Type() Type { return TypeBalanceChecked }
+
+func (e *BalanceChecked) Message() string {
+	return fmt.Sprintf("Balance is %s wei", e.Balance)
+}
+
+// RegistrationChecked is published whenever the validator's registration with
+// the protocol contract is checked
+type RegistrationChecked struct {
+	Header
+	Registered bool
+}
+
+func (e *RegistrationChecked) Type() Type { return TypeRegistrationChecked }
+
+func (e *RegistrationChecked) Message() string {
+	if e.Registered {
+		return "Registered with the protocol contract"
+	}
+	return "Not registered with the protocol contract"
+}
+
+// Entry is the flat form of an event, kept in activity logs and served as JSON
+type Entry struct {
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"requestId,omitempty"`
+	TxHash    string    `json:"txHash,omitempty"`
+	Block     uint64    `json:"block,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// NewEntry flattens an event
+func NewEntry(event Event) Entry {
+	header := event.EventHeader()
+
+	return Entry{
+		Type:      event.Type(),
+		Time:      header.Time,
+		RequestID: header.RequestID,
+		TxHash:    header.TxHash,
+		Block:     header.Block,
+		Message:   event.Message(),
+	}
+}
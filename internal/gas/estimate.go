@@ -0,0 +1,128 @@
+package gas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrSimulationFailed is returned when the pre-flight call of a transaction fails
+var ErrSimulationFailed = errors.New("transaction simulation failed")
+
+// CallBackend is the subset of the Ethereum client needed to simulate and estimate transactions
+type CallBackend interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+}
+
+// Unsigned returns a copy of opts that builds transactions through a contract
+// binding without signing or sending them. Prepare and SetLimit sign the
+// transaction once its gas limit is known, so a remote signer is only asked once.
+func Unsigned(opts *bind.TransactOpts) *bind.TransactOpts {
+	unsigned := *opts
+	unsigned.NoSend = true
+	unsigned.Signer = func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+
+	return &unsigned
+}
+
+// Prepare checks a transaction built with Unsigned against the latest block,
+// sizes its gas limit from an estimate and signs it, ready to be broadcast
+func Prepare(ctx context.Context, backend CallBackend, cfg *config.Config, opts *bind.TransactOpts, tx *types.Transaction) (*types.Transaction, error) {
+	if err := Simulate(ctx, backend, opts.From, tx); err != nil {
+		return nil, err
+	}
+
+	return SetLimit(ctx, backend, cfg, opts, tx)
+}
+
+// Simulate executes the transaction as an eth_call at the latest block and
+// decodes the revert reason if it would fail, so no gas is spent on a doomed
+// transaction. It runs with the transaction's gas limit, if it has one.
+// Transactions still pending are not taken into account.
+func Simulate(ctx context.Context, backend CallBackend, from common.Address, tx *types.Transaction) error {
+	msg := callMsg(from, tx)
+	msg.Gas = tx.Gas()
+	if _, err := backend.CallContract(ctx, msg, nil); err != nil {
+		return fmt.Errorf("%w: %s", ErrSimulationFailed, contracts.RevertReason(err))
+	}
+
+	return nil
+}
+
+// SetLimit estimates the gas used by a transaction built with Unsigned, adds
+// the configured safety margin and signs it with the new limit. The
+// configured gas limit is used only when estimation fails.
+func SetLimit(ctx context.Context, backend CallBackend, cfg *config.Config, opts *bind.TransactOpts, tx *types.Transaction) (*types.Transaction, error) {
+	limit := cfg.GasLimit
+
+	estimate, err := backend.EstimateGas(ctx, callMsg(opts.From, tx))
+	if err != nil {
+		logging.FromContext(ctx).Warn("Gas estimation failed, falling back to the configured gas limit", "gasLimit", limit, "reason", contracts.RevertReason(err))
+	} else {
+		limit = uint64(float64(estimate) * (1 + cfg.GasLimitMargin))
+	}
+
+	if limit != tx.Gas() {
+		tx = withGas(tx, limit)
+	}
+
+	return opts.Signer(opts.From, tx)
+}
+
+// callMsg converts a transaction into a call message from the given sender,
+// without a gas limit so estimation is not capped by the placeholder one
+func callMsg(from common.Address, tx *types.Transaction) ethereum.CallMsg {
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+
+	if tx.Type() == types.DynamicFeeTxType {
+		msg.GasFeeCap = tx.GasFeeCap()
+		msg.GasTipCap = tx.GasTipCap()
+	} else {
+		msg.GasPrice = tx.GasPrice()
+	}
+
+	return msg
+}
+
+// withGas returns a copy of an unsigned transaction with a different gas limit
+func withGas(tx *types.Transaction, gasLimit uint64) *types.Transaction {
+	if tx.Type() == types.DynamicFeeTxType {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tx.GasTipCap(),
+			GasFeeCap:  tx.GasFeeCap(),
+			Gas:        gasLimit,
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	}
+
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasPrice(),
+		Gas:      gasLimit,
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	})
+}
@@ -0,0 +1,152 @@
+package gas
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeCaller simulates and estimates transactions, keeping the messages it
+// was given
+type fakeCaller struct {
+	callErr     error
+	estimate    uint64
+	estimateErr error
+
+	calls     []ethereum.CallMsg
+	estimates []ethereum.CallMsg
+}
+
+func (c *fakeCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.calls = append(c.calls, msg)
+	return nil, c.callErr
+}
+
+func (c *fakeCaller) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	c.estimates = append(c.estimates, msg)
+	return c.estimate, c.estimateErr
+}
+
+func TestPrepare(t *testing.T) {
+	const placeholder = 3_000_000
+
+	tests := []struct {
+		name   string
+		caller *fakeCaller
+
+		wantErr   error
+		wantLimit uint64
+		// wantEstimates is how many estimates were made
+		wantEstimates int
+	}{
+		{
+			name:          "limit from the estimate and the margin",
+			caller:        &fakeCaller{estimate: 100_000},
+			wantLimit:     120_000,
+			wantEstimates: 1,
+		},
+		{
+			name:          "failed estimate falls back to the configured limit",
+			caller:        &fakeCaller{estimateErr: errors.New("gas required exceeds allowance")},
+			wantLimit:     placeholder,
+			wantEstimates: 1,
+		},
+		{
+			name:    "reverting transaction is not signed",
+			caller:  &fakeCaller{callErr: errors.New("execution reverted: not registered")},
+			wantErr: ErrSimulationFailed,
+		},
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(31337)
+	txSigner := types.LatestSignerForChainID(chainID)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed := 0
+			opts := &bind.TransactOpts{
+				From: crypto.PubkeyToAddress(key.PublicKey),
+				Signer: func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+					signed++
+					return types.SignTx(tx, txSigner, key)
+				},
+			}
+			cfg := &config.Config{GasLimit: placeholder, GasLimitMargin: 0.2}
+
+			// Built the way a binding called with Unsigned(opts) builds it
+			to := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+			unsigned, err := Unsigned(opts).Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+				ChainID:   chainID,
+				GasTipCap: big.NewInt(1e9),
+				GasFeeCap: big.NewInt(3e9),
+				Gas:       placeholder,
+				To:        &to,
+				Data:      []byte{0xde, 0xad},
+			}))
+			if err != nil || signed != 0 {
+				t.Fatalf("building signed the transaction (%v)", err)
+			}
+
+			tx, err := Prepare(context.Background(), tt.caller, cfg, opts, unsigned)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if signed != 0 || len(tt.caller.estimates) != 0 {
+					t.Errorf("signed %d times after %d estimates, want neither", signed, len(tt.caller.estimates))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Prepare: %v", err)
+			}
+
+			if len(tt.caller.calls) != 1 || tt.caller.calls[0].Gas != placeholder {
+				t.Errorf("simulated %d times with gas %v, want once with the transaction's limit", len(tt.caller.calls), tt.caller.calls)
+			}
+			if len(tt.caller.estimates) != tt.wantEstimates || tt.caller.estimates[0].Gas != 0 {
+				t.Errorf("estimated %d times (%v), want %d without a gas cap", len(tt.caller.estimates), tt.caller.estimates, tt.wantEstimates)
+			}
+			if signed != 1 {
+				t.Errorf("signed %d times, want once", signed)
+			}
+			if tx.Gas() != tt.wantLimit {
+				t.Errorf("gas limit %d, want %d", tx.Gas(), tt.wantLimit)
+			}
+			if sender, err := types.Sender(txSigner, tx); err != nil || sender != opts.From {
+				t.Errorf("signed by %s (%v), want %s", sender.Hex(), err, opts.From.Hex())
+			}
+		})
+	}
+}
+
+func TestUnsigned(t *testing.T) {
+	original := func(common.Address, *types.Transaction) (*types.Transaction, error) {
+		return nil, errors.New("signed")
+	}
+	opts := &bind.TransactOpts{Signer: original}
+
+	unsigned := Unsigned(opts)
+	if !unsigned.NoSend {
+		t.Error("transactions built with Unsigned would be sent")
+	}
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1})
+	if got, err := unsigned.Signer(common.Address{}, tx); err != nil || got != tx {
+		t.Errorf("Unsigned signer returned %v, %v; want the transaction unchanged", got, err)
+	}
+	if _, err := opts.Signer(common.Address{}, tx); err == nil || opts.NoSend {
+		t.Error("Unsigned changed the options it was given")
+	}
+}
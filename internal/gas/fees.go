@@ -0,0 +1,144 @@
+package gas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrFeeCeiling is returned when current network fees exceed the configured hard ceiling
+var ErrFeeCeiling = errors.New("network fees exceed the configured ceiling")
+
+// ErrMaxFeeTooLow is returned when the configured max fee per gas is below
+// what the transaction needs to be included at the current base fee
+var ErrMaxFeeTooLow = errors.New("max fee per gas is too low for the current base fee")
+
+// Backend is the subset of the Ethereum client needed to price transactions
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+}
+
+// Fees holds the fee parameters chosen for a transaction.
+// GasPrice is only set on chains without EIP-1559 support.
+type Fees struct {
+	BaseFee   *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+	GasPrice  *big.Int
+}
+
+// Suggest computes transaction fees from the latest base fee and the suggested
+// priority fee, applying the multiplier and caps from the configuration
+func Suggest(ctx context.Context, backend Backend, cfg *config.Config) (*Fees, error) {
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %v", err)
+	}
+
+	ceiling := gweiToWei(cfg.GasFeeCeilingGwei)
+
+	// Fall back to legacy pricing on chains without a base fee
+	if head.BaseFee == nil {
+		gasPrice, err := backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+		gasPrice = multiply(gasPrice, cfg.GasPriceMultiplier)
+
+		if ceiling != nil && gasPrice.Cmp(ceiling) > 0 {
+			return nil, fmt.Errorf("%w: gas price %s gwei, ceiling %s gwei", ErrFeeCeiling, FormatGwei(gasPrice), FormatGwei(ceiling))
+		}
+
+		return &Fees{GasPrice: gasPrice}, nil
+	}
+
+	tip, err := backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas tip cap: %v", err)
+	}
+	tip = multiply(tip, cfg.GasPriceMultiplier)
+
+	if maxTip := gweiToWei(cfg.MaxPriorityFeePerGasGwei); maxTip != nil && tip.Cmp(maxTip) > 0 {
+		tip = maxTip
+	}
+
+	// Refuse to send when the fee needed for inclusion right now is above the ceiling
+	current := new(big.Int).Add(head.BaseFee, tip)
+	if ceiling != nil && current.Cmp(ceiling) > 0 {
+		return nil, fmt.Errorf("%w: base fee %s gwei + tip %s gwei, ceiling %s gwei",
+			ErrFeeCeiling, FormatGwei(head.BaseFee), FormatGwei(tip), FormatGwei(ceiling))
+	}
+
+	// Leave room for the base fee to double before the transaction is priced out
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	if maxFee := gweiToWei(cfg.MaxFeePerGasGwei); maxFee != nil && feeCap.Cmp(maxFee) > 0 {
+		feeCap = maxFee
+	}
+
+	// A fee cap below the current base fee plus tip would be signed and sent
+	// but sit in the mempool until the request expires
+	if feeCap.Cmp(current) < 0 {
+		return nil, fmt.Errorf("%w: max fee %s gwei, base fee %s gwei + tip %s gwei",
+			ErrMaxFeeTooLow, FormatGwei(feeCap), FormatGwei(head.BaseFee), FormatGwei(tip))
+	}
+
+	return &Fees{
+		BaseFee:   head.BaseFee,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+	}, nil
+}
+
+// Apply sets the fees on the transaction options
+func (f *Fees) Apply(opts *bind.TransactOpts) {
+	if f.GasPrice != nil {
+		opts.GasPrice = f.GasPrice
+		opts.GasFeeCap = nil
+		opts.GasTipCap = nil
+		return
+	}
+
+	opts.GasPrice = nil
+	opts.GasFeeCap = f.GasFeeCap
+	opts.GasTipCap = f.GasTipCap
+}
+
+// String returns a human-readable summary of the fees
+func (f *Fees) String() string {
+	if f.GasPrice != nil {
+		return fmt.Sprintf("gas price %s gwei (legacy)", FormatGwei(f.GasPrice))
+	}
+
+	return fmt.Sprintf("base fee %s gwei, max fee %s gwei, priority fee %s gwei",
+		FormatGwei(f.BaseFee), FormatGwei(f.GasFeeCap), FormatGwei(f.GasTipCap))
+}
+
+// FormatGwei converts wei to a gwei string for display
+func FormatGwei(wei *big.Int) string {
+	gwei := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9))
+	return gwei.Text('f', 4)
+}
+
+// gweiToWei converts a configured gwei amount to wei, returning nil when unset
+func gweiToWei(gwei float64) *big.Int {
+	if gwei <= 0 {
+		return nil
+	}
+
+	wei, _ := new(big.Float).Mul(big.NewFloat(gwei), big.NewFloat(1e9)).Int(nil)
+	return wei
+}
+
+// multiply scales a wei amount by the given multiplier
+func multiply(wei *big.Int, multiplier float64) *big.Int {
+	scaled := new(big.Float).Mul(new(big.Float).SetInt(wei), big.NewFloat(multiplier))
+	result, _ := scaled.Int(nil)
+	return result
+}
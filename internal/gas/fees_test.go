@@ -0,0 +1,140 @@
+package gas
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeBackend prices transactions from fixed values
+type fakeBackend struct {
+	baseFee  *big.Int
+	gasPrice *big.Int
+	tip      *big.Int
+}
+
+func (b *fakeBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), BaseFee: b.baseFee}, nil
+}
+
+func (b *fakeBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return b.gasPrice, nil
+}
+
+func (b *fakeBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return b.tip, nil
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend *fakeBackend
+		cfg     config.Config
+
+		wantErr   error
+		wantPrice *big.Int
+		wantTip   *big.Int
+		wantCap   *big.Int
+	}{
+		{
+			name:    "fee cap leaves room for the base fee to double",
+			backend: &fakeBackend{baseFee: gwei(10), tip: gwei(2)},
+			cfg:     config.Config{GasPriceMultiplier: 1},
+			wantTip: gwei(2),
+			wantCap: gwei(22),
+		},
+		{
+			name:    "multiplier scales the tip",
+			backend: &fakeBackend{baseFee: gwei(10), tip: gwei(2)},
+			cfg:     config.Config{GasPriceMultiplier: 1.5},
+			wantTip: gwei(3),
+			wantCap: gwei(23),
+		},
+		{
+			name:    "tip is capped by the max priority fee",
+			backend: &fakeBackend{baseFee: gwei(10), tip: gwei(5)},
+			cfg:     config.Config{GasPriceMultiplier: 1, MaxPriorityFeePerGasGwei: 1},
+			wantTip: gwei(1),
+			wantCap: gwei(21),
+		},
+		{
+			name:    "fee cap is capped by the max fee",
+			backend: &fakeBackend{baseFee: gwei(10), tip: gwei(2)},
+			cfg:     config.Config{GasPriceMultiplier: 1, MaxFeePerGasGwei: 15},
+			wantTip: gwei(2),
+			wantCap: gwei(15),
+		},
+		{
+			name:    "max fee below base fee plus tip is refused",
+			backend: &fakeBackend{baseFee: gwei(10), tip: gwei(2)},
+			cfg:     config.Config{GasPriceMultiplier: 1, MaxFeePerGasGwei: 11},
+			wantErr: ErrMaxFeeTooLow,
+		},
+		{
+			name:    "base fee plus tip above the ceiling is refused",
+			backend: &fakeBackend{baseFee: gwei(10), tip: gwei(2)},
+			cfg:     config.Config{GasPriceMultiplier: 1, GasFeeCeilingGwei: 11},
+			wantErr: ErrFeeCeiling,
+		},
+		{
+			name:    "ceiling only applies to the fee needed now",
+			backend: &fakeBackend{baseFee: gwei(10), tip: gwei(2)},
+			cfg:     config.Config{GasPriceMultiplier: 1, GasFeeCeilingGwei: 12},
+			wantTip: gwei(2),
+			wantCap: gwei(22),
+		},
+		{
+			name:      "legacy chains use the multiplied gas price",
+			backend:   &fakeBackend{gasPrice: gwei(4)},
+			cfg:       config.Config{GasPriceMultiplier: 1.5},
+			wantPrice: gwei(6),
+		},
+		{
+			name:    "legacy gas price above the ceiling is refused",
+			backend: &fakeBackend{gasPrice: gwei(4)},
+			cfg:     config.Config{GasPriceMultiplier: 1, GasFeeCeilingGwei: 3},
+			wantErr: ErrFeeCeiling,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees, err := Suggest(context.Background(), tt.backend, &tt.cfg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !equal(fees.GasPrice, tt.wantPrice) {
+				t.Errorf("gas price %v, want %v", fees.GasPrice, tt.wantPrice)
+			}
+			if !equal(fees.GasTipCap, tt.wantTip) {
+				t.Errorf("tip %v, want %v", fees.GasTipCap, tt.wantTip)
+			}
+			if !equal(fees.GasFeeCap, tt.wantCap) {
+				t.Errorf("fee cap %v, want %v", fees.GasFeeCap, tt.wantCap)
+			}
+		})
+	}
+}
+
+// equal compares two optional amounts
+func equal(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Cmp(b) == 0
+}
@@ -0,0 +1,248 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+)
+
+// FileName is the file in the data directory holding the request journal
+const FileName = "request-journal.jsonl"
+
+// maxLineSize bounds a single journal line when reading
+const maxLineSize = 1024 * 1024
+
+// Path returns the request journal of the node using cfg
+func Path(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, FileName)
+}
+
+// Journal is the durable record of every verification request the node has
+// seen. Each request event is appended to a JSON lines file as it happens, so
+// a request's history survives restarts and log rotation. Events are recorded
+// in memory at once and written to disk by a writer goroutine, so recording
+// never waits for the disk; the only exception is a sent transaction, whose
+// hash must survive a crash right after it was broadcast.
+type Journal struct {
+	mutex sync.Mutex
+	path  string
+	// writeMutex serializes writes to the file, so lines keep their order
+	// whether the writer or Record writes them
+	writeMutex sync.Mutex
+	file       *os.File
+	records    map[string]*Record
+	// pending holds the lines waiting for the writer
+	pending [][]byte
+	closed  bool
+	// wake is signalled when lines are pending or the journal is closed, and
+	// done is closed once the writer has written everything and returned
+	wake chan struct{}
+	done chan struct{}
+}
+
+// Open opens the journal at path, creating it if needed, and replays it
+func Open(path string) (*Journal, error) {
+	records, err := replay(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request journal: %v", err)
+	}
+
+	j := &Journal{
+		path:    path,
+		file:    file,
+		records: records,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go j.write()
+
+	return j, nil
+}
+
+// Record appends an event about a request to the journal. It is registered
+// as a handler on the validator's event bus; events that are not about a
+// request are ignored.
+func (j *Journal) Record(event events.Event) {
+	if event.EventHeader().RequestID == "" {
+		return
+	}
+	entry := newEntry(event)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		logging.Logger().Error("Failed to encode journal entry", logging.KeyRequestID, entry.RequestID, "err", err)
+		return
+	}
+
+	j.mutex.Lock()
+	if j.closed {
+		j.mutex.Unlock()
+		return
+	}
+	j.pending = append(j.pending, append(line, '\n'))
+	apply(j.records, entry)
+	j.mutex.Unlock()
+
+	// Without the line, a restart would not know the result was sent and
+	// could submit it again
+	if entry.Type == events.TypeTxSent {
+		j.flush()
+		return
+	}
+	j.signal()
+}
+
+// signal wakes the writer; it never blocks
+func (j *Journal) signal() {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// write writes the pending lines until the journal is closed
+func (j *Journal) write() {
+	defer close(j.done)
+
+	for range j.wake {
+		j.mutex.Lock()
+		closed := j.closed
+		j.mutex.Unlock()
+
+		j.flush()
+		if closed {
+			return
+		}
+	}
+}
+
+// flush writes the pending lines and syncs them, as the journal is what
+// disputes are settled with
+func (j *Journal) flush() {
+	j.writeMutex.Lock()
+	defer j.writeMutex.Unlock()
+
+	j.mutex.Lock()
+	lines := j.pending
+	j.pending = nil
+	j.mutex.Unlock()
+	if len(lines) == 0 || j.file == nil {
+		return
+	}
+
+	if _, err := j.file.Write(bytes.Join(lines, nil)); err != nil {
+		logging.Logger().Error("Failed to write request journal", "path", j.path, "err", err)
+	} else if err := j.file.Sync(); err != nil {
+		logging.Logger().Error("Failed to sync request journal", "path", j.path, "err", err)
+	}
+}
+
+// Get returns the record of a request
+func (j *Journal) Get(id string) (Record, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	record, ok := j.records[id]
+	if !ok {
+		return Record{}, false
+	}
+
+	return record.copy(), true
+}
+
+// Close writes the pending lines and closes the journal file; later events
+// are not recorded
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	if j.closed {
+		j.mutex.Unlock()
+		return nil
+	}
+	j.closed = true
+	j.mutex.Unlock()
+
+	j.signal()
+	<-j.done
+
+	j.writeMutex.Lock()
+	defer j.writeMutex.Unlock()
+	err := j.file.Close()
+	j.file = nil
+
+	return err
+}
+
+// Read returns the records in the journal at path, most recently updated
+// first. It can be read while the node is writing to it.
+func Read(path string) ([]Record, error) {
+	records, err := replay(path)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Record, 0, len(records))
+	for _, record := range records {
+		list = append(list, *record)
+	}
+	sort.Slice(list, func(i, k int) bool {
+		return list[i].Updated.After(list[k].Updated)
+	})
+
+	return list, nil
+}
+
+// replay reads the journal at path into records by request ID. A missing
+// journal is empty; unreadable lines, such as one cut short by a crash, are
+// skipped.
+func replay(path string) (map[string]*Record, error) {
+	records := make(map[string]*Record)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request journal: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	skipped := 0
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.RequestID == "" {
+			skipped++
+			continue
+		}
+		apply(records, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read request journal: %v", err)
+	}
+
+	if skipped > 0 {
+		logging.Logger().Warn("Skipped unreadable request journal lines", "path", path, "lines", skipped)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,270 @@
+package journal
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/events"
+)
+
+func header(id string, block uint64, offset time.Duration) events.Header {
+	return events.Header{
+		Time:      time.Unix(1_700_000_000, 0).Add(offset),
+		RequestID: id,
+		Block:     block,
+	}
+}
+
+func TestJournalReplay(t *testing.T) {
+	deadline := time.Unix(1_700_003_600, 0).UTC()
+
+	found := &events.RequestFound{
+		Header:    header("1", 100, 0),
+		Requester: "0xabc",
+		BlockHash: "0xb10c",
+		LogIndex:  3,
+		Deadline:  deadline,
+	}
+	computed := &events.ComputeDone{Header: header("1", 0, time.Second), Result: []byte("42")}
+	agreed := &events.ConsensusChecked{
+		Header:  header("1", 0, 2*time.Second),
+		Reached: true,
+		Result:  []byte("42"),
+		Votes:   map[string][]byte{"0xb": []byte("42"), "0xa": []byte("42")},
+	}
+	sent := &events.TxSent{Header: events.Header{Time: time.Unix(1_700_000_003, 0), RequestID: "1", TxHash: "0x7a"}}
+
+	tests := []struct {
+		name   string
+		events []events.Event
+		// tail is appended to the journal file before it is replayed
+		tail string
+
+		wantStatus  string
+		wantSettled bool
+		check       func(t *testing.T, r Record)
+	}{
+		{
+			name:       "found",
+			events:     []events.Event{found},
+			wantStatus: StatusQueued,
+			check: func(t *testing.T, r Record) {
+				if r.Requester != "0xabc" || r.Block != 100 || r.BlockHash != "0xb10c" || r.LogIndex != 3 {
+					t.Errorf("requester %q, log %d/%s/%d, want the request's", r.Requester, r.Block, r.BlockHash, r.LogIndex)
+				}
+				if r.Deadline == nil || !r.Deadline.Equal(deadline) {
+					t.Errorf("deadline %v, want %v", r.Deadline, deadline)
+				}
+			},
+		},
+		{
+			name:       "submitted",
+			events:     []events.Event{found, computed, agreed, sent},
+			wantStatus: StatusSubmitted,
+			check: func(t *testing.T, r Record) {
+				if r.Result != "42" || r.AgreedResult != "42" || r.Consensus != "reached" {
+					t.Errorf("result %q, agreed %q, consensus %q", r.Result, r.AgreedResult, r.Consensus)
+				}
+				if len(r.Votes) != 2 || r.Votes[0].Participant != "0xa" {
+					t.Errorf("votes %v, want two sorted by participant", r.Votes)
+				}
+				if r.TxHash != "0x7a" || len(r.Entries) != 4 {
+					t.Errorf("tx %q with %d entries, want 0x7a with 4", r.TxHash, len(r.Entries))
+				}
+			},
+		},
+		{
+			name: "confirmed",
+			events: []events.Event{found, sent, &events.TxMined{
+				Header:  events.Header{Time: time.Unix(1_700_000_010, 0), RequestID: "1", TxHash: "0x7a", Block: 105},
+				GasUsed: 50000,
+				Fee:     big.NewInt(1000),
+			}},
+			wantStatus:  StatusConfirmed,
+			wantSettled: true,
+			check: func(t *testing.T, r Record) {
+				if r.Receipt != ReceiptSuccess || r.ReceiptBlock != 105 || r.GasUsed != 50000 || r.Fee != "1000" {
+					t.Errorf("receipt %q in %d, gas %d, fee %s", r.Receipt, r.ReceiptBlock, r.GasUsed, r.Fee)
+				}
+				if r.Block != 100 {
+					t.Errorf("block %d, want the block the request was found in", r.Block)
+				}
+			},
+		},
+		{
+			name: "reverted",
+			events: []events.Event{found, sent, &events.TxReverted{
+				Header: events.Header{Time: time.Unix(1_700_000_010, 0), RequestID: "1", TxHash: "0x7a", Block: 105},
+				Reason: "already submitted",
+			}},
+			wantStatus:  StatusReverted,
+			wantSettled: true,
+			check: func(t *testing.T, r Record) {
+				if r.Receipt != ReceiptReverted || r.Error != "already submitted" {
+					t.Errorf("receipt %q, error %q", r.Receipt, r.Error)
+				}
+			},
+		},
+		{
+			name:       "failed transactions can be retried",
+			events:     []events.Event{found, &events.TxFailed{Header: header("1", 0, time.Second), Err: errors.New("nonce too low")}},
+			wantStatus: StatusFailed,
+			check: func(t *testing.T, r Record) {
+				if r.Error != "nonce too low" {
+					t.Errorf("error %q", r.Error)
+				}
+			},
+		},
+		{
+			name: "split consensus can be retried",
+			events: []events.Event{found, &events.ConsensusChecked{
+				Header: header("1", 0, time.Second),
+				Votes:  map[string][]byte{"0xa": []byte("1"), "0xb": []byte("2")},
+			}},
+			wantStatus: StatusFailed,
+		},
+		{
+			name:       "set aside",
+			events:     []events.Event{found, &events.RequestSetAside{Header: header("1", 0, time.Second)}},
+			wantStatus: StatusSetAside,
+		},
+		{
+			name:        "dropped",
+			events:      []events.Event{found, &events.RequestDropped{Header: header("1", 0, time.Second), Reason: "too late"}},
+			wantStatus:  StatusDropped,
+			wantSettled: true,
+		},
+		{
+			name:        "expired before it was queued",
+			events:      []events.Event{&events.RequestExpired{Header: header("1", 90, 0), Stage: "intake", Deadline: deadline}},
+			wantStatus:  StatusExpired,
+			wantSettled: true,
+			check: func(t *testing.T, r Record) {
+				if r.Block != 90 {
+					t.Errorf("block %d, want the block it expired in", r.Block)
+				}
+			},
+		},
+		{
+			name:       "line cut short by a crash is skipped",
+			events:     []events.Event{found, computed},
+			tail:       `{"type":"consensus_reached","requestId":"1","res`,
+			wantStatus: StatusComputed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+
+			j, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			for _, event := range tt.events {
+				j.Record(event)
+			}
+			// Events that are not about a request are not recorded
+			j.Record(&events.BlockProcessed{Header: header("", 100, 0)})
+
+			live, ok := j.Get("1")
+			if !ok {
+				t.Fatal("request 1 is not in the journal")
+			}
+			if err := j.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if tt.tail != "" {
+				file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				file.WriteString(tt.tail)
+				file.Close()
+			}
+
+			// The replayed record matches the one kept in memory
+			records, err := Read(path)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if len(records) != 1 {
+				t.Fatalf("replayed %d records, want 1", len(records))
+			}
+			replayed := records[0]
+
+			for _, r := range []Record{live, replayed} {
+				if r.Status != tt.wantStatus {
+					t.Errorf("status %q, want %q", r.Status, tt.wantStatus)
+				}
+				if r.Settled() != tt.wantSettled {
+					t.Errorf("settled %v, want %v", r.Settled(), tt.wantSettled)
+				}
+				if !r.FirstSeen.Equal(tt.events[0].EventHeader().Time) {
+					t.Errorf("first seen %v, want %v", r.FirstSeen, tt.events[0].EventHeader().Time)
+				}
+				if tt.check != nil {
+					tt.check(t, r)
+				}
+			}
+			if len(replayed.Entries) != len(live.Entries) {
+				t.Errorf("replayed %d entries, recorded %d", len(replayed.Entries), len(live.Entries))
+			}
+		})
+	}
+}
+
+func TestRecordSettled(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{StatusQueued, false},
+		{StatusProcessing, false},
+		{StatusComputed, false},
+		{StatusAgreed, false},
+		{StatusProved, false},
+		{StatusSubmitted, false},
+		{StatusConfirmed, true},
+		{StatusReverted, true},
+		{StatusFailed, false},
+		{StatusSetAside, false},
+		{StatusDropped, true},
+		{StatusExpired, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			r := Record{Status: tt.status}
+			if got := r.Settled(); got != tt.want {
+				t.Errorf("Settled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentTxIsWrittenBeforeRecordReturns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer j.Close()
+
+	j.Record(&events.RequestFound{Header: header("1", 100, 0)})
+	j.Record(&events.TxSent{Header: events.Header{Time: time.Unix(1_700_000_003, 0), RequestID: "1", TxHash: "0x7a"}})
+
+	// Read as a restart after a crash would, without closing the journal
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != 1 || records[0].TxHash != "0x7a" || len(records[0].Entries) != 2 {
+		t.Fatalf("journal on disk holds %+v, want request 1 sent in 0x7a", records)
+	}
+}
@@ -0,0 +1,239 @@
+package journal
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/events"
+)
+
+// Request statuses in the journal
+const (
+	StatusQueued     = "queued"
+	StatusProcessing = "processing"
+	StatusComputed   = "computed"
+	StatusAgreed     = "agreed"
+	StatusProved     = "proved"
+	StatusSubmitted  = "submitted"
+	StatusConfirmed  = "confirmed"
+	StatusReverted   = "reverted"
+	StatusFailed     = "failed"
+	StatusSetAside   = "set aside"
+	StatusDropped    = "dropped"
+	StatusExpired    = "expired"
+)
+
+// Receipt statuses
+const (
+	ReceiptSuccess  = "success"
+	ReceiptReverted = "reverted"
+)
+
+// Vote is the result a consensus participant submitted for a request
+type Vote struct {
+	Participant string `json:"participant"`
+	Result      string `json:"result"`
+}
+
+// Entry is one line of the journal: an event about a request with the
+// details the journal keeps
+type Entry struct {
+	events.Entry
+	Requester string `json:"requester,omitempty"`
+	BlockHash string `json:"blockHash,omitempty"`
+	LogIndex  uint   `json:"logIndex,omitempty"`
+	// Deadline is when the request expires
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Result is the computed result, or the agreed result for consensus
+	Result  string `json:"result,omitempty"`
+	Votes   []Vote `json:"votes,omitempty"`
+	Proof   string `json:"proof,omitempty"`
+	GasUsed uint64 `json:"gasUsed,omitempty"`
+	// Fee is the transaction fee in wei
+	Fee   string `json:"fee,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Record is everything the journal knows about a request
+type Record struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Requester string `json:"requester,omitempty"`
+	// Block is the block the request was found in, and BlockHash and
+	// LogIndex the log it was emitted in
+	Block     uint64 `json:"block,omitempty"`
+	BlockHash string `json:"blockHash,omitempty"`
+	LogIndex  uint   `json:"logIndex,omitempty"`
+	// Deadline is when the request expires, if it does
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Result is the result this node computed
+	Result string `json:"result,omitempty"`
+	// Consensus is "reached" or "split" once the votes were counted
+	Consensus    string `json:"consensus,omitempty"`
+	AgreedResult string `json:"agreedResult,omitempty"`
+	Votes        []Vote `json:"votes,omitempty"`
+	Proof        string `json:"proof,omitempty"`
+	TxHash       string `json:"txHash,omitempty"`
+	// Receipt is the status of the mined transaction, in ReceiptBlock
+	Receipt      string `json:"receipt,omitempty"`
+	ReceiptBlock uint64 `json:"receiptBlock,omitempty"`
+	GasUsed      uint64 `json:"gasUsed,omitempty"`
+	Fee          string `json:"fee,omitempty"`
+	// Error is the last failure or revert reason
+	Error     string    `json:"error,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	Updated   time.Time `json:"updated"`
+	// Entries holds the request's history, oldest first
+	Entries []Entry `json:"entries,omitempty"`
+}
+
+// Settled reports whether the request needs no more work: its result was
+// mined, or it was dropped or expired. A failed request may succeed when
+// retried, and one set aside or cut short by a crash is not settled either.
+func (r *Record) Settled() bool {
+	switch r.Status {
+	case StatusConfirmed, StatusReverted, StatusDropped, StatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// copy returns a copy of the record that shares nothing with it
+func (r *Record) copy() Record {
+	copied := *r
+	copied.Votes = append([]Vote(nil), r.Votes...)
+	copied.Entries = append([]Entry(nil), r.Entries...)
+
+	return copied
+}
+
+// newEntry builds the journal entry for an event
+func newEntry(event events.Event) Entry {
+	entry := Entry{Entry: events.NewEntry(event)}
+
+	switch e := event.(type) {
+	case *events.RequestFound:
+		entry.Requester = e.Requester
+		entry.BlockHash = e.BlockHash
+		entry.LogIndex = e.LogIndex
+		if !e.Deadline.IsZero() {
+			entry.Deadline = &e.Deadline
+		}
+	case *events.ComputeDone:
+		entry.Result = string(e.Result)
+	case *events.RequestDropped:
+		entry.Error = e.Reason
+	case *events.RequestExpired:
+		entry.Deadline = &e.Deadline
+		entry.Error = e.Message()
+	case *events.ComputeFailed:
+		entry.Error = e.Err.Error()
+	case *events.ConsensusChecked:
+		entry.Result = string(e.Result)
+		for participant, result := range e.Votes {
+			entry.Votes = append(entry.Votes, Vote{Participant: participant, Result: string(result)})
+		}
+		sort.Slice(entry.Votes, func(i, k int) bool {
+			return entry.Votes[i].Participant < entry.Votes[k].Participant
+		})
+	case *events.ProofGenerated:
+		entry.Proof = string(e.Proof)
+	case *events.ProofFailed:
+		entry.Error = e.Err.Error()
+	case *events.TxMined:
+		entry.GasUsed = e.GasUsed
+		if e.Fee != nil {
+			entry.Fee = e.Fee.String()
+		}
+	case *events.TxReverted:
+		entry.GasUsed = e.GasUsed
+		if e.Fee != nil {
+			entry.Fee = e.Fee.String()
+		}
+		entry.Error = e.Reason
+	case *events.TxFailed:
+		entry.Error = e.Err.Error()
+	}
+
+	return entry
+}
+
+// apply adds an entry to the record of its request
+func apply(records map[string]*Record, entry Entry) {
+	record, ok := records[entry.RequestID]
+	if !ok {
+		record = &Record{ID: entry.RequestID, FirstSeen: entry.Time}
+		records[entry.RequestID] = record
+	}
+
+	record.Entries = append(record.Entries, entry)
+	record.Updated = entry.Time
+	if entry.TxHash != "" {
+		record.TxHash = entry.TxHash
+	}
+	if entry.Error != "" {
+		record.Error = entry.Error
+	}
+
+	switch entry.Type {
+	case events.TypeRequestFound:
+		record.Status = StatusQueued
+		if entry.Requester != "" {
+			record.Requester = entry.Requester
+		}
+		if entry.Block != 0 {
+			record.Block = entry.Block
+		}
+		if entry.BlockHash != "" {
+			record.BlockHash = entry.BlockHash
+			record.LogIndex = entry.LogIndex
+		}
+		if entry.Deadline != nil {
+			record.Deadline = entry.Deadline
+		}
+	case events.TypeRequestStarted:
+		record.Status = StatusProcessing
+	case events.TypeRequestSetAside:
+		record.Status = StatusSetAside
+	case events.TypeRequestDropped:
+		record.Status = StatusDropped
+	case events.TypeRequestExpired:
+		record.Status = StatusExpired
+		record.Deadline = entry.Deadline
+		// A request can expire before it is queued
+		if record.Block == 0 {
+			record.Block = entry.Block
+		}
+	case events.TypeComputeDone:
+		record.Status = StatusComputed
+		record.Result = entry.Result
+	case events.TypeConsensusReached, events.TypeConsensusSplit:
+		record.Votes = entry.Votes
+		if entry.Type == events.TypeConsensusReached {
+			record.Status = StatusAgreed
+			record.Consensus = "reached"
+			record.AgreedResult = entry.Result
+		} else {
+			record.Status = StatusFailed
+			record.Consensus = "split"
+		}
+	case events.TypeProofGenerated:
+		record.Status = StatusProved
+		record.Proof = entry.Proof
+	case events.TypeTxSent:
+		record.Status = StatusSubmitted
+	case events.TypeTxMined, events.TypeTxReverted:
+		record.Status = StatusConfirmed
+		record.Receipt = ReceiptSuccess
+		if entry.Type == events.TypeTxReverted {
+			record.Status = StatusReverted
+			record.Receipt = ReceiptReverted
+		}
+		record.ReceiptBlock = entry.Block
+		record.GasUsed = entry.GasUsed
+		record.Fee = entry.Fee
+	case events.TypeComputeFailed, events.TypeProofFailed, events.TypeTxFailed:
+		record.Status = StatusFailed
+	}
+}
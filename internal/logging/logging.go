@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Correlation attribute keys shared by every package that logs about a
+// verification request or transaction
+const (
+	KeyRequestID = "requestID"
+	KeyTxHash    = "txHash"
+	KeyBlock     = "block"
+)
+
+// Options configures the node's logger
+type Options struct {
+	// Level is debug, info, warn or error
+	Level string
+	// Format is text or json
+	Format string
+	// File is the log file; logs go to stderr when it is empty
+	File string
+	// MaxSizeMB is the size at which the log file is rotated
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files kept
+	MaxBackups int
+}
+
+var (
+	// level is shared by every handler so it can be changed at runtime
+	level = new(slog.LevelVar)
+
+	mutex  sync.Mutex
+	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+)
+
+// contextKey is the context key of the logger carrying correlation fields
+type contextKey struct{}
+
+// Setup replaces the logger with one configured by opts and routes the
+// standard library logger through it. The returned closer closes the log file.
+func Setup(opts Options) (io.Closer, error) {
+	if err := SetLevel(opts.Level); err != nil {
+		return nil, err
+	}
+
+	var output io.WriteCloser = nopCloser{os.Stderr}
+	if opts.File != "" {
+		file, err := OpenRotatingFile(opts.File, int64(opts.MaxSizeMB)*1024*1024, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		output = file
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(output, handlerOptions)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	default:
+		output.Close()
+		return nil, fmt.Errorf("unknown log format %q, use %s or %s", opts.Format, FormatText, FormatJSON)
+	}
+
+	mutex.Lock()
+	logger = slog.New(handler)
+	mutex.Unlock()
+
+	// Messages from the standard library logger are logged at info level
+	slog.SetDefault(logger)
+
+	return output, nil
+}
+
+// Logger returns the node's logger
+func Logger() *slog.Logger {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return logger
+}
+
+// ParseLevel converts a configured log level name into a slog level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// SetLevel changes the level of the logger, including after Setup
+func SetLevel(name string) error {
+	parsed, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+
+	level.Set(parsed)
+	return nil
+}
+
+// NewContext returns a context carrying a logger, typically one with
+// correlation fields added with With
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the node's logger when
+// ctx is nil or carries none
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx == nil {
+		return Logger()
+	}
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+
+	return Logger()
+}
+
+// nopCloser keeps Close from closing stderr
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "debug", want: slog.LevelDebug},
+		{name: "", want: slog.LevelInfo},
+		{name: "INFO", want: slog.LevelInfo},
+		{name: "warn", want: slog.LevelWarn},
+		{name: "error", want: slog.LevelError},
+		{name: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		// check is given the lines written to the log file
+		check   func(t *testing.T, lines []string)
+		wantErr string
+	}{
+		{
+			name:   "json with correlation fields",
+			format: FormatJSON,
+			check: func(t *testing.T, lines []string) {
+				var entry map[string]interface{}
+				if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+					t.Fatalf("line is not JSON: %v", err)
+				}
+				if entry["msg"] != "Submitted" || entry[KeyRequestID] != "7" || entry["level"] != "WARN" {
+					t.Errorf("logged %v", entry)
+				}
+			},
+		},
+		{
+			name:   "text",
+			format: FormatText,
+			check: func(t *testing.T, lines []string) {
+				if !strings.Contains(lines[0], "level=WARN msg=Submitted requestID=7") {
+					t.Errorf("logged %q", lines[0])
+				}
+			},
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			wantErr: `unknown log format "xml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "validator.log")
+			t.Cleanup(func() { Setup(Options{}) })
+			closer, err := Setup(Options{Level: "warn", Format: tt.format, File: path})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Setup: %v", err)
+			}
+
+			// Below the level, with the request's logger from its context,
+			// and from the standard library logger
+			ctx := NewContext(context.Background(), Logger().With(KeyRequestID, "7"))
+			FromContext(ctx).Info("Ignored")
+			FromContext(ctx).Warn("Submitted")
+			log.Print("From the standard logger")
+			if err := closer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(lines) != 1 {
+				t.Fatalf("logged %d lines, want only the warning: %q", len(lines), lines)
+			}
+			tt.check(t, lines)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RotatingFile is a log file that is rotated once it reaches a maximum size.
+// Rotated files are renamed to name.1, name.2 and so on, oldest last.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// OpenRotatingFile opens path for appending. A maxSize of zero disables rotation.
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %v", err)
+	}
+
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Write appends p to the file, rotating first if p would take it over the maximum size
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the file
+func (r *RotatingFile) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.file.Close()
+}
+
+// open opens the current file and records its size
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups up by one, drops the oldest and starts a new file
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %v", err)
+	}
+
+	var err error
+	if r.maxBackups > 0 {
+		os.Remove(r.backup(r.maxBackups))
+		for i := r.maxBackups - 1; i >= 1; i-- {
+			os.Rename(r.backup(i), r.backup(i+1))
+		}
+		err = os.Rename(r.path, r.backup(1))
+	} else {
+		err = os.Remove(r.path)
+	}
+
+	if openErr := r.open(); openErr != nil {
+		return openErr
+	}
+
+	// Keep writing to the current file if it could not be moved aside, and
+	// only try again once another maxSize bytes have been written
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to rotate log file %s: %v\n", r.path, err)
+		r.size = 0
+	}
+
+	return nil
+}
+
+// backup returns the name of the n-th rotated file
+func (r *RotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
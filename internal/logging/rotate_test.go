@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxSize    int64
+		maxBackups int
+		// existing is written to the file before it is opened
+		existing string
+		writes   []string
+
+		// want is the content of the file and then of each backup, newest first
+		want []string
+	}{
+		{
+			name:    "no rotation below the maximum size",
+			maxSize: 10,
+			writes:  []string{"aaaa", "bbbb"},
+			want:    []string{"aaaabbbb"},
+		},
+		{
+			name:       "rotates before going over the maximum size",
+			maxSize:    10,
+			maxBackups: 2,
+			writes:     []string{"aaaaaa", "bbbbbb", "cccccc"},
+			want:       []string{"cccccc", "bbbbbb", "aaaaaa"},
+		},
+		{
+			name:       "oldest backup is dropped",
+			maxSize:    4,
+			maxBackups: 1,
+			writes:     []string{"aaaa", "bbbb", "cccc"},
+			want:       []string{"cccc", "bbbb"},
+		},
+		{
+			name:    "no backups kept",
+			maxSize: 4,
+			writes:  []string{"aaaa", "bbbb"},
+			want:    []string{"bbbb"},
+		},
+		{
+			name:       "size of an existing file counts",
+			maxSize:    10,
+			maxBackups: 1,
+			existing:   "previous",
+			writes:     []string{"aaaa"},
+			want:       []string{"aaaa", "previous"},
+		},
+		{
+			name:       "a write larger than the maximum size is not split",
+			maxSize:    4,
+			maxBackups: 1,
+			writes:     []string{"aaaaaaaa"},
+			want:       []string{"aaaaaaaa"},
+		},
+		{
+			name:   "rotation disabled",
+			writes: []string{"aaaa", "bbbb"},
+			want:   []string{"aaaabbbb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "logs", "validator.log")
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.existing), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			file, err := OpenRotatingFile(path, tt.maxSize, tt.maxBackups)
+			if err != nil {
+				t.Fatalf("OpenRotatingFile: %v", err)
+			}
+			for _, write := range tt.writes {
+				if _, err := file.Write([]byte(write)); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := file.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			names := []string{path}
+			for i := 1; i <= tt.maxBackups+1; i++ {
+				names = append(names, fmt.Sprintf("%s.%d", path, i))
+			}
+			var got []string
+			for _, name := range names {
+				data, err := os.ReadFile(name)
+				if err != nil {
+					break
+				}
+				got = append(got, string(data))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("files hold %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package metrics
+
+import (
+	"math/big"
+
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// namespace prefixes every metric name
+const namespace = "dxp_validator"
+
+// Consensus outcomes
+const (
+	ConsensusReached = "reached"
+	ConsensusSplit   = "split"
+)
+
+// Submission results
+const (
+	SubmissionSuccess = "success"
+	SubmissionFailure = "failure"
+)
+
+// Metrics holds the validator's Prometheus metrics. The dashboard counters are
+// read back from the same collectors, so both always agree.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	LastProcessedBlock prometheus.Gauge
+	HeadBlock          prometheus.Gauge
+	HeadLag            prometheus.Gauge
+	QueueDepth         prometheus.Gauge
+	ComputeDuration    prometheus.Histogram
+	ConsensusOutcomes  *prometheus.CounterVec
+	ProcessedRequests  prometheus.Counter
+	DroppedRequests    prometheus.Counter
+	ExpiredRequests    *prometheus.CounterVec
+	Submissions        *prometheus.CounterVec
+	GasUsed            prometheus.Counter
+	GasFees            prometheus.Counter
+	Balance            prometheus.Gauge
+	DXPBalance         prometheus.Gauge
+}
+
+// Counts holds the request counters shown on the dashboard
+type Counts struct {
+	ProcessedRequests     int
+	SuccessfulSubmissions int
+	FailedSubmissions     int
+}
+
+// New creates the validator's metrics in a registry of their own, together
+// with the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		LastProcessedBlock: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_processed_block",
+			Help:      "Number of the last block scanned for verification requests.",
+		}),
+		HeadBlock: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "head_block",
+			Help:      "Latest block number reported by the RPC endpoint.",
+		}),
+		HeadLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "head_lag_blocks",
+			Help:      "Blocks between the chain head and the last processed block.",
+		}),
+		QueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "verification_queue_depth",
+			Help:      "Verification requests waiting to be processed.",
+		}),
+		ComputeDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "compute_duration_seconds",
+			Help:      "Time taken by the compute engine per verification request.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 30},
+		}),
+		ConsensusOutcomes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "consensus_outcomes_total",
+			Help:      "Consensus rounds by outcome.",
+		}, []string{"outcome"}),
+		ProcessedRequests: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_processed_total",
+			Help:      "Verification requests taken through the pipeline, whatever the outcome.",
+		}),
+		DroppedRequests: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_dropped_total",
+			Help:      "Queued verification requests dropped for being too close to their deadline.",
+		}),
+		ExpiredRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_expired_total",
+			Help:      "Verification requests that expired before a result was submitted, by the stage they reached.",
+		}, []string{"stage"}),
+		Submissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "submissions_total",
+			Help:      "Verification result submissions by result; failures include reverts.",
+		}, []string{"result"}),
+		GasUsed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_used_total",
+			Help:      "Gas used by mined transactions sent by the validator.",
+		}),
+		GasFees: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_fees_eth_total",
+			Help:      "Transaction fees paid by the validator, in ETH.",
+		}),
+		Balance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "balance_eth",
+			Help:      "ETH balance of the operator account.",
+		}),
+		DXPBalance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "dxp_balance",
+			Help:      "DXP token balance of the operator account, available for staking.",
+		}),
+	}
+
+	// Start the labelled counters at zero so they are exported before the first event
+	for _, outcome := range []string{ConsensusReached, ConsensusSplit} {
+		m.ConsensusOutcomes.WithLabelValues(outcome)
+	}
+	for _, result := range []string{SubmissionSuccess, SubmissionFailure} {
+		m.Submissions.WithLabelValues(result)
+	}
+
+	m.registry.MustRegister(
+		m.LastProcessedBlock,
+		m.HeadBlock,
+		m.HeadLag,
+		m.QueueDepth,
+		m.ComputeDuration,
+		m.ConsensusOutcomes,
+		m.ProcessedRequests,
+		m.DroppedRequests,
+		m.ExpiredRequests,
+		m.Submissions,
+		m.GasUsed,
+		m.GasFees,
+		m.Balance,
+		m.DXPBalance,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Registry returns the registry holding the metrics
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// SetBlocks records the chain head and the last processed block
+func (m *Metrics) SetBlocks(head, lastProcessed uint64) {
+	m.HeadBlock.Set(float64(head))
+	m.LastProcessedBlock.Set(float64(lastProcessed))
+	if head > lastProcessed {
+		m.HeadLag.Set(float64(head - lastProcessed))
+	} else {
+		m.HeadLag.Set(0)
+	}
+}
+
+// Observe updates the metrics for a validator event. It is registered as a
+// handler on the validator's event bus.
+func (m *Metrics) Observe(event events.Event) {
+	// Only events about requests count as submissions; the registration
+	// transaction still adds to the gas metrics
+	request := event.EventHeader().RequestID != ""
+
+	switch e := event.(type) {
+	case *events.HeadChecked:
+		// Published on every poll, so the lag keeps growing while blocks fail
+		m.SetBlocks(e.Head, e.LastProcessed)
+	case *events.BlockProcessed:
+		m.SetBlocks(e.Head, e.Block)
+	case *events.RequestFinished:
+		m.ProcessedRequests.Inc()
+	case *events.RequestDropped:
+		m.DroppedRequests.Inc()
+	case *events.RequestExpired:
+		m.ExpiredRequests.WithLabelValues(e.Stage).Inc()
+	case *events.ComputeDone:
+		m.ComputeDuration.Observe(e.Duration.Seconds())
+	case *events.ConsensusChecked:
+		if e.Reached {
+			m.ConsensusOutcomes.WithLabelValues(ConsensusReached).Inc()
+		} else {
+			m.ConsensusOutcomes.WithLabelValues(ConsensusSplit).Inc()
+		}
+	case *events.TxMined:
+		m.addGas(e.GasUsed, e.Fee)
+		if request {
+			m.Submissions.WithLabelValues(SubmissionSuccess).Inc()
+		}
+	case *events.TxReverted:
+		m.addGas(e.GasUsed, e.Fee)
+		if request {
+			m.Submissions.WithLabelValues(SubmissionFailure).Inc()
+		}
+	case *events.BalanceChecked:
+		m.Balance.Set(ether(e.Balance))
+		if e.DXP != nil {
+			// The DXP token has 18 decimals, like ether
+			m.DXPBalance.Set(ether(e.DXP))
+		}
+	case *events.TxFailed:
+		if request {
+			m.Submissions.WithLabelValues(SubmissionFailure).Inc()
+		}
+	}
+}
+
+// addGas adds the gas used and fee paid by a mined transaction
+func (m *Metrics) addGas(gasUsed uint64, fee *big.Int) {
+	m.GasUsed.Add(float64(gasUsed))
+	if fee != nil {
+		m.GasFees.Add(ether(fee))
+	}
+}
+
+// ether converts an amount in wei to ether
+func ether(wei *big.Int) float64 {
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return value
+}
+
+// Counts returns the dashboard counters
+func (m *Metrics) Counts() Counts {
+	return Counts{
+		ProcessedRequests:     int(counterValue(m.ProcessedRequests)),
+		SuccessfulSubmissions: int(counterValue(m.Submissions.WithLabelValues(SubmissionSuccess))),
+		FailedSubmissions:     int(counterValue(m.Submissions.WithLabelValues(SubmissionFailure))),
+	}
+}
+
+// counterValue reads the current value of a counter
+func counterValue(counter prometheus.Counter) float64 {
+	var metric dto.Metric
+	if err := counter.Write(&metric); err != nil {
+		return 0
+	}
+
+	return metric.GetCounter().GetValue()
+}
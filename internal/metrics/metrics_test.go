@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gaugeValue reads the current value of a gauge
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := gauge.Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetGauge().GetValue()
+}
+
+func TestSetBlocks(t *testing.T) {
+	tests := []struct {
+		name          string
+		head          uint64
+		lastProcessed uint64
+		wantLag       float64
+	}{
+		{name: "behind the head", head: 110, lastProcessed: 100, wantLag: 10},
+		{name: "at the head", head: 110, lastProcessed: 110},
+		{name: "head reported behind", head: 105, lastProcessed: 110},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.SetBlocks(tt.head, tt.lastProcessed)
+
+			if got := gaugeValue(t, m.HeadBlock); got != float64(tt.head) {
+				t.Errorf("head block %v, want %d", got, tt.head)
+			}
+			if got := gaugeValue(t, m.LastProcessedBlock); got != float64(tt.lastProcessed) {
+				t.Errorf("last processed block %v, want %d", got, tt.lastProcessed)
+			}
+			if got := gaugeValue(t, m.HeadLag); got != tt.wantLag {
+				t.Errorf("head lag %v, want %v", got, tt.wantLag)
+			}
+		})
+	}
+}
+
+func TestCounts(t *testing.T) {
+	m := New()
+	m.ProcessedRequests.Add(3)
+	m.Submissions.WithLabelValues(SubmissionSuccess).Add(2)
+	m.Submissions.WithLabelValues(SubmissionFailure).Inc()
+
+	want := Counts{ProcessedRequests: 3, SuccessfulSubmissions: 2, FailedSubmissions: 1}
+	if got := m.Counts(); got != want {
+		t.Errorf("Counts = %+v, want %+v", got, want)
+	}
+}
+
+func TestServer(t *testing.T) {
+	m := New()
+	m.QueueDepth.Set(4)
+
+	server := NewServer("127.0.0.1:0", m)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer server.Close()
+
+	response, err := http.Get("http://" + server.listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Labelled counters are exported before anything happened, next to
+	// the runtime metrics
+	for _, want := range []string{
+		"dxp_validator_verification_queue_depth 4",
+		`dxp_validator_submissions_total{result="failure"} 0`,
+		`dxp_validator_consensus_outcomes_total{outcome="reached"} 0`,
+		"go_goroutines",
+		"process_cpu_seconds_total",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("scrape is missing %q", want)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	request := events.Header{RequestID: "7"}
+
+	tests := []struct {
+		name   string
+		events []events.Event
+		check  func(t *testing.T, m *Metrics)
+	}{
+		{
+			name: "head lag grows while blocks fail",
+			events: []events.Event{
+				&events.BlockProcessed{Header: events.Header{Block: 100}, Head: 100},
+				&events.HeadChecked{Head: 104, LastProcessed: 100},
+			},
+			check: func(t *testing.T, m *Metrics) {
+				if got := gaugeValue(t, m.HeadLag); got != 4 {
+					t.Errorf("head lag %v, want 4", got)
+				}
+			},
+		},
+		{
+			name: "submissions",
+			events: []events.Event{
+				&events.TxMined{Header: request, GasUsed: 50000, Fee: big.NewInt(1e15)},
+				&events.TxReverted{Header: request, GasUsed: 30000},
+				&events.TxFailed{Header: request},
+				// The registration transaction is not a submission
+				&events.TxMined{GasUsed: 20000},
+				&events.RequestFinished{Header: request},
+			},
+			check: func(t *testing.T, m *Metrics) {
+				want := Counts{ProcessedRequests: 1, SuccessfulSubmissions: 1, FailedSubmissions: 2}
+				if got := m.Counts(); got != want {
+					t.Errorf("Counts = %+v, want %+v", got, want)
+				}
+				if got := counterValue(m.GasUsed); got != 100000 {
+					t.Errorf("gas used %v, want 100000", got)
+				}
+				if got := counterValue(m.GasFees); got != 0.001 {
+					t.Errorf("fees %v ETH, want 0.001", got)
+				}
+			},
+		},
+		{
+			name: "balances",
+			events: []events.Event{
+				&events.BalanceChecked{Balance: big.NewInt(2e18), DXP: big.NewInt(5e17)},
+			},
+			check: func(t *testing.T, m *Metrics) {
+				if gaugeValue(t, m.Balance) != 2 || gaugeValue(t, m.DXPBalance) != 0.5 {
+					t.Errorf("balances %v ETH and %v DXP, want 2 and 0.5", gaugeValue(t, m.Balance), gaugeValue(t, m.DXPBalance))
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			for _, event := range tt.events {
+				m.Observe(event)
+			}
+			tt.check(t, m)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server serves the metrics over HTTP for Prometheus to scrape
+type Server struct {
+	addr     string
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewServer creates a metrics server listening on addr, e.g. 127.0.0.1:9464
+func NewServer(addr string, m *Metrics) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.Registry(), promhttp.HandlerOpts{}))
+
+	return &Server{
+		addr: addr,
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Start listens on the configured address and serves in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on metrics address: %v", err)
+	}
+	s.listener = listener
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Logger().Error("Metrics server stopped", "err", err)
+		}
+	}()
+
+	logging.Logger().Info("Serving metrics", "url", "http://"+listener.Addr().String()+"/metrics")
+	return nil
+}
+
+// Close stops serving
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,158 @@
+package offline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Formats for unsigned transaction files
+const (
+	// FormatJSON writes the transaction together with its chain ID, sender and description
+	FormatJSON = "json"
+	// FormatRLP writes only the hex-encoded transaction, as used by other signing tools
+	FormatRLP = "rlp"
+)
+
+// UnsignedTx is a transaction prepared on the validator host for signing on a
+// machine that holds a key the host must not have
+type UnsignedTx struct {
+	ChainID     int64              `json:"chainId"`
+	From        common.Address     `json:"from"`
+	Description string             `json:"description"`
+	Tx          *types.Transaction `json:"tx"`
+}
+
+// WriteUnsigned writes an unsigned transaction in the given format
+func WriteUnsigned(path string, format string, unsigned *UnsignedTx) error {
+	var data []byte
+	switch format {
+	case FormatJSON, "":
+		encoded, err := json.MarshalIndent(unsigned, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode unsigned transaction: %v", err)
+		}
+		data = encoded
+	case FormatRLP:
+		encoded, err := unsigned.Tx.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to encode unsigned transaction: %v", err)
+		}
+		data = []byte(hexutil.Encode(encoded))
+	default:
+		return fmt.Errorf("unknown transaction format %q, use %s or %s", format, FormatJSON, FormatRLP)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write unsigned transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ReadUnsigned reads an unsigned transaction in either format. Files in the
+// RLP format carry no sender or description, and legacy transactions in it
+// carry no chain ID.
+func ReadUnsigned(path string) (*UnsignedTx, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unsigned transaction: %v", err)
+	}
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("{")) {
+		var unsigned UnsignedTx
+		if err := json.Unmarshal(data, &unsigned); err != nil {
+			return nil, fmt.Errorf("failed to decode unsigned transaction %s: %v", path, err)
+		}
+		if unsigned.Tx == nil {
+			return nil, fmt.Errorf("unsigned transaction %s has no tx", path)
+		}
+		return &unsigned, nil
+	}
+
+	tx, err := decodeHex(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode unsigned transaction %s: %v", path, err)
+	}
+
+	unsigned := &UnsignedTx{Tx: tx}
+	if tx.Type() != types.LegacyTxType {
+		unsigned.ChainID = tx.ChainId().Int64()
+	}
+
+	return unsigned, nil
+}
+
+// WriteSigned writes a signed transaction as hex-encoded raw bytes, the form
+// accepted by eth_sendRawTransaction
+func WriteSigned(path string, tx *types.Transaction) error {
+	encoded, err := tx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to encode signed transaction: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(hexutil.Encode(encoded)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write signed transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ReadSigned reads a signed transaction as hex-encoded raw bytes or JSON
+func ReadSigned(path string) (*types.Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signed transaction: %v", err)
+	}
+	data = bytes.TrimSpace(data)
+
+	var tx *types.Transaction
+	if bytes.HasPrefix(data, []byte("{")) {
+		var wrapped struct {
+			Tx json.RawMessage `json:"tx"`
+		}
+		if json.Unmarshal(data, &wrapped) == nil && len(wrapped.Tx) > 0 {
+			return nil, fmt.Errorf("%s is an unsigned transaction file, sign it with 'tx sign' first", path)
+		}
+		tx = new(types.Transaction)
+		err = json.Unmarshal(data, tx)
+	} else {
+		tx, err = decodeHex(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signed transaction %s: %v", path, err)
+	}
+
+	if v, r, s := tx.RawSignatureValues(); v.Sign() == 0 && r.Sign() == 0 && s.Sign() == 0 {
+		return nil, fmt.Errorf("transaction in %s is not signed, sign it with 'tx sign' first", path)
+	}
+
+	return tx, nil
+}
+
+// decodeHex decodes a hex-encoded transaction, with or without 0x prefix
+func decodeHex(data []byte) (*types.Transaction, error) {
+	text := string(data)
+	if !strings.HasPrefix(text, "0x") && !strings.HasPrefix(text, "0X") {
+		text = "0x" + text
+	}
+
+	raw, err := hexutil.Decode(text)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,246 @@
+package offline
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+var (
+	testChainID = big.NewInt(31337)
+	testTo      = common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+)
+
+func dynamicFeeTx() *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(3e9),
+		Gas:       100000,
+		To:        &testTo,
+		Value:     big.NewInt(0),
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+func legacyTx() *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    7,
+		GasPrice: big.NewInt(2e9),
+		Gas:      100000,
+		To:       &testTo,
+		Value:    big.NewInt(1),
+		Data:     []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+func TestUnsignedRoundTrip(t *testing.T) {
+	from := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+
+	tests := []struct {
+		name   string
+		format string
+		tx     *types.Transaction
+
+		wantChainID     int64
+		wantFrom        common.Address
+		wantDescription string
+	}{
+		{
+			name:            "json keeps the sender and description",
+			format:          FormatJSON,
+			tx:              dynamicFeeTx(),
+			wantChainID:     31337,
+			wantFrom:        from,
+			wantDescription: "Claim rewards",
+		},
+		{
+			name:            "json is the default",
+			format:          "",
+			tx:              legacyTx(),
+			wantChainID:     31337,
+			wantFrom:        from,
+			wantDescription: "Claim rewards",
+		},
+		{
+			name:        "rlp keeps the chain ID of typed transactions",
+			format:      FormatRLP,
+			tx:          dynamicFeeTx(),
+			wantChainID: 31337,
+		},
+		{
+			name:        "rlp legacy transactions carry no chain ID",
+			format:      FormatRLP,
+			tx:          legacyTx(),
+			wantChainID: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "claim.json")
+			err := WriteUnsigned(path, tt.format, &UnsignedTx{
+				ChainID:     31337,
+				From:        from,
+				Description: "Claim rewards",
+				Tx:          tt.tx,
+			})
+			if err != nil {
+				t.Fatalf("WriteUnsigned: %v", err)
+			}
+
+			unsigned, err := ReadUnsigned(path)
+			if err != nil {
+				t.Fatalf("ReadUnsigned: %v", err)
+			}
+
+			if unsigned.Tx.Hash() != tt.tx.Hash() {
+				t.Errorf("read transaction %s, wrote %s", unsigned.Tx.Hash().Hex(), tt.tx.Hash().Hex())
+			}
+			if unsigned.ChainID != tt.wantChainID {
+				t.Errorf("chain ID %d, want %d", unsigned.ChainID, tt.wantChainID)
+			}
+			if unsigned.From != tt.wantFrom {
+				t.Errorf("from %s, want %s", unsigned.From.Hex(), tt.wantFrom.Hex())
+			}
+			if unsigned.Description != tt.wantDescription {
+				t.Errorf("description %q, want %q", unsigned.Description, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestWriteUnsignedUnknownFormat(t *testing.T) {
+	err := WriteUnsigned(filepath.Join(t.TempDir(), "tx"), "yaml", &UnsignedTx{Tx: legacyTx()})
+	if err == nil || !strings.Contains(err.Error(), `unknown transaction format "yaml"`) {
+		t.Fatalf("got error %v, want an unknown format error", err)
+	}
+}
+
+func TestSignedRoundTrip(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.LatestSignerForChainID(testChainID)
+
+	sign := func(tx *types.Transaction) *types.Transaction {
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	// Each case writes a file and returns the transaction it should read back
+	tests := []struct {
+		name    string
+		write   func(t *testing.T, path string) *types.Transaction
+		wantErr string
+	}{
+		{
+			name: "dynamic fee transaction",
+			write: func(t *testing.T, path string) *types.Transaction {
+				tx := sign(dynamicFeeTx())
+				if err := WriteSigned(path, tx); err != nil {
+					t.Fatal(err)
+				}
+				return tx
+			},
+		},
+		{
+			name: "legacy transaction",
+			write: func(t *testing.T, path string) *types.Transaction {
+				tx := sign(legacyTx())
+				if err := WriteSigned(path, tx); err != nil {
+					t.Fatal(err)
+				}
+				return tx
+			},
+		},
+		{
+			name: "hex without 0x prefix",
+			write: func(t *testing.T, path string) *types.Transaction {
+				tx := sign(dynamicFeeTx())
+				raw, _ := tx.MarshalBinary()
+				writeFile(t, path, strings.TrimPrefix(hexutil.Encode(raw), "0x"))
+				return tx
+			},
+		},
+		{
+			name: "json transaction",
+			write: func(t *testing.T, path string) *types.Transaction {
+				tx := sign(dynamicFeeTx())
+				data, _ := json.Marshal(tx)
+				writeFile(t, path, string(data))
+				return tx
+			},
+		},
+		{
+			name: "unsigned transaction",
+			write: func(t *testing.T, path string) *types.Transaction {
+				raw, _ := dynamicFeeTx().MarshalBinary()
+				writeFile(t, path, hexutil.Encode(raw))
+				return nil
+			},
+			wantErr: "is not signed",
+		},
+		{
+			name: "unsigned transaction file",
+			write: func(t *testing.T, path string) *types.Transaction {
+				if err := WriteUnsigned(path, FormatJSON, &UnsignedTx{ChainID: 31337, Tx: dynamicFeeTx()}); err != nil {
+					t.Fatal(err)
+				}
+				return nil
+			},
+			wantErr: "is an unsigned transaction file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "signed.txt")
+			want := tt.write(t, path)
+
+			tx, err := ReadSigned(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadSigned: %v", err)
+			}
+
+			if tx.Hash() != want.Hash() {
+				t.Errorf("read transaction %s, wrote %s", tx.Hash().Hex(), want.Hash().Hex())
+			}
+			sender, err := types.Sender(signer, tx)
+			if err != nil {
+				t.Fatalf("cannot recover sender: %v", err)
+			}
+			if sender != crypto.PubkeyToAddress(key.PublicKey) {
+				t.Errorf("signed by %s, want %s", sender.Hex(), crypto.PubkeyToAddress(key.PublicKey).Hex())
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -27,8 +27,10 @@ func (g *Generator) GenerateProof(requestID string, result []byte) ([]byte, erro
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
 
-	// Check if we already generated a proof for this request
-	if proof, ok := g.proofs[requestID]; ok {
+	// Check if we already generated a proof for this result of the request;
+	// a request processed again may have reached a different result
+	key := cacheKey(requestID, result)
+	if proof, ok := g.proofs[key]; ok {
 		return proof, nil
 	}
 
@@ -44,11 +46,17 @@ func (g *Generator) GenerateProof(requestID string, result []byte) ([]byte, erro
 	proof := []byte(fmt.Sprintf("proof:%s", hex.EncodeToString(hash2[:])))
 
 	// Store the proof
-	g.proofs[requestID] = proof
+	g.proofs[key] = proof
 
 	return proof, nil
 }
 
+// cacheKey returns the key a proof is cached under
+func cacheKey(requestID string, result []byte) string {
+	hash := sha256.Sum256(result)
+	return requestID + ":" + hex.EncodeToString(hash[:])
+}
+
 // VerifyProof verifies a cryptographic proof against a result
 func (g *Generator) VerifyProof(result []byte, proof []byte) (bool, error) {
 	// For this example, we'll verify the simple proof by recreating it
@@ -0,0 +1,460 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrNoQuorum is returned when not enough endpoints agree on a quorum read
+var ErrNoQuorum = errors.New("RPC endpoints do not agree")
+
+// notFound is the key of a "not found" answer in a quorum read
+const notFound = "not found"
+
+// Client is an Ethereum client backed by a pool of endpoints. It can be used
+// wherever the node used an ethclient.Client, including as a contract backend.
+type Client struct {
+	pool *Pool
+	// quorum is how many endpoints must agree on a trusted read
+	quorum int
+}
+
+var (
+	_ bind.ContractBackend = (*Client)(nil)
+	_ bind.DeployBackend   = (*Client)(nil)
+)
+
+// JSON-RPC error codes
+const (
+	// codeExecution is returned for a reverted call
+	codeExecution = 3
+	// codeServer is the generic code nodes return for execution errors, such
+	// as a revert or a nonce too low, and some providers for rate limits
+	codeServer = -32000
+)
+
+// rateLimited holds fragments of the messages providers send with a
+// codeServer error when they throttle a client
+var rateLimited = []string{"rate limit", "limit exceeded", "too many requests", "capacity"}
+
+// answered reports whether an error is the outcome of executing the call,
+// such as a revert or an unknown transaction, rather than a failure of the
+// endpoint. Answered calls are not retried elsewhere and count as a reply in
+// a quorum; rate limits, exceeded limits and internal errors fail over.
+func answered(err error) bool {
+	if errors.Is(err, ethereum.NotFound) {
+		return true
+	}
+
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.ErrorCode() {
+	case codeExecution:
+		return true
+	case codeServer:
+		message := strings.ToLower(rpcErr.Error())
+		for _, fragment := range rateLimited {
+			if strings.Contains(message, fragment) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// errNoEndpoint returns the error of a call no endpoint could be tried for
+func (c *Client) errNoEndpoint() error {
+	if err := c.pool.err(); err != nil {
+		return err
+	}
+	return errors.New("no RPC endpoint has confirmed it serves the configured chain")
+}
+
+// failover calls fn on the endpoints in order until one answers
+func (c *Client) failover(ctx context.Context, fn func(context.Context, *ethclient.Client) error) error {
+	ranked := c.pool.ranked()
+	if len(ranked) == 0 {
+		return c.errNoEndpoint()
+	}
+
+	var lastErr error
+	for _, e := range ranked {
+		attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+		start := time.Now()
+		err := fn(attemptCtx, e.client)
+		cancel()
+
+		if err == nil || answered(err) {
+			c.pool.succeeded(e, time.Since(start))
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		c.pool.failed(e, err)
+		lastErr = err
+	}
+
+	return fmt.Errorf("all RPC endpoints failed: %v", lastErr)
+}
+
+// read makes a read the node must be able to trust. fn returns the answer of
+// an endpoint and a key that is equal for equal answers. With a quorum, the
+// healthy endpoints are asked in parallel and the answer is only believed if
+// enough of them agree; without one, the read fails over like any call.
+func (c *Client) read(ctx context.Context, fn func(context.Context, *ethclient.Client) (interface{}, string, error)) (interface{}, error) {
+	if c.quorum <= 1 {
+		var value interface{}
+		err := c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+			var err error
+			value, _, err = fn(ctx, client)
+			return err
+		})
+		return value, err
+	}
+
+	// Ask the healthy endpoints, or all of them if too few are healthy
+	ranked := c.pool.ranked()
+	if len(ranked) == 0 {
+		return nil, c.errNoEndpoint()
+	}
+	candidates := make([]*endpoint, 0, len(ranked))
+	c.pool.mutex.Lock()
+	for _, e := range ranked {
+		if e.healthy {
+			candidates = append(candidates, e)
+		}
+	}
+	c.pool.mutex.Unlock()
+	if len(candidates) < c.quorum {
+		candidates = ranked
+	}
+
+	type answer struct {
+		value interface{}
+		key   string
+		err   error
+	}
+	answers := make([]answer, len(candidates))
+
+	var wg sync.WaitGroup
+	for i, e := range candidates {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+
+			attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+			defer cancel()
+
+			start := time.Now()
+			value, key, err := fn(attemptCtx, e.client)
+			switch {
+			case err == nil:
+			case errors.Is(err, ethereum.NotFound):
+				key = notFound
+			case answered(err):
+				key = "error: " + err.Error()
+			default:
+				if ctx.Err() == nil {
+					c.pool.failed(e, err)
+				}
+				answers[i] = answer{err: err}
+				return
+			}
+			c.pool.succeeded(e, time.Since(start))
+			answers[i] = answer{value: value, key: key, err: err}
+		}(i, e)
+	}
+	wg.Wait()
+
+	// Count the votes for each answer
+	votes := make(map[string]int)
+	byKey := make(map[string]answer)
+	var lastErr error
+	for _, a := range answers {
+		if a.key == "" {
+			lastErr = a.err
+			continue
+		}
+		votes[a.key]++
+		byKey[a.key] = a
+	}
+
+	best := ""
+	for key, count := range votes {
+		if best == "" || count > votes[best] {
+			best = key
+		}
+	}
+
+	if len(votes) > 1 {
+		keys := make([]string, len(answers))
+		for i, a := range answers {
+			keys[i] = a.key
+		}
+		logDisagreement(candidates, keys)
+	}
+	if best != "" && votes[best] >= c.quorum {
+		return byKey[best].value, byKey[best].err
+	}
+	if best == "" && lastErr != nil {
+		return nil, fmt.Errorf("all RPC endpoints failed: %v", lastErr)
+	}
+
+	return nil, fmt.Errorf("%w: at most %d of %d agree, %d needed", ErrNoQuorum, votes[best], len(candidates), c.quorum)
+}
+
+// logDisagreement logs the endpoints that gave different answers to a quorum
+// read. An endpoint that has not seen something yet is lagging, not lying,
+// and only logged at debug level.
+func logDisagreement(candidates []*endpoint, keys []string) {
+	var found []string
+	lagging := false
+	distinct := make(map[string]bool)
+	for i, e := range candidates {
+		switch keys[i] {
+		case "":
+			continue
+		case notFound:
+			lagging = true
+		default:
+			distinct[keys[i]] = true
+		}
+		found = append(found, e.name+"="+keys[i])
+	}
+
+	logger := logging.Logger()
+	if len(distinct) > 1 {
+		logger.Warn("RPC endpoints gave conflicting answers", "answers", strings.Join(found, ", "))
+	} else if lagging {
+		logger.Debug("Some RPC endpoints have not caught up", "answers", strings.Join(found, ", "))
+	}
+}
+
+// ChainID returns the chain ID served by the endpoints
+func (c *Client) ChainID(ctx context.Context) (chainID *big.Int, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		chainID, err = client.ChainID(ctx)
+		return err
+	})
+	return chainID, err
+}
+
+// BlockNumber returns the most recent block number
+func (c *Client) BlockNumber(ctx context.Context) (number uint64, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		number, err = client.BlockNumber(ctx)
+		return err
+	})
+	return number, err
+}
+
+// BalanceAt returns the wei balance of an account
+func (c *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		balance, err = client.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+// HeaderByNumber returns a block header; the latest one when number is nil.
+// Headers of a given block are a trusted read, checked by the quorum.
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		// Endpoints see the latest block at slightly different times
+		var header *types.Header
+		err := c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+			var err error
+			header, err = client.HeaderByNumber(ctx, nil)
+			return err
+		})
+		return header, err
+	}
+
+	value, err := c.read(ctx, func(ctx context.Context, client *ethclient.Client) (interface{}, string, error) {
+		header, err := client.HeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, "", err
+		}
+		return header, header.Hash().Hex(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*types.Header), nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction, a trusted
+// read checked by the quorum
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	value, err := c.read(ctx, func(ctx context.Context, client *ethclient.Client) (interface{}, string, error) {
+		receipt, err := client.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return nil, "", err
+		}
+		return receipt, fmt.Sprintf("%s/%d/%d/%d", receipt.BlockHash.Hex(), receipt.Status, receipt.GasUsed, len(receipt.Logs)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*types.Receipt), nil
+}
+
+// TransactionByHash returns a transaction known to the endpoints, and whether
+// it is still pending
+func (c *Client) TransactionByHash(ctx context.Context, txHash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		tx, isPending, err = client.TransactionByHash(ctx, txHash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+// CallContract executes a contract call, a trusted read checked by the quorum
+func (c *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	value, err := c.read(ctx, func(ctx context.Context, client *ethclient.Client) (interface{}, string, error) {
+		result, err := client.CallContract(ctx, msg, blockNumber)
+		if err != nil {
+			return nil, "", err
+		}
+		return result, hexutil.Encode(result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// FilterLogs returns the logs matching a query, a trusted read checked by the quorum
+func (c *Client) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	value, err := c.read(ctx, func(ctx context.Context, client *ethclient.Client) (interface{}, string, error) {
+		logs, err := client.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, "", err
+		}
+		keys := make([]string, 0, len(logs))
+		for _, log := range logs {
+			keys = append(keys, fmt.Sprintf("%s:%d:%s", log.BlockHash.Hex(), log.Index, hexutil.Encode(log.Data)))
+		}
+		return logs, strings.Join(keys, ","), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]types.Log), nil
+}
+
+// SubscribeFilterLogs subscribes to the logs matching a query
+func (c *Client) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (sub ethereum.Subscription, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		sub, err = client.SubscribeFilterLogs(ctx, query, ch)
+		return err
+	})
+	return sub, err
+}
+
+// CodeAt returns the contract code of an account
+func (c *Client) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		code, err = client.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+// PendingCodeAt returns the contract code of an account in the pending state
+func (c *Client) PendingCodeAt(ctx context.Context, account common.Address) (code []byte, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		code, err = client.PendingCodeAt(ctx, account)
+		return err
+	})
+	return code, err
+}
+
+// PendingNonceAt returns the account nonce in the pending state
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		nonce, err = client.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+// SuggestGasPrice returns the suggested legacy gas price
+func (c *Client) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		price, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+// SuggestGasTipCap returns the suggested priority fee
+func (c *Client) SuggestGasTipCap(ctx context.Context) (tip *big.Int, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		tip, err = client.SuggestGasTipCap(ctx)
+		return err
+	})
+	return tip, err
+}
+
+// EstimateGas estimates the gas a call needs
+func (c *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (gas uint64, err error) {
+	err = c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		gas, err = client.EstimateGas(ctx, msg)
+		return err
+	})
+	return gas, err
+}
+
+// alreadySent holds fragments of the errors nodes return for a transaction
+// they already have, or whose nonce was already used
+var alreadySent = []string{"already known", "known transaction", "nonce too low"}
+
+// SendTransaction broadcasts a signed transaction. Sending it again through
+// another endpoint after a failure is safe, as it keeps its hash, but the
+// failed endpoint may have passed it on before failing: when the next one
+// answers that it already has the transaction or its nonce, it was sent.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	attempts := 0
+	return c.failover(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		attempts++
+		err := client.SendTransaction(ctx, tx)
+		if err != nil && attempts > 1 && isAlreadySent(err) {
+			logging.Logger().Info("Transaction was already sent through a failed endpoint", logging.KeyTxHash, tx.Hash().Hex(), "answer", err.Error())
+			return nil
+		}
+		return err
+	})
+}
+
+// isAlreadySent reports whether a node refused a transaction because it
+// already has it or its nonce was used
+func isAlreadySent(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, fragment := range alreadySent {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
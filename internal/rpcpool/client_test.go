@@ -0,0 +1,313 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var testMsg = ethereum.CallMsg{To: &common.Address{1}}
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary callAnswer
+		// primaryDown takes the primary endpoint down
+		primaryDown bool
+		secondary   callAnswer
+
+		want          string
+		wantErr       string
+		wantSecondary int
+	}{
+		{
+			name:    "primary answers",
+			primary: callAnswer{result: "0x01"},
+			want:    "0x01",
+		},
+		{
+			name:          "unreachable primary fails over",
+			primaryDown:   true,
+			secondary:     callAnswer{result: "0x02"},
+			want:          "0x02",
+			wantSecondary: 1,
+		},
+		{
+			name:          "rate limited primary fails over",
+			primary:       callAnswer{code: -32000, message: "rate limit exceeded"},
+			secondary:     callAnswer{result: "0x02"},
+			want:          "0x02",
+			wantSecondary: 1,
+		},
+		{
+			name:          "primary over its capacity fails over",
+			primary:       callAnswer{code: -32005, message: "daily request limit exceeded"},
+			secondary:     callAnswer{result: "0x02"},
+			want:          "0x02",
+			wantSecondary: 1,
+		},
+		{
+			name:          "internal error fails over",
+			primary:       callAnswer{code: -32603, message: "internal error"},
+			secondary:     callAnswer{result: "0x02"},
+			want:          "0x02",
+			wantSecondary: 1,
+		},
+		{
+			name:      "revert is an answer",
+			primary:   callAnswer{code: 3, message: "execution reverted: not registered"},
+			secondary: callAnswer{result: "0x02"},
+			wantErr:   "execution reverted: not registered",
+		},
+		{
+			name:      "execution error is an answer",
+			primary:   callAnswer{code: -32000, message: "insufficient funds for gas * price + value"},
+			secondary: callAnswer{result: "0x02"},
+			wantErr:   "insufficient funds",
+		},
+		{
+			name:          "all endpoints fail",
+			primaryDown:   true,
+			secondary:     callAnswer{code: -32000, message: "too many requests"},
+			wantErr:       "all RPC endpoints failed: too many requests",
+			wantSecondary: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, secondary := newFakeNode(t), newFakeNode(t)
+			p := dialPool(t, 1, primary, secondary)
+
+			primary.set(func(n *fakeNode) {
+				n.call = tt.primary
+				n.down = tt.primaryDown
+			})
+			secondary.set(func(n *fakeNode) { n.call = tt.secondary })
+
+			result, err := p.Client().CallContract(context.Background(), testMsg, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if hexutil.Encode(result) != tt.want {
+				t.Errorf("result %s, want %s", hexutil.Encode(result), tt.want)
+			}
+
+			if got := secondary.count("eth_call"); got != tt.wantSecondary {
+				t.Errorf("secondary was called %d times, want %d", got, tt.wantSecondary)
+			}
+			if failedOver := tt.wantSecondary > 0; healthy(p)[0] == failedOver {
+				t.Errorf("primary healthy %v after the call", healthy(p)[0])
+			}
+		})
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	const quorum = 2
+
+	tests := []struct {
+		name string
+		// answers holds how each of the three endpoints answers, and down
+		// which are unreachable
+		answers [3]callAnswer
+		down    [3]bool
+
+		want    string
+		wantErr string
+		// wantNoQuorum expects ErrNoQuorum
+		wantNoQuorum bool
+	}{
+		{
+			name:    "all endpoints agree",
+			answers: [3]callAnswer{{result: "0x01"}, {result: "0x01"}, {result: "0x01"}},
+			want:    "0x01",
+		},
+		{
+			name:    "the majority outvotes a lying endpoint",
+			answers: [3]callAnswer{{result: "0x02"}, {result: "0x01"}, {result: "0x01"}},
+			want:    "0x01",
+		},
+		{
+			name:    "an unreachable endpoint leaves enough to agree",
+			answers: [3]callAnswer{{result: "0x01"}, {result: "0x01"}, {result: "0x01"}},
+			down:    [3]bool{true, false, false},
+			want:    "0x01",
+		},
+		{
+			name:    "a rate limited endpoint does not vote",
+			answers: [3]callAnswer{{code: -32000, message: "rate limit exceeded"}, {result: "0x01"}, {result: "0x01"}},
+			want:    "0x01",
+		},
+		{
+			name:    "an agreed revert is returned",
+			answers: [3]callAnswer{{code: 3, message: "execution reverted"}, {code: 3, message: "execution reverted"}, {result: "0x01"}},
+			wantErr: "execution reverted",
+		},
+		{
+			name:         "endpoints that all disagree",
+			answers:      [3]callAnswer{{result: "0x01"}, {result: "0x02"}, {result: "0x03"}},
+			wantNoQuorum: true,
+		},
+		{
+			name:         "too few endpoints answer",
+			answers:      [3]callAnswer{{result: "0x01"}, {result: "0x01"}, {result: "0x01"}},
+			down:         [3]bool{true, true, false},
+			wantNoQuorum: true,
+		},
+		{
+			name:    "no endpoint answers",
+			answers: [3]callAnswer{{result: "0x01"}, {result: "0x01"}, {result: "0x01"}},
+			down:    [3]bool{true, true, true},
+			wantErr: "all RPC endpoints failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []*fakeNode{newFakeNode(t), newFakeNode(t), newFakeNode(t)}
+			p := dialPool(t, quorum, nodes...)
+			for i, node := range nodes {
+				node.set(func(n *fakeNode) {
+					n.call = tt.answers[i]
+					n.down = tt.down[i]
+				})
+			}
+
+			result, err := p.QuorumClient().CallContract(context.Background(), testMsg, nil)
+			switch {
+			case tt.wantNoQuorum:
+				if !errors.Is(err, ErrNoQuorum) {
+					t.Fatalf("got error %v, want ErrNoQuorum", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || errors.Is(err, ErrNoQuorum) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case hexutil.Encode(result) != tt.want:
+				t.Errorf("result %s, want %s", hexutil.Encode(result), tt.want)
+			}
+		})
+	}
+}
+
+func TestSendTransaction(t *testing.T) {
+	internal := callAnswer{code: -32603, message: "internal error"}
+
+	tests := []struct {
+		name      string
+		primary   callAnswer
+		secondary callAnswer
+
+		wantErr       string
+		wantSecondary int
+	}{
+		{
+			name: "primary accepts",
+		},
+		{
+			name:          "failed primary fails over",
+			primary:       internal,
+			wantSecondary: 1,
+		},
+		{
+			name:          "already known after a failover",
+			primary:       internal,
+			secondary:     callAnswer{code: -32000, message: "already known"},
+			wantSecondary: 1,
+		},
+		{
+			name:          "nonce too low after a failover",
+			primary:       internal,
+			secondary:     callAnswer{code: -32000, message: "nonce too low: next nonce 8, tx nonce 7"},
+			wantSecondary: 1,
+		},
+		{
+			name:    "nonce too low on the first attempt",
+			primary: callAnswer{code: -32000, message: "nonce too low: next nonce 8, tx nonce 7"},
+			wantErr: "nonce too low",
+		},
+		{
+			name:    "rejected",
+			primary: callAnswer{code: -32000, message: "insufficient funds for gas * price + value"},
+			wantErr: "insufficient funds",
+		},
+		{
+			name:          "rejected after a failover",
+			primary:       internal,
+			secondary:     callAnswer{code: -32000, message: "insufficient funds for gas * price + value"},
+			wantErr:       "insufficient funds",
+			wantSecondary: 1,
+		},
+	}
+
+	tx := types.NewTx(&types.LegacyTx{Nonce: 7, Gas: 21000, GasPrice: common.Big1, To: &common.Address{1}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, secondary := newFakeNode(t), newFakeNode(t)
+			p := dialPool(t, 1, primary, secondary)
+			if tt.primary.code != 0 {
+				primary.set(func(n *fakeNode) { n.send = tt.primary })
+			}
+			if tt.secondary.code != 0 {
+				secondary.set(func(n *fakeNode) { n.send = tt.secondary })
+			}
+
+			err := p.Client().SendTransaction(context.Background(), tx)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+			}
+			if got := secondary.count("eth_sendRawTransaction"); got != tt.wantSecondary {
+				t.Errorf("secondary was sent the transaction %d times, want %d", got, tt.wantSecondary)
+			}
+		})
+	}
+}
+
+func TestAnswered(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not found", ethereum.NotFound, true},
+		{"revert", rpcError{3, "execution reverted"}, true},
+		{"execution error", rpcError{-32000, "nonce too low"}, true},
+		{"rate limit", rpcError{-32000, "Rate limit reached"}, false},
+		{"exceeded limit", rpcError{-32005, "limit exceeded"}, false},
+		{"over capacity", rpcError{-32000, "Service is over capacity"}, false},
+		{"internal error", rpcError{-32603, "internal error"}, false},
+		{"transport error", errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := answered(tt.err); got != tt.want {
+				t.Errorf("answered(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// rpcError is a JSON-RPC error as returned by the rpc package
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string  { return e.message }
+func (e rpcError) ErrorCode() int { return e.code }
@@ -0,0 +1,360 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// attemptTimeout bounds a single call to one endpoint, so a hanging endpoint
+// leaves time to fail over
+const attemptTimeout = 10 * time.Second
+
+// Pool is a set of RPC endpoints serving the same chain. Calls go to the
+// healthy endpoint with the lowest latency and fail over to the next one when
+// an endpoint cannot be reached. Endpoints are checked periodically, and one
+// that errors or falls behind the others is avoided until it recovers. No
+// call goes to an endpoint before it confirmed it serves the configured chain.
+type Pool struct {
+	cfg       *config.Config
+	endpoints []*endpoint
+	quorum    int
+	maxLag    uint64
+
+	mutex sync.Mutex
+	// closedErr is set once the pool is closed, failing all further calls
+	closedErr error
+}
+
+// endpoint is one RPC endpoint of a pool. Its health is guarded by the pool's mutex.
+type endpoint struct {
+	// name is the endpoint URL with its credentials redacted, for logs
+	name   string
+	client *ethclient.Client
+
+	// verified is set once the endpoint confirmed it serves the configured chain
+	verified bool
+	healthy  bool
+	latency  time.Duration
+	head     uint64
+	lastErr  string
+}
+
+// EndpointStatus is the health of an endpoint, as last checked
+type EndpointStatus struct {
+	URL       string `json:"url"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMs int64  `json:"latencyMs"`
+	Head      uint64 `json:"head"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Dial connects to the RPC endpoints of cfg and checks they all serve its
+// chain. Endpoints that cannot be reached yet are kept, as unhealthy; an
+// error is returned if none can be reached or one serves another chain.
+func Dial(ctx context.Context, cfg *config.Config) (*Pool, error) {
+	p := &Pool{
+		cfg:    cfg,
+		quorum: int(cfg.RPCQuorum),
+		maxLag: cfg.RPCMaxBlockLag,
+	}
+
+	for _, rpcURL := range cfg.RPCURLs() {
+		name := config.RedactURL(rpcURL)
+		client, err := ethclient.DialContext(ctx, rpcURL)
+		if err != nil {
+			logging.Logger().Warn("Failed to connect to RPC endpoint", "endpoint", name, "err", err)
+			continue
+		}
+		p.endpoints = append(p.endpoints, &endpoint{name: name, client: client})
+	}
+	if len(p.endpoints) == 0 {
+		return nil, errors.New("failed to connect to the Ethereum client: no RPC endpoint could be reached")
+	}
+
+	// Refuse to run against an endpoint serving a different chain. Endpoints
+	// that cannot tell yet are verified by the health checks before use.
+	verified := 0
+	var lastErr error
+	for _, e := range p.endpoints {
+		err := p.verify(ctx, e)
+		switch {
+		case isMismatch(err):
+			p.Close()
+			return nil, err
+		case err != nil:
+			logging.Logger().Warn("RPC endpoint is unreachable", "endpoint", e.name, "err", err)
+			lastErr = err
+		default:
+			verified++
+		}
+	}
+	if verified == 0 {
+		p.Close()
+		return nil, lastErr
+	}
+
+	p.check(ctx)
+	return p, nil
+}
+
+// Monitor checks the health of the endpoints every interval until ctx is cancelled
+func (p *Pool) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.check(ctx)
+		}
+	}
+}
+
+// Close closes the connections to all endpoints
+func (p *Pool) Close() {
+	p.close(errors.New("RPC pool is closed"))
+}
+
+// close closes the pool, failing all further calls with err
+func (p *Pool) close(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closedErr != nil {
+		return
+	}
+	p.closedErr = err
+	for _, e := range p.endpoints {
+		e.healthy = false
+		e.client.Close()
+	}
+}
+
+// verify checks that an endpoint serves the configured chain, and marks it
+// verified if it does
+func (p *Pool) verify(ctx context.Context, e *endpoint) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	defer cancel()
+
+	if err := p.cfg.VerifyChainID(attemptCtx, e.client); err != nil {
+		if isMismatch(err) {
+			return fmt.Errorf("%s: %w", e.name, err)
+		}
+		return err
+	}
+
+	p.mutex.Lock()
+	e.verified = true
+	p.mutex.Unlock()
+	return nil
+}
+
+// isMismatch reports whether err is an endpoint serving another chain
+func isMismatch(err error) bool {
+	var mismatch *config.ChainIDMismatchError
+	return errors.As(err, &mismatch)
+}
+
+// Client returns a client sending each call to the best endpoint, failing over
+func (p *Pool) Client() *Client {
+	return &Client{pool: p, quorum: 1}
+}
+
+// QuorumClient returns a client that, for reads the node must be able to
+// trust, only believes an answer given by RPC_QUORUM endpoints
+func (p *Pool) QuorumClient() *Client {
+	return &Client{pool: p, quorum: p.quorum}
+}
+
+// Status returns the health of each endpoint, in the configured order
+func (p *Pool) Status() []EndpointStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	status := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		status = append(status, EndpointStatus{
+			URL:       e.name,
+			Healthy:   e.healthy,
+			LatencyMs: e.latency.Milliseconds(),
+			Head:      e.head,
+			Error:     e.lastErr,
+		})
+	}
+
+	return status
+}
+
+// check reads the head block of every endpoint, timing the call, and marks
+// the endpoints that failed or lag the highest head as unhealthy. Endpoints
+// whose chain ID was not verified yet are verified first; if one turns out to
+// serve another chain, the pool is closed so nothing more is sent through it.
+func (p *Pool) check(ctx context.Context) {
+	if p.err() != nil {
+		return
+	}
+
+	type result struct {
+		head    uint64
+		latency time.Duration
+		err     error
+	}
+	results := make([]result, len(p.endpoints))
+
+	var wg sync.WaitGroup
+	for i, e := range p.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+
+			if !p.isVerified(e) {
+				if err := p.verify(ctx, e); err != nil {
+					results[i] = result{err: err}
+					return
+				}
+				logging.Logger().Info("RPC endpoint serves the configured chain", "endpoint", e.name)
+			}
+
+			attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+			defer cancel()
+
+			start := time.Now()
+			head, err := e.client.BlockNumber(attemptCtx)
+			results[i] = result{head: head, latency: time.Since(start), err: err}
+		}(i, e)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	for _, r := range results {
+		if isMismatch(r.err) {
+			logging.Logger().Error("RPC endpoint serves a different chain, refusing to use any endpoint", "err", r.err)
+			p.close(r.err)
+			return
+		}
+	}
+
+	var best uint64
+	for _, r := range results {
+		if r.err == nil && r.head > best {
+			best = r.head
+		}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i, e := range p.endpoints {
+		r := results[i]
+		healthy := r.err == nil && e.verified && r.head+p.maxLag >= best
+
+		switch {
+		case r.err != nil:
+			e.lastErr = r.err.Error()
+		case !healthy:
+			e.lastErr = fmt.Sprintf("head %d is %d blocks behind %d", r.head, best-r.head, best)
+		default:
+			e.lastErr = ""
+		}
+		if r.err == nil {
+			e.head = r.head
+			e.observe(r.latency)
+		}
+
+		if healthy != e.healthy {
+			if healthy {
+				logging.Logger().Info("RPC endpoint is healthy", "endpoint", e.name, "head", e.head, "latency", e.latency)
+			} else {
+				logging.Logger().Warn("RPC endpoint is unhealthy", "endpoint", e.name, "reason", e.lastErr)
+			}
+		}
+		e.healthy = healthy
+	}
+}
+
+// observe folds the latency of a call into the endpoint's average. Must be
+// called with the pool's mutex held.
+func (e *endpoint) observe(latency time.Duration) {
+	if e.latency == 0 {
+		e.latency = latency
+		return
+	}
+	e.latency = (3*e.latency + latency) / 4
+}
+
+// ranked returns the endpoints in the order to try them: healthy ones first,
+// then by latency, keeping the configured order on ties. Endpoints whose
+// chain ID was not verified are left out, and none is returned once the pool
+// is closed.
+func (p *Pool) ranked() []*endpoint {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closedErr != nil {
+		return nil
+	}
+	ranked := make([]*endpoint, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		if e.verified {
+			ranked = append(ranked, e)
+		}
+	}
+	sort.SliceStable(ranked, func(i, k int) bool {
+		if ranked[i].healthy != ranked[k].healthy {
+			return ranked[i].healthy
+		}
+		return ranked[i].latency < ranked[k].latency
+	})
+
+	return ranked
+}
+
+// succeeded records a call an endpoint answered
+func (p *Pool) succeeded(e *endpoint, latency time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	e.observe(latency)
+}
+
+// failed marks an endpoint that could not be reached as unhealthy until its
+// next successful check
+func (p *Pool) failed(e *endpoint, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if e.healthy {
+		logging.Logger().Warn("RPC endpoint failed, failing over", "endpoint", e.name, "err", err)
+	}
+	e.healthy = false
+	e.lastErr = err.Error()
+}
+
+// isVerified reports whether an endpoint confirmed it serves the configured chain
+func (p *Pool) isVerified(e *endpoint) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return e.verified
+}
+
+// err returns why the pool was closed, or nil while it is open
+func (p *Pool) err() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.closedErr
+}
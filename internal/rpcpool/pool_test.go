@@ -0,0 +1,276 @@
+package rpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+)
+
+const testChainID = 31337
+
+// callAnswer is how a fake node answers eth_call: with a result, or with a
+// JSON-RPC error when code is set
+type callAnswer struct {
+	result  string
+	code    int
+	message string
+}
+
+// fakeNode is a JSON-RPC endpoint serving the few methods the pool uses
+type fakeNode struct {
+	*httptest.Server
+
+	mutex   sync.Mutex
+	chainID int64
+	head    uint64
+	// down makes the node answer every request with 503
+	down bool
+	call callAnswer
+	// send is how the node answers eth_sendRawTransaction
+	send  callAnswer
+	calls map[string]int
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	n := &fakeNode{
+		chainID: testChainID,
+		head:    100,
+		call:    callAnswer{result: "0x01"},
+		send:    callAnswer{result: "0x7a"},
+		calls:   make(map[string]int),
+	}
+	n.Server = httptest.NewServer(http.HandlerFunc(n.serve))
+	t.Cleanup(n.Close)
+
+	return n
+}
+
+func (n *fakeNode) serve(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.down {
+		http.Error(w, "node is down", http.StatusServiceUnavailable)
+		return
+	}
+	n.calls[req.Method]++
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_chainId":
+		resp["result"] = fmt.Sprintf("0x%x", n.chainID)
+	case "eth_blockNumber":
+		resp["result"] = fmt.Sprintf("0x%x", n.head)
+	case "eth_call", "eth_sendRawTransaction":
+		answer := n.call
+		if req.Method == "eth_sendRawTransaction" {
+			answer = n.send
+		}
+		if answer.code != 0 {
+			resp["error"] = map[string]interface{}{"code": answer.code, "message": answer.message}
+		} else {
+			resp["result"] = answer.result
+		}
+	default:
+		resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// set changes how the node behaves
+func (n *fakeNode) set(fn func(n *fakeNode)) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	fn(n)
+}
+
+// count returns how many times a method was called
+func (n *fakeNode) count(method string) int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.calls[method]
+}
+
+// testConfig returns a configuration using the nodes as endpoints, the first
+// one as BASE_RPC_URL
+func testConfig(quorum int, nodes ...*fakeNode) *config.Config {
+	urls := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		urls = append(urls, n.URL)
+	}
+
+	return &config.Config{
+		Network:         "dev",
+		ChainID:         testChainID,
+		BaseRPCURL:      urls[0],
+		FallbackRPCURLs: strings.Join(urls[1:], ","),
+		RPCQuorum:       int64(quorum),
+		RPCMaxBlockLag:  5,
+	}
+}
+
+// dialPool dials a pool over the nodes and ranks the endpoints in the order given
+func dialPool(t *testing.T, quorum int, nodes ...*fakeNode) *Pool {
+	t.Helper()
+
+	p, err := Dial(context.Background(), testConfig(quorum, nodes...))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(p.Close)
+
+	p.mutex.Lock()
+	for i, e := range p.endpoints {
+		e.latency = time.Duration(i+1) * time.Millisecond
+	}
+	p.mutex.Unlock()
+
+	return p
+}
+
+// healthy returns the health of each endpoint
+func healthy(p *Pool) []bool {
+	var health []bool
+	for _, status := range p.Status() {
+		health = append(health, status.Healthy)
+	}
+	return health
+}
+
+func TestDial(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the three nodes the pool is dialled over
+		setup func(nodes []*fakeNode)
+
+		wantErr     string
+		wantHealthy []bool
+	}{
+		{
+			name:        "all endpoints serve the chain",
+			setup:       func(nodes []*fakeNode) {},
+			wantHealthy: []bool{true, true, true},
+		},
+		{
+			name: "an unreachable endpoint is kept as unhealthy",
+			setup: func(nodes []*fakeNode) {
+				nodes[1].set(func(n *fakeNode) { n.down = true })
+			},
+			wantHealthy: []bool{true, false, true},
+		},
+		{
+			name: "a lagging endpoint is unhealthy",
+			setup: func(nodes []*fakeNode) {
+				nodes[2].set(func(n *fakeNode) { n.head = 90 })
+			},
+			wantHealthy: []bool{true, true, false},
+		},
+		{
+			name: "an endpoint serving another chain is refused",
+			setup: func(nodes []*fakeNode) {
+				nodes[2].set(func(n *fakeNode) { n.chainID = 1 })
+			},
+			wantErr: "serves chain ID 1 but network dev expects 31337",
+		},
+		{
+			name: "no endpoint can be reached",
+			setup: func(nodes []*fakeNode) {
+				for _, node := range nodes {
+					node.set(func(n *fakeNode) { n.down = true })
+				}
+			},
+			wantErr: "failed to get chain ID from RPC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []*fakeNode{newFakeNode(t), newFakeNode(t), newFakeNode(t)}
+			tt.setup(nodes)
+
+			p, err := Dial(context.Background(), testConfig(1, nodes...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			defer p.Close()
+
+			if got := healthy(p); fmt.Sprint(got) != fmt.Sprint(tt.wantHealthy) {
+				t.Errorf("healthy %v, want %v", got, tt.wantHealthy)
+			}
+		})
+	}
+}
+
+func TestUnverifiedEndpointIsNotUsed(t *testing.T) {
+	primary, late := newFakeNode(t), newFakeNode(t)
+	late.set(func(n *fakeNode) { n.down = true })
+	p := dialPool(t, 1, primary, late)
+	client := p.Client()
+
+	// The late endpoint comes up, but is not used before a check verifies it
+	late.set(func(n *fakeNode) { n.down = false })
+	primary.set(func(n *fakeNode) { n.down = true })
+	if _, err := client.BlockNumber(context.Background()); err == nil {
+		t.Fatal("call succeeded through an endpoint that was not verified")
+	}
+	if got := late.count("eth_blockNumber"); got != 0 {
+		t.Fatalf("unverified endpoint was called %d times", got)
+	}
+
+	p.check(context.Background())
+	head, err := client.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("call failed after the endpoint was verified: %v", err)
+	}
+	if head != 100 {
+		t.Errorf("head %d, want 100", head)
+	}
+}
+
+func TestCheckClosesPoolOnChainMismatch(t *testing.T) {
+	primary, late := newFakeNode(t), newFakeNode(t)
+	late.set(func(n *fakeNode) { n.down = true })
+	p := dialPool(t, 1, primary, late)
+
+	// The late endpoint turns out to serve another chain
+	late.set(func(n *fakeNode) {
+		n.down = false
+		n.chainID = 1
+	})
+	p.check(context.Background())
+
+	_, err := p.Client().BlockNumber(context.Background())
+	var mismatch *config.ChainIDMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("got error %v, want the chain ID mismatch", err)
+	}
+	if got := healthy(p); fmt.Sprint(got) != "[false false]" {
+		t.Errorf("healthy %v after the pool was closed", got)
+	}
+}
@@ -0,0 +1,192 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Supported external signer APIs
+const (
+	// APIClef uses account_signTransaction as served by Clef
+	APIClef = "clef"
+	// APIWeb3Signer uses eth_signTransaction as served by Web3Signer
+	APIWeb3Signer = "web3signer"
+)
+
+// RemoteSigner signs through an external JSON-RPC signer, so the key never
+// enters the validator process
+type RemoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+	api     string
+}
+
+// accountsTimeout bounds listing the external signer's accounts, which Clef
+// may ask its operator to approve
+const accountsTimeout = time.Minute
+
+// signTxArgs is the transaction object sent to the external signer
+type signTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big     `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId,omitempty"`
+}
+
+// clefSignResult is the response of Clef's account_signTransaction
+type clefSignResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// NewRemoteSigner connects to an external signer that holds the key for address
+func NewRemoteSigner(url string, address common.Address, api string) (*RemoteSigner, error) {
+	if api == "" {
+		api = APIClef
+	}
+	if api != APIClef && api != APIWeb3Signer {
+		return nil, fmt.Errorf("unsupported signer API %q, use %s or %s", api, APIClef, APIWeb3Signer)
+	}
+
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to external signer: %v", err)
+	}
+
+	r := &RemoteSigner{
+		client:  client,
+		address: address,
+		api:     api,
+	}
+
+	// Catch a wrong SIGNER_ADDRESS now rather than at the first signature
+	ctx, cancel := context.WithTimeout(context.Background(), accountsTimeout)
+	defer cancel()
+	if err := r.checkAccount(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// checkAccount checks that the external signer holds the key for the signer's address
+func (r *RemoteSigner) checkAccount(ctx context.Context) error {
+	method := "account_list"
+	if r.api == APIWeb3Signer {
+		method = "eth_accounts"
+	}
+
+	var accounts []common.Address
+	if err := r.client.CallContext(ctx, &accounts, method); err != nil {
+		return fmt.Errorf("failed to list the external signer's accounts: %v", err)
+	}
+
+	for _, account := range accounts {
+		if account == r.address {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("external signer does not hold the key for %s (it has %d account(s))", r.address.Hex(), len(accounts))
+}
+
+// Address returns the account the external signer signs for
+func (r *RemoteSigner) Address() common.Address {
+	return r.address
+}
+
+// SignTx asks the external signer to sign the transaction and checks that the
+// signed transaction is the one that was requested
+func (r *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if chainID == nil {
+		return nil, errors.New("chain ID is required for signing")
+	}
+
+	args := signTxArgs{
+		From:    r.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	var raw hexutil.Bytes
+	switch r.api {
+	case APIClef:
+		var result clefSignResult
+		if err := r.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+			return nil, fmt.Errorf("external signer refused to sign: %v", err)
+		}
+		raw = result.Raw
+	case APIWeb3Signer:
+		if err := r.client.CallContext(ctx, &raw, "eth_signTransaction", args); err != nil {
+			return nil, fmt.Errorf("external signer refused to sign: %v", err)
+		}
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("external signer returned an invalid transaction: %v", err)
+	}
+
+	if err := checkSigned(tx, signed, r.address, chainID); err != nil {
+		return nil, fmt.Errorf("external signer returned a different transaction: %v", err)
+	}
+
+	return signed, nil
+}
+
+// Close disconnects from the external signer
+func (r *RemoteSigner) Close() {
+	r.client.Close()
+}
+
+// checkSigned verifies that a transaction signed elsewhere matches the request
+// and was signed by the expected account for the expected chain
+func checkSigned(requested, signed *types.Transaction, from common.Address, chainID *big.Int) error {
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return fmt.Errorf("cannot recover sender: %v", err)
+	}
+
+	switch {
+	case sender != from:
+		return fmt.Errorf("signed by %s instead of %s", sender.Hex(), from.Hex())
+	case signed.Nonce() != requested.Nonce():
+		return fmt.Errorf("nonce %d instead of %d", signed.Nonce(), requested.Nonce())
+	case signed.Gas() != requested.Gas():
+		return fmt.Errorf("gas %d instead of %d", signed.Gas(), requested.Gas())
+	case signed.Value().Cmp(requested.Value()) != 0:
+		return fmt.Errorf("value %s instead of %s", signed.Value(), requested.Value())
+	case signed.GasFeeCap().Cmp(requested.GasFeeCap()) != 0 || signed.GasTipCap().Cmp(requested.GasTipCap()) != 0:
+		return errors.New("fees differ from the request")
+	case string(signed.Data()) != string(requested.Data()):
+		return errors.New("data differs from the request")
+	case (signed.To() == nil) != (requested.To() == nil) || (signed.To() != nil && *signed.To() != *requested.To()):
+		return errors.New("recipient differs from the request")
+	}
+
+	return nil
+}
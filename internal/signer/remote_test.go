@@ -0,0 +1,212 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const testKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// fakeSigner serves the Clef and Web3Signer methods for one key. tamper, if
+// set, changes the transaction before it is signed.
+type fakeSigner struct {
+	key    *ecdsa.PrivateKey
+	tamper func(tx *types.DynamicFeeTx)
+}
+
+func (f *fakeSigner) address() common.Address {
+	return crypto.PubkeyToAddress(f.key.PublicKey)
+}
+
+func (f *fakeSigner) sign(args signTxArgs) (hexutil.Bytes, error) {
+	txData := &types.DynamicFeeTx{
+		ChainID:   args.ChainID.ToInt(),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		Gas:       uint64(args.Gas),
+		To:        args.To,
+		Value:     args.Value.ToInt(),
+		Data:      args.Data,
+	}
+	if f.tamper != nil {
+		f.tamper(txData)
+	}
+
+	tx, err := types.SignNewTx(f.key, types.LatestSignerForChainID(txData.ChainID), txData)
+	if err != nil {
+		return nil, err
+	}
+	return tx.MarshalBinary()
+}
+
+type clefService struct{ f *fakeSigner }
+
+func (s *clefService) List() []common.Address { return []common.Address{s.f.address()} }
+
+func (s *clefService) SignTransaction(args signTxArgs) (*clefSignResult, error) {
+	raw, err := s.f.sign(args)
+	if err != nil {
+		return nil, err
+	}
+	return &clefSignResult{Raw: raw}, nil
+}
+
+type web3SignerService struct{ f *fakeSigner }
+
+func (s *web3SignerService) Accounts() []common.Address { return []common.Address{s.f.address()} }
+
+func (s *web3SignerService) SignTransaction(args signTxArgs) (hexutil.Bytes, error) {
+	return s.f.sign(args)
+}
+
+// serve starts an external signer serving both APIs and returns its URL
+func serve(t *testing.T, f *fakeSigner) string {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &clefService{f: f}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("eth", &web3SignerService{f: f}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+
+	return httpServer.URL
+}
+
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(31337),
+		Nonce:     7,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(3e9),
+		Gas:       100000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	operator := crypto.PubkeyToAddress(key.PublicKey)
+
+	tests := []struct {
+		name    string
+		api     string
+		address common.Address
+		tamper  func(tx *types.DynamicFeeTx)
+
+		wantDialErr string
+		wantSignErr string
+	}{
+		{
+			name:    "clef",
+			api:     APIClef,
+			address: operator,
+		},
+		{
+			name:    "web3signer",
+			api:     APIWeb3Signer,
+			address: operator,
+		},
+		{
+			name:        "clef without the account",
+			api:         APIClef,
+			address:     common.HexToAddress("0xdead"),
+			wantDialErr: "external signer does not hold the key for 0x000000000000000000000000000000000000dEaD",
+		},
+		{
+			name:        "web3signer without the account",
+			api:         APIWeb3Signer,
+			address:     common.HexToAddress("0xdead"),
+			wantDialErr: "external signer does not hold the key for",
+		},
+		{
+			name:        "unsupported API",
+			api:         "vault",
+			address:     operator,
+			wantDialErr: `unsupported signer API "vault"`,
+		},
+		{
+			name:        "signer changes the recipient",
+			api:         APIClef,
+			address:     operator,
+			tamper:      func(tx *types.DynamicFeeTx) { tx.To = &common.Address{} },
+			wantSignErr: "recipient differs from the request",
+		},
+		{
+			name:        "signer changes the nonce",
+			api:         APIWeb3Signer,
+			address:     operator,
+			tamper:      func(tx *types.DynamicFeeTx) { tx.Nonce++ },
+			wantSignErr: "nonce 8 instead of 7",
+		},
+		{
+			name:        "signer raises the fee",
+			api:         APIClef,
+			address:     operator,
+			tamper:      func(tx *types.DynamicFeeTx) { tx.GasFeeCap = big.NewInt(30e9) },
+			wantSignErr: "fees differ from the request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := serve(t, &fakeSigner{key: key, tamper: tt.tamper})
+
+			remote, err := NewRemoteSigner(url, tt.address, tt.api)
+			if tt.wantDialErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantDialErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantDialErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRemoteSigner: %v", err)
+			}
+			defer remote.Close()
+
+			tx := testTx()
+			signed, err := remote.SignTx(context.Background(), tx, big.NewInt(31337))
+			if tt.wantSignErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantSignErr) {
+					t.Fatalf("got error %v, want one mentioning %q", err, tt.wantSignErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SignTx: %v", err)
+			}
+
+			txSigner := types.LatestSignerForChainID(big.NewInt(31337))
+			sender, err := types.Sender(txSigner, signed)
+			if err != nil || sender != operator {
+				t.Errorf("signed by %s (%v), want %s", sender.Hex(), err, operator.Hex())
+			}
+			if txSigner.Hash(signed) != txSigner.Hash(tx) {
+				t.Error("signed transaction differs from the request")
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/wallet"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Signer signs transactions on behalf of a single account
+type Signer interface {
+	// Address returns the account the signer signs for
+	Address() common.Address
+	// SignTx signs a transaction for the given chain
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// New creates the signer configured for the validator: an external signer if
+// SIGNER_URL is set, otherwise an in-process signer using the keystore or
+// WALLET_PRIVATE_KEY
+func New(cfg *config.Config) (Signer, error) {
+	if cfg.SignerURL != "" {
+		return NewRemoteSigner(cfg.SignerURL, common.HexToAddress(cfg.SignerAddress), cfg.SignerAPI)
+	}
+
+	privateKey, err := wallet.LoadKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeySigner(privateKey), nil
+}
+
+// TransactOpts returns transaction options for the contract bindings that
+// sign through the given signer
+func TransactOpts(ctx context.Context, s Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: s.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(ctx, tx, chainID)
+		},
+		Context: ctx,
+	}
+}
+
+// UnsignedOpts returns transaction options that build transactions for from
+// without signing them, for accounts whose key is kept offline
+func UnsignedOpts(ctx context.Context, from common.Address, chainID *big.Int) *bind.TransactOpts {
+	opts := TransactOpts(ctx, NewUnsigned(from), chainID)
+	opts.NoSend = true
+
+	return opts
+}
+
+// Unsigned stands in for an account whose key is kept on another machine.
+// It returns transactions unchanged, so they can be written out and signed
+// offline.
+type Unsigned struct {
+	address common.Address
+}
+
+// NewUnsigned creates a placeholder signer for the given account
+func NewUnsigned(address common.Address) *Unsigned {
+	return &Unsigned{address: address}
+}
+
+// Address returns the account the transactions are built for
+func (u *Unsigned) Address() common.Address {
+	return u.address
+}
+
+// SignTx returns the transaction without a signature, with the chain ID
+// filled in so the offline signer can check it
+func (u *Unsigned) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if tx.Type() != types.DynamicFeeTxType || chainID == nil {
+		return tx, nil
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:    chainID,
+		Nonce:      tx.Nonce(),
+		GasTipCap:  tx.GasTipCap(),
+		GasFeeCap:  tx.GasFeeCap(),
+		Gas:        tx.Gas(),
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}), nil
+}
+
+// KeySigner signs in-process with a private key
+type KeySigner struct {
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+// NewKeySigner creates a signer for a private key held in memory
+func NewKeySigner(privateKey *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{
+		privateKey: privateKey,
+		address:    wallet.Address(privateKey),
+	}
+}
+
+// Address returns the address of the private key
+func (k *KeySigner) Address() common.Address {
+	return k.address
+}
+
+// SignTx signs the transaction with the private key
+func (k *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if chainID == nil {
+		return nil, errors.New("chain ID is required for signing")
+	}
+
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), k.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+
+	return signed, nil
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"io"
+)
+
+// key is a key press the terminal UI reacts to
+type key int
+
+const (
+	keyUp key = iota
+	keyDown
+	keyTab
+	keyEnter
+	keyBack
+	keyFilter
+	keyPause
+	keyQuit
+)
+
+// readKeys decodes key presses from a terminal in raw mode until it fails
+func readKeys(in io.Reader, keys chan<- key) {
+	buf := make([]byte, 16)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for _, k := range decodeKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// decodeKeys turns raw terminal input into key presses, ignoring unknown keys
+func decodeKeys(input []byte) []key {
+	var decoded []key
+	for i := 0; i < len(input); i++ {
+		switch b := input[i]; {
+		case b == 0x1b && i+2 < len(input) && input[i+1] == '[':
+			// Arrow keys are sent as ESC [ A to ESC [ D
+			switch input[i+2] {
+			case 'A':
+				decoded = append(decoded, keyUp)
+			case 'B':
+				decoded = append(decoded, keyDown)
+			case 'D':
+				decoded = append(decoded, keyBack)
+			}
+			i += 2
+		case b == 0x1b:
+			decoded = append(decoded, keyBack)
+		case b == 'k':
+			decoded = append(decoded, keyUp)
+		case b == 'j':
+			decoded = append(decoded, keyDown)
+		case b == '\t':
+			decoded = append(decoded, keyTab)
+		case b == '\r' || b == '\n':
+			decoded = append(decoded, keyEnter)
+		case b == 0x7f || b == 'b':
+			decoded = append(decoded, keyBack)
+		case b == 'f':
+			decoded = append(decoded, keyFilter)
+		case b == 'p':
+			decoded = append(decoded, keyPause)
+		case b == 'q' || b == 0x03:
+			decoded = append(decoded, keyQuit)
+		}
+	}
+
+	return decoded
+}
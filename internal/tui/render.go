@@ -0,0 +1,311 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dexponent/geth-validator/internal/validator"
+	"golang.org/x/term"
+)
+
+// ANSI styles
+const (
+	styleReset    = "\033[0m"
+	styleTitle    = "\033[1;30;46m"
+	styleHeader   = "\033[1;36m"
+	styleFocused  = "\033[1;33m"
+	styleSelected = "\033[7m"
+	styleDim      = "\033[2m"
+	styleError    = "\033[31m"
+)
+
+// statusStyles colours request statuses
+var statusStyles = map[string]string{
+	validator.RequestConfirmed: "\033[32m",
+	validator.RequestFailed:    "\033[31m",
+	validator.RequestReverted:  "\033[35m",
+	validator.RequestPending:   "\033[36m",
+	validator.RequestSetAside:  "\033[2m",
+	validator.RequestDropped:   "\033[2m",
+	validator.RequestExpired:   "\033[2m",
+}
+
+// screen collects the lines of one frame
+type screen struct {
+	width int
+	lines []string
+}
+
+// add appends a line, cut to the screen width and wrapped in a style
+func (s *screen) add(style, text string) {
+	line := fit(text, s.width)
+	if style != "" {
+		line = style + line + styleReset
+	}
+	s.lines = append(s.lines, line)
+}
+
+// draw renders the current screen
+func (a *App) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width < 40 || height < 12 {
+		width, height = 80, 24
+	}
+
+	s := &screen{width: width}
+	a.drawTitle(s)
+
+	if a.detail != "" {
+		a.drawDetail(s, height-3)
+	} else {
+		a.drawMain(s, height-3)
+	}
+
+	for len(s.lines) < height-1 {
+		s.add("", "")
+	}
+
+	if a.detail != "" {
+		s.add(styleDim, " ↑/↓ scroll   esc back   p pause/resume intake   q quit")
+	} else {
+		s.add(styleDim, " ↑/↓ move   tab switch pane   enter details   f filter   p pause/resume intake   q quit")
+	}
+
+	// Raw mode needs explicit carriage returns; clear each line's leftovers
+	fmt.Print("\033[H" + strings.Join(s.lines[:height], "\033[K\r\n") + "\033[K")
+}
+
+// drawTitle renders the node summary and the latest message or error
+func (a *App) drawTitle(s *screen) {
+	title := " DXP VALIDATOR (" + a.mode + ")"
+	if a.activity != nil && a.activity.Status != nil {
+		status := a.activity.Status
+		intake := "running"
+		if status.IntakePaused {
+			intake = "PAUSED"
+		}
+		balance := "balance unavailable"
+		if status.Balance != nil {
+			balance = fmt.Sprintf("%.4f ETH", *status.Balance)
+		}
+		title += fmt.Sprintf("   node %s   block %d   queue %d   intake %s   %s",
+			status.NodeID, status.LastBlockProcessed, status.VerificationQueueSize, intake, balance)
+	}
+	s.add(styleTitle, pad(title, s.width))
+
+	switch {
+	case a.err != nil:
+		s.add(styleError, " "+a.err.Error())
+	case a.message != "":
+		s.add("", " "+a.message)
+	default:
+		s.add("", "")
+	}
+}
+
+// drawMain renders the request, vote, pending transaction and log panes
+func (a *App) drawMain(s *screen, height int) {
+	requestRows := height*2/5 - 2
+	middleRows := height/4 - 1
+	logRows := height - requestRows - middleRows - 4
+
+	// Requests
+	requests := a.requests()
+	a.selected[paneRequests] = clamp(a.selected[paneRequests], len(requests))
+	a.paneHeader(s, paneRequests, fmt.Sprintf("REQUESTS (%d, filter: %s)", len(requests), filters[a.filter].name))
+	s.add(styleDim, fmt.Sprintf("  %-12s %-11s %-10s %-18s %s", "ID", "STATUS", "BLOCK", "TX", "UPDATED"))
+	offset := scrollOffset(a.selected[paneRequests], requestRows)
+	for i := offset; i < offset+requestRows; i++ {
+		if i >= len(requests) {
+			s.add("", "")
+			continue
+		}
+		request := requests[i]
+		line := fmt.Sprintf("  %-12s %-11s %-10s %-18s %s",
+			request.ID, request.Status, blockText(request.Block), shortHash(request.TxHash), request.Updated.Local().Format("15:04:05"))
+		s.add(a.rowStyle(paneRequests, i, request.Status), pad(line, s.width))
+	}
+
+	// Votes for the selected request next to the pending transactions
+	var votes []string
+	voteTitle := "CONSENSUS VOTES"
+	if request, ok := a.selectedRequest(); ok {
+		voteTitle += " FOR " + request.ID
+		for _, vote := range request.Votes {
+			votes = append(votes, fmt.Sprintf("  %-10s %s", vote.Participant, shortHash(vote.Result)))
+		}
+		if len(request.Votes) == 0 {
+			votes = append(votes, "  no votes yet")
+		}
+	}
+
+	pending := a.pendingTransactions()
+	a.selected[panePending] = clamp(a.selected[panePending], len(pending))
+	pendingLines := make([]string, 0, len(pending))
+	pendingOffset := scrollOffset(a.selected[panePending], middleRows)
+	for i := pendingOffset; i < len(pending) && i < pendingOffset+middleRows; i++ {
+		pendingLines = append(pendingLines, fmt.Sprintf("  %-12s %s", pending[i].ID, pending[i].TxHash))
+	}
+
+	half := s.width / 2
+	s.add("", styleHeader+pad(" "+voteTitle, half)+styleReset+a.paneTitle(panePending, fmt.Sprintf("PENDING TRANSACTIONS (%d)", len(pending))))
+	for i := 0; i < middleRows; i++ {
+		left := ""
+		if i < len(votes) {
+			left = votes[i]
+		}
+		right := ""
+		if i < len(pendingLines) {
+			right = pendingLines[i]
+		}
+		rightText := fit(right, s.width-half)
+		if right != "" && a.focus == panePending && i+pendingOffset == a.selected[panePending] {
+			rightText = styleSelected + rightText + styleReset
+		}
+		s.lines = append(s.lines, pad(fit(left, half), half)+rightText)
+	}
+
+	// Recent events
+	s.add(styleHeader, " LOG")
+	for i := 0; i < logRows; i++ {
+		if a.activity == nil || i >= len(a.activity.Events) {
+			s.add("", "")
+			continue
+		}
+		event := a.activity.Events[i]
+		s.add("", fmt.Sprintf("  %s %-12s %-18s %s", event.Time.Local().Format("15:04:05"), event.RequestID, event.Type, event.Message))
+	}
+}
+
+// drawDetail renders the lifecycle of a single request
+func (a *App) drawDetail(s *screen, height int) {
+	request, ok := a.findRequest(a.detail)
+	if !ok {
+		s.add(styleHeader, " REQUEST "+a.detail)
+		s.add("", "  This request is no longer tracked by the node.")
+		return
+	}
+
+	lines := []string{
+		fmt.Sprintf("  Status:       %s", request.Status),
+		fmt.Sprintf("  Found in:     %s", blockText(request.Block)),
+		fmt.Sprintf("  Transaction:  %s", valueOr(request.TxHash, "-")),
+		fmt.Sprintf("  Last update:  %s", request.Updated.Local().Format("2006-01-02 15:04:05")),
+		"",
+		"  CONSENSUS VOTES",
+	}
+	if len(request.Votes) == 0 {
+		lines = append(lines, "    none")
+	}
+	for _, vote := range request.Votes {
+		lines = append(lines, fmt.Sprintf("    %-10s %s", vote.Participant, vote.Result))
+	}
+	lines = append(lines, "", "  LIFECYCLE")
+	for _, event := range request.Events {
+		line := fmt.Sprintf("    %s  %-18s %s", event.Time.Local().Format("15:04:05.000"), event.Type, event.Message)
+		if event.TxHash != "" {
+			line += "  " + event.TxHash
+		}
+		lines = append(lines, line)
+	}
+
+	s.add(styleHeader, " REQUEST "+request.ID)
+
+	rows := height - 1
+	if a.detailScroll > len(lines)-rows {
+		a.detailScroll = len(lines) - rows
+	}
+	if a.detailScroll < 0 {
+		a.detailScroll = 0
+	}
+	for i := a.detailScroll; i < len(lines) && i < a.detailScroll+rows; i++ {
+		s.add(statusStyles[request.Status], lines[i])
+	}
+}
+
+// paneHeader renders the title line of a pane, highlighted when it has the focus
+func (a *App) paneHeader(s *screen, pane int, title string) {
+	s.lines = append(s.lines, a.paneTitle(pane, title))
+}
+
+// paneTitle returns the styled title of a pane
+func (a *App) paneTitle(pane int, title string) string {
+	style := styleHeader
+	if a.focus == pane {
+		style = styleFocused
+		title = "▶ " + title
+	} else {
+		title = " " + title
+	}
+
+	return style + title + styleReset
+}
+
+// rowStyle returns the style of a list row
+func (a *App) rowStyle(pane, index int, status string) string {
+	if a.focus == pane && a.selected[pane] == index {
+		return styleSelected
+	}
+
+	return statusStyles[status]
+}
+
+// scrollOffset returns the first row to show so the selected row is visible
+func scrollOffset(selected, rows int) int {
+	if rows <= 0 || selected < rows {
+		return 0
+	}
+
+	return selected - rows + 1
+}
+
+// fit cuts text to at most width characters
+func fit(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+
+	return string([]rune(text)[:width])
+}
+
+// pad fills text with spaces up to width characters
+func pad(text string, width int) string {
+	text = fit(text, width)
+	if n := utf8.RuneCountInString(text); n < width {
+		text += strings.Repeat(" ", width-n)
+	}
+
+	return text
+}
+
+// shortHash abbreviates a hash to its first and last characters
+func shortHash(hash string) string {
+	if len(hash) <= 18 {
+		return hash
+	}
+
+	return hash[:10] + "…" + hash[len(hash)-6:]
+}
+
+// blockText formats a block number, which is unknown for restored requests
+func blockText(block uint64) string {
+	if block == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d", block)
+}
+
+// valueOr returns value, or fallback when it is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
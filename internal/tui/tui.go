@@ -0,0 +1,272 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/validator"
+	"golang.org/x/term"
+)
+
+// refreshInterval is how often the activity is fetched from the node
+const refreshInterval = time.Second
+
+// Source provides what the terminal UI shows: the node in this process, or a
+// running node reached through its admin API
+type Source interface {
+	Activity(ctx context.Context) (*validator.Activity, error)
+	SetIntakePaused(ctx context.Context, paused bool) error
+}
+
+// Panes that can take the keyboard focus on the main screen
+const (
+	paneRequests = iota
+	panePending
+	paneCount
+)
+
+// filters are the request status filters, cycled with f
+var filters = []struct {
+	name     string
+	statuses []string
+}{
+	{name: "all"},
+	{name: "active", statuses: []string{validator.RequestQueued, validator.RequestProcessing, validator.RequestComputed, validator.RequestAgreed, validator.RequestPending}},
+	{name: "pending tx", statuses: []string{validator.RequestPending}},
+	{name: "confirmed", statuses: []string{validator.RequestConfirmed}},
+	{name: "failed", statuses: []string{validator.RequestFailed, validator.RequestReverted, validator.RequestDropped, validator.RequestExpired}},
+	{name: "set aside", statuses: []string{validator.RequestSetAside}},
+}
+
+// App is the state of the terminal UI
+type App struct {
+	source Source
+	mode   string
+
+	activity *validator.Activity
+	err      error
+	message  string
+
+	focus    int
+	selected [paneCount]int
+	filter   int
+
+	// detail is the ID of the request being drilled into, if any
+	detail       string
+	detailScroll int
+}
+
+// Run shows the terminal UI until the user quits or ctx is cancelled. mode
+// describes where the data comes from, e.g. "local" or the admin socket.
+func Run(ctx context.Context, source Source, mode string) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("the terminal UI needs an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to switch the terminal to raw mode: %v", err)
+	}
+	defer term.Restore(fd, state)
+
+	// Use the alternate screen so the terminal is left as it was
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	app := &App{source: source, mode: mode}
+	keys := make(chan key, 16)
+	go readKeys(os.Stdin, keys)
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	app.refresh(ctx)
+	for {
+		app.draw()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			app.refresh(ctx)
+		case k := <-keys:
+			if app.handleKey(ctx, k) {
+				return nil
+			}
+		}
+	}
+}
+
+// refresh fetches the latest activity from the source
+func (a *App) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	activity, err := a.source.Activity(ctx)
+	if err != nil {
+		a.err = err
+		return
+	}
+
+	a.activity = activity
+	a.err = nil
+}
+
+// handleKey applies a key press and reports whether the user quit
+func (a *App) handleKey(ctx context.Context, k key) bool {
+	switch k {
+	case keyQuit:
+		return true
+	case keyPause:
+		a.toggleIntake(ctx)
+		return false
+	}
+
+	// Keys on the request detail screen
+	if a.detail != "" {
+		switch k {
+		case keyBack:
+			a.detail = ""
+		case keyUp:
+			if a.detailScroll > 0 {
+				a.detailScroll--
+			}
+		case keyDown:
+			a.detailScroll++
+		}
+		return false
+	}
+
+	// Keys on the main screen
+	switch k {
+	case keyUp:
+		if a.selected[a.focus] > 0 {
+			a.selected[a.focus]--
+		}
+	case keyDown:
+		a.selected[a.focus]++
+	case keyTab:
+		a.focus = (a.focus + 1) % paneCount
+	case keyFilter:
+		a.filter = (a.filter + 1) % len(filters)
+		a.selected[paneRequests] = 0
+	case keyEnter:
+		if request, ok := a.selectedRequest(); ok {
+			a.detail = request.ID
+			a.detailScroll = 0
+		}
+	}
+
+	return false
+}
+
+// toggleIntake pauses or resumes the node's request intake
+func (a *App) toggleIntake(ctx context.Context) {
+	if a.activity == nil || a.activity.Status == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	paused := !a.activity.Status.IntakePaused
+	if err := a.source.SetIntakePaused(ctx, paused); err != nil {
+		a.message = "Could not change intake: " + err.Error()
+		return
+	}
+
+	if paused {
+		a.message = "Intake paused, requests keep queueing"
+	} else {
+		a.message = "Intake resumed"
+	}
+	a.refresh(ctx)
+}
+
+// requests returns the requests passing the current filter
+func (a *App) requests() []validator.RequestActivity {
+	if a.activity == nil {
+		return nil
+	}
+
+	statuses := filters[a.filter].statuses
+	if len(statuses) == 0 {
+		return a.activity.Requests
+	}
+
+	var matching []validator.RequestActivity
+	for _, request := range a.activity.Requests {
+		for _, status := range statuses {
+			if request.Status == status {
+				matching = append(matching, request)
+				break
+			}
+		}
+	}
+
+	return matching
+}
+
+// pendingTransactions returns the requests whose transaction is not mined yet
+func (a *App) pendingTransactions() []validator.RequestActivity {
+	if a.activity == nil {
+		return nil
+	}
+
+	var pending []validator.RequestActivity
+	for _, request := range a.activity.Requests {
+		if request.Status == validator.RequestPending {
+			pending = append(pending, request)
+		}
+	}
+
+	return pending
+}
+
+// selectedRequest returns the request selected in the focused pane
+func (a *App) selectedRequest() (validator.RequestActivity, bool) {
+	list := a.requests()
+	if a.focus == panePending {
+		list = a.pendingTransactions()
+	}
+
+	if len(list) == 0 {
+		return validator.RequestActivity{}, false
+	}
+
+	index := clamp(a.selected[a.focus], len(list))
+	a.selected[a.focus] = index
+
+	return list[index], true
+}
+
+// findRequest returns the tracked request with the given ID
+func (a *App) findRequest(id string) (validator.RequestActivity, bool) {
+	if a.activity == nil {
+		return validator.RequestActivity{}, false
+	}
+
+	for _, request := range a.activity.Requests {
+		if request.ID == id {
+			return request, true
+		}
+	}
+
+	return validator.RequestActivity{}, false
+}
+
+// clamp keeps an index within a list of n items
+func clamp(index, n int) int {
+	if index >= n {
+		index = n - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+
+	return index
+}
@@ -93,6 +93,11 @@ func (c *ConsoleUI) UpdateMetrics(metrics ValidatorMetrics) {
 	c.metrics = metrics
 }
 
+// AddRevert adds a log entry for a transaction that reverted, with its decoded reason
+func (c *ConsoleUI) AddRevert(requestID, txHash, reason string) {
+	c.AddLog(requestID, "reverted", txHash, "Reverted: "+reason)
+}
+
 // AddLog adds a new log entry
 func (c *ConsoleUI) AddLog(requestID, status, txHash, message string) {
 	c.mutex.Lock()
@@ -126,6 +131,8 @@ func (c *ConsoleUI) AddLog(requestID, status, txHash, message string) {
 		statusColor = "\033[32m" // Green
 	case "error":
 		statusColor = "\033[31m" // Red
+	case "reverted":
+		statusColor = "\033[35m" // Magenta
 	case "processing":
 		statusColor = "\033[33m" // Yellow
 	case "pending":
@@ -210,14 +217,14 @@ func (c *ConsoleUI) renderMetrics() {
 
 	table.Append([]string{"Node ID", c.metrics.NodeID})
 	table.Append([]string{"Address", c.metrics.Address})
-	table.Append([]string{"Balance", c.metrics.Balance + " ETH"})
+	table.Append([]string{"Balance", withUnit(c.metrics.Balance)})
 	table.Append([]string{"Registered", registeredStatus})
 	table.Append([]string{"Last Block", fmt.Sprintf("%d", c.metrics.LastBlockProcessed)})
 	table.Append([]string{"Queue Size", fmt.Sprintf("%d", c.metrics.VerificationQueueSize)})
 	table.Append([]string{"Processed Requests", fmt.Sprintf("%d", c.metrics.ProcessedRequests)})
 	table.Append([]string{"Successful Submissions", fmt.Sprintf("%d", c.metrics.SuccessfulSubmissions)})
 	table.Append([]string{"Failed Submissions", fmt.Sprintf("%d", c.metrics.FailedSubmissions)})
-	table.Append([]string{"Rewards", c.metrics.Rewards + " ETH"})
+	table.Append([]string{"Rewards", withUnit(c.metrics.Rewards)})
 
 	// Render the table
 	table.Render()
@@ -265,6 +272,8 @@ func (c *ConsoleUI) renderMetrics() {
 			statusStr = "\033[32mSUCCESS\033[0m" // Green
 		case "error":
 			statusStr = "\033[31mERROR\033[0m" // Red
+		case "reverted":
+			statusStr = "\033[35mREVERTED\033[0m" // Magenta
 		case "processing":
 			statusStr = "\033[33mPROCESSING\033[0m" // Yellow
 		case "pending":
@@ -302,6 +311,14 @@ func (c *ConsoleUI) renderMetrics() {
 	fmt.Println()
 }
 
+// withUnit formats an ETH amount, which is empty when it could not be read
+func withUnit(amount string) string {
+	if amount == "" {
+		return "unavailable"
+	}
+	return amount + " ETH"
+}
+
 // RenderOnce renders the metrics once without starting the update routine
 func (c *ConsoleUI) RenderOnce() {
 	c.renderMetrics()
@@ -0,0 +1,84 @@
+package validator
+
+import (
+	"context"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// accountCheckInterval is how often the operator account's balances and
+// registration are read from the chain
+const accountCheckInterval = 30 * time.Second
+
+// monitorAccount publishes the operator account's balances and registration
+// status until ctx is cancelled, for the metrics and alerts
+func (v *Validator) monitorAccount(ctx context.Context) {
+	ticker := time.NewTicker(accountCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		v.checkBalances(ctx)
+		v.checkRegistration(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkBalances reads the operator account's ETH and DXP balances
+func (v *Validator) checkBalances(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	balance, err := v.client.BalanceAt(ctx, v.address, nil)
+	if err != nil {
+		logging.Logger().Warn("Failed to get wallet balance", "err", err)
+		return
+	}
+	checked := &events.BalanceChecked{Balance: balance}
+
+	if v.token != nil {
+		dxp, err := v.token.BalanceOf(&bind.CallOpts{Context: ctx}, v.address)
+		if err != nil {
+			logging.Logger().Warn("Failed to get DXP balance", "err", err)
+		} else {
+			checked.DXP = dxp
+		}
+	}
+
+	v.events.Publish(checked)
+}
+
+// checkRegistration reads whether the validator is still registered with the
+// protocol contract
+func (v *Validator) checkRegistration(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	registered, err := v.contract.IsRegistered(&bind.CallOpts{Context: ctx, From: v.address}, v.address)
+	if err != nil {
+		logging.Logger().Warn("Failed to check registration status", "err", err)
+		return
+	}
+
+	v.mutex.Lock()
+	if v.registered && !registered {
+		logging.Logger().Error("Validator is no longer registered with the protocol contract")
+	}
+	v.registered = registered
+	v.mutex.Unlock()
+
+	v.events.Publish(&events.RegistrationChecked{Registered: registered})
+}
+
+// Address returns the operator account the validator signs with
+func (v *Validator) Address() common.Address {
+	return v.address
+}
@@ -0,0 +1,223 @@
+package validator
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+)
+
+// Limits on the activity kept in memory for the terminal UI
+const (
+	maxTrackedRequests = 200
+	maxActivityEvents  = 200
+)
+
+// Request statuses shown in the activity view
+const (
+	RequestQueued     = "queued"
+	RequestProcessing = "processing"
+	RequestComputed   = "computed"
+	RequestAgreed     = "agreed"
+	RequestPending    = "pending"
+	RequestConfirmed  = "confirmed"
+	RequestReverted   = "reverted"
+	RequestFailed     = "failed"
+	RequestSetAside   = "set aside"
+	RequestDropped    = "dropped"
+	RequestExpired    = "expired"
+)
+
+// eventStatus maps each event to the request status it leads to
+var eventStatus = map[events.Type]string{
+	events.TypeRequestFound:     RequestQueued,
+	events.TypeRequestStarted:   RequestProcessing,
+	events.TypeRequestSetAside:  RequestSetAside,
+	events.TypeRequestDropped:   RequestDropped,
+	events.TypeRequestExpired:   RequestExpired,
+	events.TypeComputeDone:      RequestComputed,
+	events.TypeComputeFailed:    RequestFailed,
+	events.TypeConsensusReached: RequestAgreed,
+	events.TypeConsensusSplit:   RequestFailed,
+	events.TypeProofFailed:      RequestFailed,
+	events.TypeTxSent:           RequestPending,
+	events.TypeTxMined:          RequestConfirmed,
+	events.TypeTxReverted:       RequestReverted,
+	events.TypeTxFailed:         RequestFailed,
+}
+
+// Vote is the result a consensus participant submitted for a request
+type Vote struct {
+	Participant string `json:"participant"`
+	Result      string `json:"result"`
+}
+
+// RequestActivity is the lifecycle of a request as seen by this node
+type RequestActivity struct {
+	ID      string         `json:"id"`
+	Status  string         `json:"status"`
+	Block   uint64         `json:"block,omitempty"`
+	TxHash  string         `json:"txHash,omitempty"`
+	Votes   []Vote         `json:"votes,omitempty"`
+	Events  []events.Entry `json:"events"`
+	Updated time.Time      `json:"updated"`
+}
+
+// Activity is the live view of the node served to the terminal UI
+type Activity struct {
+	Status *ValidatorStatus `json:"status"`
+	// Requests holds the most recently updated requests first
+	Requests []RequestActivity `json:"requests"`
+	// Events holds the most recent events first
+	Events []events.Entry `json:"events"`
+}
+
+// activityLog records the events published by the node
+type activityLog struct {
+	mutex    sync.Mutex
+	requests map[string]*RequestActivity
+	events   []events.Entry
+}
+
+// newActivityLog creates an empty activity log
+func newActivityLog() *activityLog {
+	return &activityLog{
+		requests: make(map[string]*RequestActivity),
+	}
+}
+
+// unrecorded lists the periodic events left out of the activity log, as they
+// would crowd out everything else
+var unrecorded = map[events.Type]bool{
+	events.TypeBlockProcessed:      true,
+	events.TypeHeadChecked:         true,
+	events.TypeBalanceChecked:      true,
+	events.TypeRegistrationChecked: true,
+}
+
+// record adds an event to the log and to the lifecycle of its request. It is
+// registered as a handler on the validator's event bus.
+func (a *activityLog) record(published events.Event) {
+	if unrecorded[published.Type()] {
+		return
+	}
+	event := events.NewEntry(published)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.events = append(a.events, event)
+	if len(a.events) > maxActivityEvents {
+		a.events = a.events[len(a.events)-maxActivityEvents:]
+	}
+
+	if event.RequestID == "" {
+		return
+	}
+
+	request, ok := a.requests[event.RequestID]
+	if !ok {
+		request = &RequestActivity{ID: event.RequestID}
+		a.requests[event.RequestID] = request
+		a.evict()
+	}
+
+	request.Events = append(request.Events, event)
+	request.Updated = event.Time
+	if status, ok := eventStatus[event.Type]; ok {
+		request.Status = status
+	}
+	if event.Type == events.TypeRequestFound && event.Block != 0 {
+		request.Block = event.Block
+	}
+	if event.TxHash != "" {
+		request.TxHash = event.TxHash
+	}
+}
+
+// evict drops the least recently updated request once too many are tracked
+func (a *activityLog) evict() {
+	if len(a.requests) <= maxTrackedRequests {
+		return
+	}
+
+	var oldest *RequestActivity
+	for _, request := range a.requests {
+		if oldest == nil || request.Updated.Before(oldest.Updated) {
+			oldest = request
+		}
+	}
+	delete(a.requests, oldest.ID)
+}
+
+// snapshot returns copies of the tracked requests and recent events, newest first
+func (a *activityLog) snapshot() ([]RequestActivity, []events.Entry) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	requests := make([]RequestActivity, 0, len(a.requests))
+	for _, request := range a.requests {
+		copied := *request
+		copied.Events = append([]events.Entry(nil), request.Events...)
+		requests = append(requests, copied)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Updated.After(requests[j].Updated)
+	})
+
+	recent := make([]events.Entry, len(a.events))
+	for i, event := range a.events {
+		recent[len(a.events)-1-i] = event
+	}
+
+	return requests, recent
+}
+
+// Activity returns the node's status with the recent requests and events
+func (v *Validator) Activity(ctx context.Context) (*Activity, error) {
+	status, err := v.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, recent := v.activity.snapshot()
+	for i := range requests {
+		requests[i].Votes = v.votes(requests[i].ID)
+	}
+
+	return &Activity{
+		Status:   status,
+		Requests: requests,
+		Events:   recent,
+	}, nil
+}
+
+// votes returns the consensus votes for a request, ordered by participant
+func (v *Validator) votes(requestID string) []Vote {
+	submitted := v.consensusEngine.Votes(requestID)
+
+	votes := make([]Vote, 0, len(submitted))
+	for participant, result := range submitted {
+		votes = append(votes, Vote{Participant: participant, Result: string(result)})
+	}
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].Participant < votes[j].Participant
+	})
+
+	return votes
+}
+
+// SetIntakePaused stops or resumes taking requests off the queue. Blocks are
+// still scanned while paused, so new requests keep queueing up.
+func (v *Validator) SetIntakePaused(ctx context.Context, paused bool) error {
+	v.mutex.Lock()
+	v.intakePaused = paused
+	v.scheduler.signal()
+	v.mutex.Unlock()
+
+	logging.Logger().Info("Request intake changed", "paused", paused)
+	return nil
+}
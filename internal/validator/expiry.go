@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+)
+
+// withDeadline returns the request with its deadline set: the one carried by
+// its event, or else ttl after its block. A zero ttl leaves it without one.
+func withDeadline(request VerificationRequest, ttl time.Duration) VerificationRequest {
+	if !request.Deadline.IsZero() || ttl <= 0 || request.Timestamp == nil {
+		return request
+	}
+
+	request.Deadline = time.Unix(request.Timestamp.Int64(), 0).Add(ttl)
+	return request
+}
+
+// requestTTL returns how long after its block a request without a deadline
+// of its own expires
+func requestTTL(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.RequestTTLSeconds) * time.Second
+}
+
+// errExpired is returned when a request expired while its result was being submitted
+var errExpired = errors.New("verification request expired")
+
+// deadlineContext returns a context that is done margin before the request's
+// deadline, or with ctx if it has none
+func deadlineContext(ctx context.Context, request VerificationRequest, margin time.Duration) (context.Context, context.CancelFunc) {
+	if request.Deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithDeadline(ctx, request.Deadline.Add(-margin))
+}
+
+// expire reports whether a request has less than margin left before its
+// deadline, in which case it is recorded as expired at stage
+func (v *Validator) expire(request VerificationRequest, stage string, margin time.Duration) bool {
+	if request.Deadline.IsZero() || time.Until(request.Deadline) >= margin {
+		return false
+	}
+
+	logging.Logger().Warn("Verification request expired", logging.KeyRequestID, request.ID.String(), "stage", stage, "deadline", request.Deadline.Format(time.RFC3339))
+	v.events.Publish(&events.RequestExpired{
+		Header:   events.Header{RequestID: request.ID.String(), Block: request.Block},
+		Stage:    stage,
+		Deadline: request.Deadline,
+	})
+	return true
+}
+
+// submitMargin returns the least time before its deadline a result is
+// submitted with, so the transaction is not mined late
+func (v *Validator) submitMargin() time.Duration {
+	return time.Duration(v.currentConfig().RequestSubmitMarginSeconds) * time.Second
+}
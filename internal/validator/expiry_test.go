@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/journal"
+	"github.com/dexponent/geth-validator/internal/signer"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestWithDeadline(t *testing.T) {
+	block := time.Unix(1_700_000_000, 0)
+	own := block.Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		deadline time.Time
+		ttl      time.Duration
+
+		want time.Time
+	}{
+		{name: "deadline from the event is kept", deadline: own, ttl: time.Hour, want: own},
+		{name: "ttl after the block", ttl: time.Hour, want: block.Add(time.Hour)},
+		{name: "no ttl, no deadline", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := VerificationRequest{ID: big.NewInt(1), Timestamp: big.NewInt(block.Unix()), Deadline: tt.deadline}
+
+			if got := withDeadline(request, tt.ttl).Deadline; !got.Equal(tt.want) {
+				t.Errorf("deadline %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmitResultDeadline(t *testing.T) {
+	const margin = 2 * time.Second
+
+	tests := []struct {
+		name string
+		// left is the time left before the deadline, none when zero
+		left time.Duration
+		// delay holds up the node while the transaction is priced
+		delay time.Duration
+
+		wantExpired bool
+		wantStatus  string
+	}{
+		{
+			name:        "deadline within the submit margin",
+			left:        margin - time.Second,
+			wantExpired: true,
+			wantStatus:  journal.StatusExpired,
+		},
+		{
+			name:        "deadline passes during the send",
+			left:        margin + 300*time.Millisecond,
+			delay:       time.Second,
+			wantExpired: true,
+			wantStatus:  journal.StatusExpired,
+		},
+		{
+			// The fake node cannot price transactions, so the send fails
+			name:       "failure without a deadline",
+			wantStatus: journal.StatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, pool := newFakeChain(t)
+			chain.delays["eth_getBlockByNumber"] = tt.delay
+			v := newTestValidator(t, pool)
+			v.config.RequestSubmitMarginSeconds = int64(margin / time.Second)
+			v.events.Handle(v.journal.Record)
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.signer = signer.NewKeySigner(key)
+
+			request := VerificationRequest{ID: big.NewInt(1), Block: 90}
+			if tt.left != 0 {
+				request.Deadline = time.Now().Add(tt.left)
+			}
+
+			start := time.Now()
+			err = v.submitResult(context.Background(), request, []byte{1}, []byte{2})
+			if errors.Is(err, errExpired) != tt.wantExpired {
+				t.Errorf("got error %v, want expired %v", err, tt.wantExpired)
+			}
+			if !request.Deadline.IsZero() && time.Since(start) > tt.left {
+				t.Errorf("send took %v, past the deadline", time.Since(start))
+			}
+
+			record, ok := v.journal.Get("1")
+			if !ok || record.Status != tt.wantStatus {
+				t.Errorf("journal has status %q, want %q", record.Status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// requestKey identifies the log a request was emitted in. The same log is
+// seen again when a block is scanned twice; the same request ID in another
+// log means it was emitted again, for instance after a reorg.
+type requestKey struct {
+	id        string
+	blockHash common.Hash
+	logIndex  uint
+}
+
+// seenBlocks is how many blocks behind the last processed block a log is
+// remembered. Blocks are scanned once, in order, so a log older than that can
+// only come back in a reorg deeper than any the node is expected to see.
+const seenBlocks = 256
+
+// keyOf returns the key of a request
+func keyOf(request VerificationRequest) requestKey {
+	return requestKey{id: request.ID.String(), blockHash: request.BlockHash, logIndex: request.LogIndex}
+}
+
+// enqueue queues a request found in a block, unless it was taken in before or
+// needs no more work. It reports whether the request was queued.
+func (v *Validator) enqueue(ctx context.Context, request VerificationRequest) bool {
+	request = withDeadline(request, requestTTL(v.currentConfig()))
+	id := request.ID.String()
+	logger := logging.Logger().With(logging.KeyRequestID, id, logging.KeyBlock, request.Block)
+
+	v.mutex.Lock()
+	_, seen := v.seen[keyOf(request)]
+	v.mutex.Unlock()
+	if seen {
+		logger.Debug("Ignoring verification request that was already taken in", "logIndex", request.LogIndex)
+		return false
+	}
+
+	if reason := v.handledBefore(ctx, request); reason != "" {
+		v.mutex.Lock()
+		v.seen[keyOf(request)] = request.Block
+		v.mutex.Unlock()
+		logger.Info("Skipping verification request", "reason", reason)
+		return false
+	}
+	if v.expire(request, StageQueued, 0) {
+		v.mutex.Lock()
+		v.seen[keyOf(request)] = request.Block
+		v.mutex.Unlock()
+		return false
+	}
+
+	v.mutex.Lock()
+	queued := v.queueLocked(request)
+	v.mutex.Unlock()
+	if !queued {
+		return false
+	}
+
+	logger.Info("Found verification request")
+	v.events.Publish(foundEvent(request, false))
+	return true
+}
+
+// foundEvent returns the event published when a request is queued
+func foundEvent(request VerificationRequest, restored bool) *events.RequestFound {
+	event := &events.RequestFound{
+		Header:    events.Header{RequestID: request.ID.String(), Block: request.Block},
+		Requester: request.Requester.Hex(),
+		LogIndex:  request.LogIndex,
+		Deadline:  request.Deadline,
+		Restored:  restored,
+	}
+	// Requests saved by older versions do not know their log
+	if request.BlockHash != (common.Hash{}) {
+		event.BlockHash = request.BlockHash.Hex()
+	}
+
+	return event
+}
+
+// queueLocked adds a request to the queue unless its log was seen before or
+// the request is already queued or in flight. Must be called with the mutex held.
+func (v *Validator) queueLocked(request VerificationRequest) bool {
+	key := keyOf(request)
+	if _, seen := v.seen[key]; seen {
+		return false
+	}
+	v.seen[key] = request.Block
+
+	if v.pendingLocked(key.id) {
+		logging.Logger().Info("Ignoring verification request emitted again while it is being processed",
+			logging.KeyRequestID, key.id, logging.KeyBlock, request.Block, "blockHash", request.BlockHash.Hex(), "logIndex", request.LogIndex)
+		return false
+	}
+
+	v.scheduler.push(request, time.Now())
+	v.metrics.QueueDepth.Set(float64(v.scheduler.len()))
+	return true
+}
+
+// pendingLocked reports whether a request is queued, in flight or set aside.
+// Must be called with the mutex held.
+func (v *Validator) pendingLocked(id string) bool {
+	if _, ok := v.inFlightRequests[id]; ok {
+		return true
+	}
+	if v.scheduler.contains(id) {
+		return true
+	}
+	for _, unfinished := range v.unfinished {
+		if unfinished.Request.ID.String() == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handledBefore returns why a request needs no more work, or "" if it does.
+// The journal tells whether this node already has a result mined or gave up
+// on the request, in this run or an earlier one; failed requests are retried.
+// A request whose result was sent, but whose outcome the journal does not
+// know, is only retried once the chain shows the transaction was dropped or
+// reverted.
+//
+// Known gap: nothing is checked on-chain about the request itself. The
+// deployed contract exposes no request state (registeredVerifiers is its only
+// getter) and emits no events for results, so requests finished by other
+// nodes are not detected, and deduplication across restarts rests on the
+// local journal alone.
+func (v *Validator) handledBefore(ctx context.Context, request VerificationRequest) string {
+	if record, ok := v.journal.Get(request.ID.String()); ok {
+		switch {
+		case record.Settled() && record.TxHash != "":
+			return fmt.Sprintf("result already submitted in tx %s (%s)", record.TxHash, record.Status)
+		case record.Settled():
+			return "request already " + record.Status
+		case record.TxHash != "":
+			// A failure after sending, such as a receipt that never came,
+			// does not mean the transaction will not be mined; sending
+			// another could submit the result twice
+			return v.sentBefore(ctx, record.ID, record.TxHash)
+		}
+	}
+
+	return ""
+}
+
+// sentBefore returns why a request whose result was sent in txHash needs no
+// more work, or "" once the transaction is known to be dropped or reverted.
+// A transaction that cannot be looked up is assumed to be on its way.
+func (v *Validator) sentBefore(ctx context.Context, id, txHash string) string {
+	status, err := v.lookupTx(ctx, txHash)
+	switch status {
+	case txMined:
+		return fmt.Sprintf("result already mined in tx %s", txHash)
+	case txPending:
+		return fmt.Sprintf("result already submitted in tx %s, awaiting its receipt", txHash)
+	case txReverted, txDropped:
+		logging.Logger().Info("Retrying verification request, its earlier transaction was "+string(status), logging.KeyRequestID, id, logging.KeyTxHash, txHash)
+		return ""
+	default:
+		return fmt.Sprintf("result already submitted in tx %s, which could not be looked up: %v", txHash, err)
+	}
+}
+
+// txStatus is what the chain shows about a transaction that was sent
+type txStatus string
+
+const (
+	txPending  txStatus = "pending"
+	txMined    txStatus = "mined"
+	txReverted txStatus = "reverted"
+	txDropped  txStatus = "dropped"
+)
+
+// lookupTx returns what the chain shows about a transaction that was sent. A
+// transaction without a receipt that the node does not know was dropped.
+func (v *Validator) lookupTx(ctx context.Context, txHash string) (txStatus, error) {
+	hash := common.HexToHash(txHash)
+
+	receipt, err := v.reads.TransactionReceipt(ctx, hash)
+	switch {
+	case err == nil && receipt.Status == types.ReceiptStatusSuccessful:
+		return txMined, nil
+	case err == nil:
+		return txReverted, nil
+	case !errors.Is(err, ethereum.NotFound):
+		return "", fmt.Errorf("failed to get receipt: %v", err)
+	}
+
+	_, _, err = v.client.TransactionByHash(ctx, hash)
+	switch {
+	case err == nil:
+		return txPending, nil
+	case errors.Is(err, ethereum.NotFound):
+		return txDropped, nil
+	default:
+		return "", fmt.Errorf("failed to look up transaction: %v", err)
+	}
+}
+
+// pruneSeenLocked forgets the logs more than seenBlocks behind block, so the
+// seen set does not grow with the node's lifetime. Requests restored from
+// older versions, which do not know their block, are forgotten at once;
+// they are still deduplicated while pending. Must be called with the mutex held.
+func (v *Validator) pruneSeenLocked(block uint64) {
+	if block <= seenBlocks {
+		return
+	}
+
+	for key, seenIn := range v.seen {
+		if seenIn < block-seenBlocks {
+			delete(v.seen, key)
+		}
+	}
+}
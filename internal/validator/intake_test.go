@@ -0,0 +1,444 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/journal"
+	"github.com/dexponent/geth-validator/internal/metrics"
+	"github.com/dexponent/geth-validator/internal/rpcpool"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const testChainID = 31337
+
+// fakeChain serves the eth methods the validator uses from a node
+type fakeChain struct {
+	mutex    sync.Mutex
+	head     uint64
+	balance  *big.Int
+	receipts map[common.Hash]*types.Receipt
+	pending  map[common.Hash]*types.Transaction
+	// failing makes the lookups of these methods fail
+	failing map[string]bool
+	// delays holds up the answers to these methods
+	delays map[string]time.Duration
+}
+
+// chainError is an internal error of the node, which is not an answer
+type chainError struct{}
+
+func (chainError) Error() string  { return "internal error" }
+func (chainError) ErrorCode() int { return -32603 }
+
+func (c *fakeChain) fail(method string) error {
+	time.Sleep(c.delays[method])
+	if c.failing[method] {
+		return chainError{}
+	}
+	return nil
+}
+
+func (c *fakeChain) ChainId() *hexutil.Big { return (*hexutil.Big)(big.NewInt(testChainID)) }
+
+func (c *fakeChain) BlockNumber() hexutil.Uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return hexutil.Uint64(c.head)
+}
+
+func (c *fakeChain) GetBalance(account common.Address, block string) (*hexutil.Big, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.fail("eth_getBalance"); err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(c.balance), nil
+}
+
+func (c *fakeChain) GetBlockByNumber(number rpc.BlockNumber, full bool) (*types.Header, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.fail("eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	if number < 0 {
+		number = rpc.BlockNumber(c.head)
+	}
+	return &types.Header{
+		Number:     big.NewInt(number.Int64()),
+		Time:       uint64(time.Now().Unix()),
+		Difficulty: common.Big0,
+	}, nil
+}
+
+func (c *fakeChain) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.fail("eth_getTransactionReceipt"); err != nil {
+		return nil, err
+	}
+	return c.receipts[hash], nil
+}
+
+func (c *fakeChain) GetTransactionByHash(hash common.Hash) (*types.Transaction, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.fail("eth_getTransactionByHash"); err != nil {
+		return nil, err
+	}
+	return c.pending[hash], nil
+}
+
+// mine gives a transaction a receipt with the status given
+func (c *fakeChain) mine(hash common.Hash, status uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.pending, hash)
+	c.receipts[hash] = &types.Receipt{
+		Status:      status,
+		TxHash:      hash,
+		BlockNumber: big.NewInt(int64(c.head)),
+		GasUsed:     50000,
+		Logs:        []*types.Log{},
+	}
+}
+
+// newFakeChain starts a node serving a fake chain and returns a pool over it
+func newFakeChain(t *testing.T) (*fakeChain, *rpcpool.Pool) {
+	t.Helper()
+
+	chain := &fakeChain{
+		head:     100,
+		balance:  big.NewInt(2e18),
+		receipts: make(map[common.Hash]*types.Receipt),
+		pending:  make(map[common.Hash]*types.Transaction),
+		failing:  make(map[string]bool),
+		delays:   make(map[string]time.Duration),
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", chain); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+
+	pool, err := rpcpool.Dial(context.Background(), &config.Config{
+		Network:        "dev",
+		ChainID:        testChainID,
+		BaseRPCURL:     httpServer.URL,
+		RPCQuorum:      1,
+		RPCMaxBlockLag: 5,
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return chain, pool
+}
+
+// signedTx returns a transaction signed by a throwaway key
+func signedTx(t *testing.T, nonce uint64) *types.Transaction {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(testChainID)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(testChainID),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(3e9),
+		Gas:       100000,
+		To:        &to,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tx
+}
+
+// newTestValidator returns a validator reading from the pool, with an empty
+// journal in a temporary data directory
+func newTestValidator(t *testing.T, pool *rpcpool.Pool) *Validator {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	j, err := journal.Open(filepath.Join(dataDir, journal.FileName))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+
+	return &Validator{
+		rpc:              pool,
+		client:           pool.Client(),
+		reads:            pool.QuorumClient(),
+		config:           &config.Config{Network: "dev", ChainID: testChainID, DataDir: dataDir},
+		scheduler:        newScheduler(1, 0, 0),
+		inFlightRequests: make(map[string]*PendingRequest),
+		seen:             make(map[requestKey]uint64),
+		metrics:          metrics.New(),
+		events:           events.NewBus(),
+		journal:          j,
+	}
+}
+
+func TestHandledBefore(t *testing.T) {
+	// sent is the lifecycle of request 1 up to its result being sent in tx
+	sent := func(tx string) []events.Event {
+		return []events.Event{
+			&events.RequestFound{Header: events.Header{RequestID: "1", Block: 90}},
+			&events.TxSent{Header: events.Header{RequestID: "1", TxHash: tx}},
+		}
+	}
+	receiptLost := func(tx string) events.Event {
+		return &events.TxFailed{Header: events.Header{RequestID: "1", TxHash: tx}, Err: errors.New("context deadline exceeded")}
+	}
+
+	tests := []struct {
+		name string
+		// setup records the request's history and prepares the chain for
+		// the transaction sent, if any
+		setup func(chain *fakeChain, tx *types.Transaction) []events.Event
+
+		// want is a fragment of the reason, or "" when the request is retried
+		want string
+	}{
+		{
+			name:  "new request",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event { return nil },
+		},
+		{
+			name: "confirmed",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				return append(sent(tx.Hash().Hex()), &events.TxMined{Header: events.Header{RequestID: "1", TxHash: tx.Hash().Hex(), Block: 100}})
+			},
+			want: "(confirmed)",
+		},
+		{
+			name: "dropped",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				return []events.Event{&events.RequestDropped{Header: events.Header{RequestID: "1"}, Reason: "too late"}}
+			},
+			want: "request already dropped",
+		},
+		{
+			name: "failed before sending",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				return []events.Event{&events.TxFailed{Header: events.Header{RequestID: "1"}, Err: errors.New("insufficient funds")}}
+			},
+		},
+		{
+			name: "receipt lost but mined",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				chain.mine(tx.Hash(), types.ReceiptStatusSuccessful)
+				return append(sent(tx.Hash().Hex()), receiptLost(tx.Hash().Hex()))
+			},
+			want: "result already mined in tx",
+		},
+		{
+			name: "receipt lost and reverted",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				chain.mine(tx.Hash(), types.ReceiptStatusFailed)
+				return append(sent(tx.Hash().Hex()), receiptLost(tx.Hash().Hex()))
+			},
+		},
+		{
+			name: "receipt lost and still pending",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				chain.pending[tx.Hash()] = tx
+				return append(sent(tx.Hash().Hex()), receiptLost(tx.Hash().Hex()))
+			},
+			want: "awaiting its receipt",
+		},
+		{
+			name: "submitted before a crash and dropped",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				return sent(tx.Hash().Hex())
+			},
+		},
+		{
+			name: "submitted before a crash and still pending",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				chain.pending[tx.Hash()] = tx
+				return sent(tx.Hash().Hex())
+			},
+			want: "awaiting its receipt",
+		},
+		{
+			name: "receipt cannot be looked up",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				chain.failing["eth_getTransactionReceipt"] = true
+				return append(sent(tx.Hash().Hex()), receiptLost(tx.Hash().Hex()))
+			},
+			want: "could not be looked up: failed to get receipt",
+		},
+		{
+			name: "transaction cannot be looked up",
+			setup: func(chain *fakeChain, tx *types.Transaction) []events.Event {
+				chain.failing["eth_getTransactionByHash"] = true
+				return sent(tx.Hash().Hex())
+			},
+			want: "could not be looked up: failed to look up transaction",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, pool := newFakeChain(t)
+			v := newTestValidator(t, pool)
+
+			history := tt.setup(chain, signedTx(t, 0))
+			for _, event := range history {
+				v.journal.Record(event)
+			}
+
+			request := VerificationRequest{ID: big.NewInt(1), Block: 100, Deadline: time.Now().Add(time.Hour)}
+			got := v.handledBefore(context.Background(), request)
+			if tt.want == "" && got != "" {
+				t.Fatalf("request skipped (%s), want it retried", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("reason %q, want one mentioning %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	blockA := common.HexToHash("0xa")
+	blockB := common.HexToHash("0xb")
+	request := func(id int64, blockHash common.Hash, logIndex uint) VerificationRequest {
+		return VerificationRequest{
+			ID:        big.NewInt(id),
+			Block:     100,
+			BlockHash: blockHash,
+			LogIndex:  logIndex,
+			Deadline:  time.Now().Add(time.Hour),
+		}
+	}
+	expired := request(1, blockA, 0)
+	expired.Deadline = time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		// history is recorded in the journal before intake
+		history  []events.Event
+		requests []VerificationRequest
+		want     []bool
+	}{
+		{
+			name:     "the same log scanned twice",
+			requests: []VerificationRequest{request(1, blockA, 0), request(1, blockA, 0)},
+			want:     []bool{true, false},
+		},
+		{
+			name:     "emitted again while queued",
+			requests: []VerificationRequest{request(1, blockA, 0), request(1, blockB, 2)},
+			want:     []bool{true, false},
+		},
+		{
+			name:     "requests in the same block",
+			requests: []VerificationRequest{request(1, blockA, 0), request(2, blockA, 1)},
+			want:     []bool{true, true},
+		},
+		{
+			name:     "settled in an earlier run",
+			history:  []events.Event{&events.TxMined{Header: events.Header{RequestID: "1", TxHash: "0x7a", Block: 90}}},
+			requests: []VerificationRequest{request(1, blockA, 0), request(1, blockB, 0)},
+			want:     []bool{false, false},
+		},
+		{
+			name:     "failed in an earlier run",
+			history:  []events.Event{&events.TxFailed{Header: events.Header{RequestID: "1"}, Err: errors.New("insufficient funds")}},
+			requests: []VerificationRequest{request(1, blockA, 0)},
+			want:     []bool{true},
+		},
+		{
+			name:     "past its deadline",
+			requests: []VerificationRequest{expired, expired},
+			want:     []bool{false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, pool := newFakeChain(t)
+			v := newTestValidator(t, pool)
+			for _, event := range tt.history {
+				v.journal.Record(event)
+			}
+
+			for i, request := range tt.requests {
+				if got := v.enqueue(context.Background(), request); got != tt.want[i] {
+					t.Errorf("request %d queued %v, want %v", i, got, tt.want[i])
+				}
+			}
+			queued := 0
+			for _, want := range tt.want {
+				if want {
+					queued++
+				}
+			}
+			if v.scheduler.len() != queued {
+				t.Errorf("%d requests queued, want %d", v.scheduler.len(), queued)
+			}
+		})
+	}
+}
+
+func TestPruneSeen(t *testing.T) {
+	tests := []struct {
+		name  string
+		seen  []uint64
+		block uint64
+		want  []uint64
+	}{
+		{"early blocks are kept", []uint64{1, 100}, seenBlocks, []uint64{1, 100}},
+		{"logs far behind are forgotten", []uint64{10, 300, 344, 500}, 600, []uint64{344, 500}},
+		{"restored requests without a block are forgotten", []uint64{0, 500}, 600, []uint64{500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validator{seen: make(map[requestKey]uint64)}
+			for i, block := range tt.seen {
+				v.seen[requestKey{id: fmt.Sprint(i), logIndex: uint(i)}] = block
+			}
+
+			v.pruneSeenLocked(tt.block)
+
+			var kept []uint64
+			for _, block := range v.seen {
+				kept = append(kept, block)
+			}
+			sort.Slice(kept, func(i, k int) bool { return kept[i] < kept[k] })
+			if fmt.Sprint(kept) != fmt.Sprint(tt.want) {
+				t.Errorf("kept the logs of blocks %v, want %v", kept, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/metrics"
+	"github.com/dexponent/geth-validator/internal/ui"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// Events returns the bus on which the node publishes its lifecycle events:
+// blocks processed and every stage of each verification request
+func (v *Validator) Events() *events.Bus {
+	return v.events
+}
+
+// Metrics returns the node's Prometheus metrics
+func (v *Validator) Metrics() *metrics.Metrics {
+	return v.metrics
+}
+
+// DashboardMetrics returns the node's state for the console dashboard, with
+// the request counters taken from the Prometheus metrics
+func (v *Validator) DashboardMetrics(ctx context.Context) (ui.ValidatorMetrics, error) {
+	status, err := v.Status(ctx)
+	if err != nil {
+		return ui.ValidatorMetrics{}, err
+	}
+
+	// The balance is left empty when it could not be read
+	var balance string
+	if status.Balance != nil {
+		balance = fmt.Sprintf("%.6f", *status.Balance)
+	}
+
+	// Rewards are left empty while the contract does not track them
+	var rewards string
+	amount, err := v.contract.GetPendingRewards(&bind.CallOpts{Context: ctx}, v.address)
+	switch {
+	case errors.Is(err, contracts.ErrRewardsUnsupported):
+	case err != nil:
+		return ui.ValidatorMetrics{}, fmt.Errorf("failed to get pending rewards: %v", err)
+	default:
+		rewards = fmt.Sprintf("%.6f", weiToEther(amount))
+	}
+
+	counts := v.metrics.Counts()
+
+	return ui.ValidatorMetrics{
+		NodeID:                status.NodeID,
+		Address:               status.Account,
+		Balance:               balance,
+		Registered:            status.Registered,
+		LastBlockProcessed:    status.LastBlockProcessed,
+		VerificationQueueSize: status.VerificationQueueSize,
+		ProcessedRequests:     counts.ProcessedRequests,
+		SuccessfulSubmissions: counts.SuccessfulSubmissions,
+		FailedSubmissions:     counts.FailedSubmissions,
+		Rewards:               rewards,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/gas"
+	"github.com/dexponent/geth-validator/internal/rpcpool"
+	"github.com/dexponent/geth-validator/internal/signer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ClaimValidatorRewards claims the pending rewards with the operator key. It
+// refuses when a separate REWARD_ADDRESS is configured, whose claims must be
+// built with BuildUnsignedClaim and signed offline.
+func ClaimValidatorRewards(cfg *config.Config) (string, error) {
+	if cfg.RewardAddress != "" {
+		return "", fmt.Errorf("rewards are claimed by REWARD_ADDRESS %s, build an unsigned claim with --unsigned-out and sign it offline", cfg.RewardAddress)
+	}
+	if !contracts.ClaimSupported {
+		return "", contracts.ErrClaimUnsupported
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	// Connect to the RPC endpoints
+	pool, err := rpcpool.Dial(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	defer pool.Close()
+
+	txSigner, err := signer.New(cfg)
+	if err != nil {
+		return "", err
+	}
+	if closer, ok := txSigner.(interface{ Close() }); ok {
+		defer closer.Close()
+	}
+
+	contract, err := contracts.NewDexponentContractWrapper(common.HexToAddress(cfg.DXPContractAddress), pool.QuorumClient())
+	if err != nil {
+		return "", fmt.Errorf("failed to create contract instance: %v", err)
+	}
+
+	tx, err := sendTransaction(ctx, pool.Client(), cfg, txSigner, contract.ClaimRewards)
+	if err != nil {
+		return "", fmt.Errorf("failed to claim rewards: %v", err)
+	}
+
+	return tx.Hash().Hex(), nil
+}
+
+// BuildUnsignedClaim builds a rewards claim sent from the reward address,
+// priced, simulated and with its gas limit set, but not signed. It returns
+// contracts.ErrClaimUnsupported before touching the network while the
+// contract has no claim method.
+func BuildUnsignedClaim(cfg *config.Config) (*types.Transaction, common.Address, error) {
+	if cfg.RewardAddress == "" {
+		return nil, common.Address{}, fmt.Errorf("REWARD_ADDRESS is not set")
+	}
+	from := common.HexToAddress(cfg.RewardAddress)
+	if !contracts.ClaimSupported {
+		return nil, from, contracts.ErrClaimUnsupported
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Connect to the RPC endpoints
+	pool, err := rpcpool.Dial(ctx, cfg)
+	if err != nil {
+		return nil, from, err
+	}
+	defer pool.Close()
+	client := pool.Client()
+
+	// Create contract instance
+	contract, err := contracts.NewDexponentContractWrapper(common.HexToAddress(cfg.DXPContractAddress), client)
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to create contract instance: %v", err)
+	}
+
+	// Build for the reward address with its next nonce
+	opts := signer.UnsignedOpts(ctx, from, big.NewInt(cfg.ChainID))
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to get nonce for %s: %v", from.Hex(), err)
+	}
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+
+	// Set fees
+	fees, err := gas.Suggest(ctx, client, cfg)
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to price transaction: %v", err)
+	}
+	fees.Apply(opts)
+	opts.GasLimit = cfg.GasLimit
+
+	tx, err := contract.ClaimRewards(gas.Unsigned(opts))
+	if err != nil {
+		return nil, from, err
+	}
+
+	tx, err = gas.Prepare(ctx, client, cfg, opts, tx)
+	if err != nil {
+		return nil, from, err
+	}
+
+	return tx, from, nil
+}
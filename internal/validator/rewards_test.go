@@ -0,0 +1,83 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/contracts"
+)
+
+func TestRewardsUnsupported(t *testing.T) {
+	const rewardAddress = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+
+	tests := []struct {
+		name          string
+		rewardAddress string
+		run           func(cfg *config.Config) error
+
+		wantErr error
+		// wantMessage is checked when no sentinel error is expected
+		wantMessage string
+	}{
+		{
+			name: "pending rewards are not made up",
+			run: func(cfg *config.Config) error {
+				_, err := GetValidatorRewards(cfg)
+				return err
+			},
+			wantErr: contracts.ErrRewardsUnsupported,
+		},
+		{
+			name: "claim with the operator key",
+			run: func(cfg *config.Config) error {
+				_, err := ClaimValidatorRewards(cfg)
+				return err
+			},
+			wantErr: contracts.ErrClaimUnsupported,
+		},
+		{
+			name:          "operator key refuses to claim for a reward address",
+			rewardAddress: rewardAddress,
+			run: func(cfg *config.Config) error {
+				_, err := ClaimValidatorRewards(cfg)
+				return err
+			},
+			wantMessage: "--unsigned-out",
+		},
+		{
+			name:          "unsigned claim",
+			rewardAddress: rewardAddress,
+			run: func(cfg *config.Config) error {
+				_, _, err := BuildUnsignedClaim(cfg)
+				return err
+			},
+			wantErr: contracts.ErrClaimUnsupported,
+		},
+		{
+			name: "unsigned claim without a reward address",
+			run: func(cfg *config.Config) error {
+				_, _, err := BuildUnsignedClaim(cfg)
+				return err
+			},
+			wantMessage: "REWARD_ADDRESS is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No endpoint is configured: all of these must fail before
+			// touching the network
+			cfg := &config.Config{Network: "dev", ChainID: testChainID, RewardAddress: tt.rewardAddress}
+
+			err := tt.run(cfg)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (err == nil || !strings.Contains(err.Error(), tt.wantMessage)) {
+				t.Errorf("got error %v, want one mentioning %q", err, tt.wantMessage)
+			}
+		})
+	}
+}
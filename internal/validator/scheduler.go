@@ -0,0 +1,219 @@
+package validator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// scheduler holds the queued verification requests and decides which one
+// runs next. Requests close to their deadline go first; otherwise requesters
+// with fewer verifications running are served first, so one requester cannot
+// crowd out the others, then requests with an earlier deadline, a higher
+// reward and, last, the oldest. Requests that can no longer finish before
+// their deadline are dropped.
+//
+// The scheduler is guarded by the validator's mutex.
+type scheduler struct {
+	queue []queuedRequest
+	// running counts the verifications running per requester
+	running map[common.Address]int
+	active  int
+
+	maxActive       int
+	maxPerRequester int
+	// minTimeLeft is the least time before its deadline a request needs to
+	// be worth starting
+	minTimeLeft time.Duration
+
+	// wake is signalled when a request may be ready to run
+	wake chan struct{}
+}
+
+// queuedRequest is a request waiting in the scheduler
+type queuedRequest struct {
+	request VerificationRequest
+	queued  time.Time
+}
+
+// droppedRequest is a request the scheduler gave up on
+type droppedRequest struct {
+	request VerificationRequest
+	reason  string
+}
+
+// newScheduler creates a scheduler running at most maxActive verifications
+// at a time, and at most maxPerRequester for the same requester
+func newScheduler(maxActive, maxPerRequester int, minTimeLeft time.Duration) *scheduler {
+	return &scheduler{
+		running:         make(map[common.Address]int),
+		maxActive:       maxActive,
+		maxPerRequester: maxPerRequester,
+		minTimeLeft:     minTimeLeft,
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+// signal wakes the dispatcher; it never blocks
+func (s *scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// push queues a request
+func (s *scheduler) push(request VerificationRequest, now time.Time) {
+	s.queue = append(s.queue, queuedRequest{request: request, queued: now})
+	s.signal()
+}
+
+// len returns the number of queued requests
+func (s *scheduler) len() int {
+	return len(s.queue)
+}
+
+// contains reports whether a request is queued
+func (s *scheduler) contains(id string) bool {
+	for _, queued := range s.queue {
+		if queued.request.ID.String() == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// next takes the request to run next off the queue, if a slot is free, and
+// counts it as running. It also returns the requests dropped for being too
+// close to their deadline.
+func (s *scheduler) next(now time.Time) (VerificationRequest, bool, []droppedRequest) {
+	dropped := s.dropLate(now)
+
+	if s.active >= s.maxActive {
+		return VerificationRequest{}, false, dropped
+	}
+
+	best := -1
+	for i := range s.queue {
+		if s.running[s.queue[i].request.Requester] >= s.maxPerRequester {
+			continue
+		}
+		if best < 0 || s.before(s.queue[i], s.queue[best], now) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return VerificationRequest{}, false, dropped
+	}
+
+	request := s.queue[best].request
+	s.queue = append(s.queue[:best], s.queue[best+1:]...)
+	s.active++
+	s.running[request.Requester]++
+
+	return request, true, dropped
+}
+
+// done frees the slot of a verification that has returned
+func (s *scheduler) done(request VerificationRequest) {
+	s.active--
+	if s.running[request.Requester]--; s.running[request.Requester] <= 0 {
+		delete(s.running, request.Requester)
+	}
+	s.signal()
+}
+
+// drain empties the queue and returns what was in it
+func (s *scheduler) drain() []VerificationRequest {
+	requests := make([]VerificationRequest, 0, len(s.queue))
+	for _, queued := range s.queue {
+		requests = append(requests, queued.request)
+	}
+	s.queue = nil
+
+	return requests
+}
+
+// nextDrop returns when the next queued request will be dropped, or the
+// zero time if none has a deadline
+func (s *scheduler) nextDrop() time.Time {
+	var earliest time.Time
+	for _, queued := range s.queue {
+		if queued.request.Deadline.IsZero() {
+			continue
+		}
+		drop := queued.request.Deadline.Add(-s.minTimeLeft)
+		if earliest.IsZero() || drop.Before(earliest) {
+			earliest = drop
+		}
+	}
+
+	return earliest
+}
+
+// dropLate removes the queued requests that cannot finish before their deadline
+func (s *scheduler) dropLate(now time.Time) []droppedRequest {
+	var dropped []droppedRequest
+
+	kept := s.queue[:0]
+	for _, queued := range s.queue {
+		deadline := queued.request.Deadline
+		if !deadline.IsZero() && deadline.Sub(now) < s.minTimeLeft {
+			reason := fmt.Sprintf("deadline %s leaves less than %s to verify", deadline.Format(time.RFC3339), s.minTimeLeft)
+			if !deadline.After(now) {
+				reason = fmt.Sprintf("deadline %s has passed", deadline.Format(time.RFC3339))
+			}
+			dropped = append(dropped, droppedRequest{request: queued.request, reason: reason})
+			continue
+		}
+		kept = append(kept, queued)
+	}
+	s.queue = kept
+
+	return dropped
+}
+
+// urgent reports whether a request is close enough to its deadline to go
+// before the others: within twice the time a verification needs
+func (s *scheduler) urgent(request VerificationRequest, now time.Time) bool {
+	return !request.Deadline.IsZero() && request.Deadline.Sub(now) < 2*s.minTimeLeft
+}
+
+// before reports whether a should run before b
+func (s *scheduler) before(a, b queuedRequest, now time.Time) bool {
+	// Urgent requests first, the closest deadline first
+	if urgentA, urgentB := s.urgent(a.request, now), s.urgent(b.request, now); urgentA != urgentB {
+		return urgentA
+	} else if urgentA && !a.request.Deadline.Equal(b.request.Deadline) {
+		return a.request.Deadline.Before(b.request.Deadline)
+	}
+
+	// Then the requester with the fewest verifications running
+	if runningA, runningB := s.running[a.request.Requester], s.running[b.request.Requester]; runningA != runningB {
+		return runningA < runningB
+	}
+
+	// Then the earliest deadline, with requests that have one first
+	deadlineA, deadlineB := a.request.Deadline, b.request.Deadline
+	if deadlineA.IsZero() != deadlineB.IsZero() {
+		return !deadlineA.IsZero()
+	}
+	if !deadlineA.Equal(deadlineB) {
+		return deadlineA.Before(deadlineB)
+	}
+
+	// Then the highest reward
+	if a.request.Reward != nil || b.request.Reward != nil {
+		if a.request.Reward == nil || b.request.Reward == nil {
+			return a.request.Reward != nil
+		}
+		if cmp := a.request.Reward.Cmp(b.request.Reward); cmp != 0 {
+			return cmp > 0
+		}
+	}
+
+	// Then the oldest
+	return a.queued.Before(b.queued)
+}
@@ -0,0 +1,219 @@
+package validator
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	requesterA = common.HexToAddress("0xa")
+	requesterB = common.HexToAddress("0xb")
+)
+
+func request(id int64, requester common.Address, deadline time.Time, reward int64) VerificationRequest {
+	r := VerificationRequest{ID: big.NewInt(id), Requester: requester, Deadline: deadline}
+	if reward > 0 {
+		r.Reward = big.NewInt(reward)
+	}
+	return r
+}
+
+func TestSchedulerBefore(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	soon := now.Add(90 * time.Second)
+	sooner := now.Add(70 * time.Second)
+	later := now.Add(time.Hour)
+	latest := now.Add(2 * time.Hour)
+
+	tests := []struct {
+		name    string
+		a, b    queuedRequest
+		running map[common.Address]int
+		want    bool
+	}{
+		{
+			name: "urgent goes before a busy requester's competitor",
+			a:    queuedRequest{request: request(1, requesterA, soon, 0)},
+			b:    queuedRequest{request: request(2, requesterB, later, 0)},
+			running: map[common.Address]int{
+				requesterA: 3,
+			},
+			want: true,
+		},
+		{
+			name: "closest deadline first among urgent requests",
+			a:    queuedRequest{request: request(1, requesterA, soon, 0)},
+			b:    queuedRequest{request: request(2, requesterA, sooner, 0)},
+			want: false,
+		},
+		{
+			name:    "requester with fewer running verifications first",
+			a:       queuedRequest{request: request(1, requesterA, later, 0)},
+			b:       queuedRequest{request: request(2, requesterB, latest, 0)},
+			running: map[common.Address]int{requesterA: 1},
+			want:    false,
+		},
+		{
+			name: "earlier deadline first",
+			a:    queuedRequest{request: request(1, requesterA, later, 0)},
+			b:    queuedRequest{request: request(2, requesterB, latest, 100)},
+			want: true,
+		},
+		{
+			name: "a deadline goes before none",
+			a:    queuedRequest{request: request(1, requesterA, time.Time{}, 100)},
+			b:    queuedRequest{request: request(2, requesterB, latest, 0)},
+			want: false,
+		},
+		{
+			name: "higher reward first",
+			a:    queuedRequest{request: request(1, requesterA, later, 100)},
+			b:    queuedRequest{request: request(2, requesterB, later, 200)},
+			want: false,
+		},
+		{
+			name: "a known reward goes before none",
+			a:    queuedRequest{request: request(1, requesterA, later, 100)},
+			b:    queuedRequest{request: request(2, requesterB, later, 0)},
+			want: true,
+		},
+		{
+			name: "oldest last",
+			a:    queuedRequest{request: request(1, requesterA, later, 100), queued: now},
+			b:    queuedRequest{request: request(2, requesterB, later, 100), queued: now.Add(-time.Second)},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(10, 10, time.Minute)
+			for requester, n := range tt.running {
+				s.running[requester] = n
+			}
+
+			if got := s.before(tt.a, tt.b, now); got != tt.want {
+				t.Errorf("before(a, b) = %v, want %v", got, tt.want)
+			}
+			if got := s.before(tt.b, tt.a, now); got == tt.want {
+				t.Errorf("before(b, a) = %v, want %v", got, !tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerNext(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name            string
+		maxActive       int
+		maxPerRequester int
+		queue           []VerificationRequest
+
+		// want is the IDs taken in order until next returns nothing
+		want        []int64
+		wantDropped []int64
+	}{
+		{
+			name:            "highest reward first",
+			maxActive:       10,
+			maxPerRequester: 10,
+			queue: []VerificationRequest{
+				request(1, requesterA, later, 100),
+				request(2, requesterA, later, 300),
+				request(3, requesterA, later, 200),
+			},
+			want: []int64{2, 3, 1},
+		},
+		{
+			name:            "requesters take turns",
+			maxActive:       10,
+			maxPerRequester: 10,
+			queue: []VerificationRequest{
+				request(1, requesterA, later, 300),
+				request(2, requesterA, later, 200),
+				request(3, requesterB, later, 100),
+			},
+			want: []int64{1, 3, 2},
+		},
+		{
+			name:            "stops at the active limit",
+			maxActive:       2,
+			maxPerRequester: 10,
+			queue: []VerificationRequest{
+				request(1, requesterA, later, 0),
+				request(2, requesterB, later, 0),
+				request(3, requesterB, later, 0),
+			},
+			want: []int64{1, 2},
+		},
+		{
+			name:            "skips requesters at their limit",
+			maxActive:       10,
+			maxPerRequester: 1,
+			queue: []VerificationRequest{
+				request(1, requesterA, later, 300),
+				request(2, requesterA, later, 200),
+				request(3, requesterB, later, 100),
+			},
+			want: []int64{1, 3},
+		},
+		{
+			name:            "drops requests too close to their deadline",
+			maxActive:       10,
+			maxPerRequester: 10,
+			queue: []VerificationRequest{
+				request(1, requesterA, now.Add(30*time.Second), 0),
+				request(2, requesterA, now.Add(-time.Second), 0),
+				request(3, requesterA, later, 0),
+			},
+			want:        []int64{3},
+			wantDropped: []int64{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(tt.maxActive, tt.maxPerRequester, time.Minute)
+			for i, r := range tt.queue {
+				s.push(r, now.Add(time.Duration(i)*time.Second))
+			}
+
+			var got, dropped []int64
+			for {
+				r, ok, late := s.next(now)
+				for _, d := range late {
+					dropped = append(dropped, d.request.ID.Int64())
+				}
+				if !ok {
+					break
+				}
+				got = append(got, r.ID.Int64())
+			}
+
+			if !equalIDs(got, tt.want) {
+				t.Errorf("ran %v, want %v", got, tt.want)
+			}
+			if !equalIDs(dropped, tt.wantDropped) {
+				t.Errorf("dropped %v, want %v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
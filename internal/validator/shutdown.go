@@ -0,0 +1,347 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/logging"
+)
+
+// PendingFileName is the file in the data directory holding the requests left
+// unfinished by the last shutdown
+const PendingFileName = "pending-requests.json"
+
+// Stages a verification request goes through
+const (
+	StageQueued     = "queued"
+	StageComputing  = "computing"
+	StageConsensus  = "consensus"
+	StageSubmitting = "submitting"
+)
+
+// PendingRequest is a verification request that has not finished, with the
+// stage it reached and, once submitted, its transaction
+type PendingRequest struct {
+	Request VerificationRequest `json:"request"`
+	Stage   string              `json:"stage"`
+	TxHash  string              `json:"txHash,omitempty"`
+}
+
+// ShutdownReport describes what a shutdown left undone
+type ShutdownReport struct {
+	// InFlight is the number of verifications being processed when shutdown began
+	InFlight int
+	// Finished is the number of those that ran to completion during the drain
+	Finished int
+	// TimedOut is set when the drain deadline passed
+	TimedOut bool
+	// Pending lists the requests saved for the next start
+	Pending     []PendingRequest
+	PendingFile string
+	// Err is set if the pending requests could not be saved
+	Err error
+}
+
+// String summarises the report for the operator
+func (r *ShutdownReport) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Finished %d of %d in-flight verification(s)", r.Finished, r.InFlight)
+	if r.TimedOut {
+		b.WriteString(", drain deadline passed")
+	}
+
+	if len(r.Pending) == 0 {
+		b.WriteString(", nothing left undone")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "\n%d request(s) left unfinished", len(r.Pending))
+	if r.Err != nil {
+		fmt.Fprintf(&b, ", NOT saved: %v", r.Err)
+	} else {
+		fmt.Fprintf(&b, ", saved to %s for the next start", r.PendingFile)
+	}
+	for _, pending := range r.Pending {
+		fmt.Fprintf(&b, "\n  request %s: %s", pending.Request.ID, pending.Stage)
+		if pending.TxHash != "" {
+			fmt.Fprintf(&b, " (tx %s, receipt not seen)", pending.TxHash)
+		}
+	}
+
+	return b.String()
+}
+
+// PendingPath returns the file holding the requests left by the last shutdown
+func PendingPath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, PendingFileName)
+}
+
+// Shutdown stops intake, waits up to timeout for in-flight verifications to
+// finish or reach a safe point, and saves everything left unfinished for the
+// next start. Verifications still running at the deadline are cancelled.
+func (v *Validator) Shutdown(timeout time.Duration) *ShutdownReport {
+	report := &ShutdownReport{}
+
+	// Stop intake and take over the queue
+	v.mutex.Lock()
+	if !v.running {
+		v.mutex.Unlock()
+		return report
+	}
+	v.running = false
+	v.stopIntake()
+	queued := v.scheduler.drain()
+	v.metrics.QueueDepth.Set(0)
+	report.InFlight = len(v.inFlightRequests)
+	v.mutex.Unlock()
+
+	logging.Logger().Info("Shutting down", "inFlight", report.InFlight, "queued", len(queued))
+
+	// Wait for in-flight requests, then cancel what is left and give it a
+	// moment to record where it stopped
+	if report.InFlight > 0 && !v.waitInFlight(timeout) {
+		report.TimedOut = true
+		v.stopWork()
+		v.waitInFlight(5 * time.Second)
+	}
+	v.stopWork()
+
+	// Collect what was set aside or is still running
+	v.mutex.Lock()
+	pending := append([]PendingRequest(nil), v.unfinished...)
+	for _, request := range v.inFlightRequests {
+		pending = append(pending, *request)
+	}
+	v.unfinished = nil
+	v.mutex.Unlock()
+
+	report.Finished = report.InFlight - len(pending)
+	for _, request := range queued {
+		pending = append(pending, PendingRequest{Request: request, Stage: StageQueued})
+	}
+	report.Pending = pending
+
+	if len(pending) > 0 {
+		report.PendingFile = PendingPath(v.config)
+		report.Err = savePending(report.PendingFile, pending)
+	}
+
+	if err := v.journal.Close(); err != nil {
+		logging.Logger().Warn("Failed to close request journal", "err", err)
+	}
+
+	return report
+}
+
+// waitInFlight waits up to timeout for in-flight verifications to return and
+// reports whether they all did
+func (v *Validator) waitInFlight(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		v.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// shuttingDown reports whether intake has stopped
+func (v *Validator) shuttingDown() bool {
+	select {
+	case <-v.intakeDone:
+		return true
+	default:
+		return false
+	}
+}
+
+// setStage records the stage an in-flight request has reached
+func (v *Validator) setStage(id string, stage string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if request, ok := v.inFlightRequests[id]; ok {
+		request.Stage = stage
+	}
+}
+
+// setTxHash records the transaction an in-flight request was submitted in
+func (v *Validator) setTxHash(id string, txHash string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if request, ok := v.inFlightRequests[id]; ok {
+		request.TxHash = txHash
+	}
+}
+
+// setAside moves an in-flight request to the unfinished list, to be saved by Shutdown
+func (v *Validator) setAside(id string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if request, ok := v.inFlightRequests[id]; ok {
+		logging.Logger().Info("Setting aside request for the next start", logging.KeyRequestID, id, "stage", request.Stage)
+		v.events.Publish(&events.RequestSetAside{Header: events.Header{RequestID: id, TxHash: request.TxHash}, Stage: request.Stage})
+		v.unfinished = append(v.unfinished, *request)
+		delete(v.inFlightRequests, id)
+	}
+}
+
+// untrack removes a request from the in-flight set once it has returned,
+// counting it as processed unless it was set aside
+func (v *Validator) untrack(id string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if _, ok := v.inFlightRequests[id]; ok {
+		delete(v.inFlightRequests, id)
+		v.events.Publish(&events.RequestFinished{Header: events.Header{RequestID: id}})
+	}
+}
+
+// restorePending returns the requests left by the last shutdown that are to
+// be queued again. Requests that were already submitted are only restored
+// once their transaction is known to be dropped or reverted; while it is
+// pending or cannot be looked up, they are kept in the pending file for the
+// next start. Requests settled since, according to the journal, are dropped.
+// It looks requests up on-chain, so it must not be called with the mutex held.
+func (v *Validator) restorePending(ctx context.Context) []VerificationRequest {
+	cfg := v.currentConfig()
+	path := PendingPath(cfg)
+	pending, err := loadPending(path)
+	if err != nil {
+		logging.Logger().Error("Could not restore unfinished requests", "err", err)
+		return nil
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var (
+		restored   []VerificationRequest
+		unresolved []PendingRequest
+	)
+	for _, request := range pending {
+		queued := withDeadline(request.Request, requestTTL(cfg))
+		logger := logging.Logger().With(logging.KeyRequestID, queued.ID.String())
+
+		if request.TxHash != "" {
+			logger = logger.With(logging.KeyTxHash, request.TxHash)
+			if record, ok := v.journal.Get(queued.ID.String()); ok && record.Settled() {
+				logger.Info("Request submitted before the last shutdown is settled", "status", record.Status)
+				continue
+			}
+
+			status, err := v.lookupTx(ctx, request.TxHash)
+			switch status {
+			case txMined:
+				logger.Info("Request submitted before the last shutdown was confirmed")
+				continue
+			case txPending:
+				logger.Info("Request submitted before the last shutdown is still pending, keeping it for the next start")
+				unresolved = append(unresolved, request)
+				continue
+			case "":
+				logger.Warn("Could not look up request submitted before the last shutdown, keeping it for the next start", "err", err)
+				unresolved = append(unresolved, request)
+				continue
+			}
+			logger.Info("Request submitted before the last shutdown was not mined, restoring it", "status", status)
+		} else if reason := v.handledBefore(ctx, queued); reason != "" {
+			logger.Info("Not restoring request", "reason", reason)
+			continue
+		}
+
+		if v.expire(queued, StageQueued, 0) {
+			continue
+		}
+		restored = append(restored, queued)
+	}
+
+	if len(unresolved) > 0 {
+		err = savePending(path, unresolved)
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		logging.Logger().Warn("Could not update pending requests file", "path", path, "err", err)
+	}
+
+	return restored
+}
+
+// requeue queues the requests restored from the last shutdown
+func (v *Validator) requeue(restored []VerificationRequest) {
+	var found []*events.RequestFound
+
+	v.mutex.Lock()
+	for _, request := range restored {
+		if v.queueLocked(request) {
+			found = append(found, foundEvent(request, true))
+		}
+	}
+	v.metrics.QueueDepth.Set(float64(v.scheduler.len()))
+	v.mutex.Unlock()
+
+	for _, event := range found {
+		v.events.Publish(event)
+	}
+	if len(restored) > 0 {
+		logging.Logger().Info("Restored unfinished requests from the last shutdown", "requeued", len(found))
+	}
+}
+
+// savePending writes the unfinished requests to path
+func savePending(path string, pending []PendingRequest) error {
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode unfinished requests: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write unfinished requests: %v", err)
+	}
+
+	return nil
+}
+
+// loadPending reads the unfinished requests saved by savePending; a missing
+// file means there are none
+func loadPending(path string) ([]PendingRequest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []PendingRequest
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,256 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestRestorePending(t *testing.T) {
+	queued := func(id int64) PendingRequest {
+		return PendingRequest{
+			Request: VerificationRequest{ID: big.NewInt(id), Block: 90, Deadline: time.Now().Add(time.Hour)},
+			Stage:   StageQueued,
+		}
+	}
+	submitted := func(id int64, tx *types.Transaction) PendingRequest {
+		pending := queued(id)
+		pending.Stage = StageSubmitting
+		pending.TxHash = tx.Hash().Hex()
+		return pending
+	}
+
+	tests := []struct {
+		name string
+		// setup prepares the chain and journal and returns the requests
+		// left by the last shutdown
+		setup func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest
+
+		wantRestored []string
+		// wantKept lists the requests left in the pending file
+		wantKept []string
+	}{
+		{
+			name: "queued requests are restored",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				return []PendingRequest{queued(1), queued(2)}
+			},
+			wantRestored: []string{"1", "2"},
+		},
+		{
+			name: "requests settled since are not restored",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				v.journal.Record(&events.RequestDropped{Header: events.Header{RequestID: "1"}, Reason: "too late"})
+				return []PendingRequest{queued(1)}
+			},
+		},
+		{
+			name: "expired requests are not restored",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				pending := queued(1)
+				pending.Request.Deadline = time.Now().Add(-time.Minute)
+				return []PendingRequest{pending}
+			},
+		},
+		{
+			name: "submitted and mined",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				chain.mine(tx.Hash(), types.ReceiptStatusSuccessful)
+				return []PendingRequest{submitted(1, tx)}
+			},
+		},
+		{
+			name: "submitted and still pending",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				chain.pending[tx.Hash()] = tx
+				return []PendingRequest{queued(1), submitted(2, tx)}
+			},
+			wantRestored: []string{"1"},
+			wantKept:     []string{"2"},
+		},
+		{
+			name: "submitted and dropped",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				return []PendingRequest{submitted(1, tx)}
+			},
+			wantRestored: []string{"1"},
+		},
+		{
+			name: "submitted and reverted",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				chain.mine(tx.Hash(), types.ReceiptStatusFailed)
+				return []PendingRequest{submitted(1, tx)}
+			},
+			wantRestored: []string{"1"},
+		},
+		{
+			name: "submitted and settled in the journal",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				v.journal.Record(&events.TxMined{Header: events.Header{RequestID: "1", TxHash: tx.Hash().Hex(), Block: 95}})
+				chain.failing["eth_getTransactionReceipt"] = true
+				return []PendingRequest{submitted(1, tx)}
+			},
+		},
+		{
+			name: "receipt cannot be looked up",
+			setup: func(v *Validator, chain *fakeChain, tx *types.Transaction) []PendingRequest {
+				chain.failing["eth_getTransactionReceipt"] = true
+				return []PendingRequest{submitted(1, tx)}
+			},
+			wantKept: []string{"1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, pool := newFakeChain(t)
+			v := newTestValidator(t, pool)
+			path := PendingPath(v.config)
+			if err := savePending(path, tt.setup(v, chain, signedTx(t, 0))); err != nil {
+				t.Fatal(err)
+			}
+
+			restored := v.restorePending(context.Background())
+			var got []string
+			for _, request := range restored {
+				got = append(got, request.ID.String())
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantRestored) {
+				t.Errorf("restored %v, want %v", got, tt.wantRestored)
+			}
+
+			v.requeue(restored)
+			for _, id := range tt.wantRestored {
+				if !v.scheduler.contains(id) {
+					t.Errorf("request %s was not queued", id)
+				}
+			}
+
+			kept, err := loadPending(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keptIDs []string
+			for _, pending := range kept {
+				keptIDs = append(keptIDs, pending.Request.ID.String())
+			}
+			if fmt.Sprint(keptIDs) != fmt.Sprint(tt.wantKept) {
+				t.Errorf("kept %v in the pending file, want %v", keptIDs, tt.wantKept)
+			}
+			if _, err := os.Stat(path); len(tt.wantKept) == 0 && !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("pending file was not removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	// finishes is an in-flight verification that returns on its own, and
+	// cancelled one that only stops when its work is cancelled, after it was
+	// submitted
+	finishes := func(v *Validator, workCtx context.Context, id string) {
+		time.Sleep(10 * time.Millisecond)
+		v.untrack(id)
+	}
+	cancelled := func(v *Validator, workCtx context.Context, id string) {
+		<-workCtx.Done()
+		v.setAside(id)
+	}
+
+	tests := []struct {
+		name     string
+		queued   int
+		inFlight []func(v *Validator, workCtx context.Context, id string)
+
+		wantFinished int
+		wantTimedOut bool
+		// wantPending lists the stages of the requests saved
+		wantPending []string
+	}{
+		{
+			name: "nothing to do",
+		},
+		{
+			name:        "queued requests are saved",
+			queued:      2,
+			wantPending: []string{StageQueued, StageQueued},
+		},
+		{
+			name:         "in-flight requests finish during the drain",
+			inFlight:     []func(v *Validator, workCtx context.Context, id string){finishes, finishes},
+			wantFinished: 2,
+		},
+		{
+			name:         "the drain deadline passes",
+			queued:       1,
+			inFlight:     []func(v *Validator, workCtx context.Context, id string){finishes, cancelled},
+			wantFinished: 1,
+			wantTimedOut: true,
+			wantPending:  []string{StageQueued, StageSubmitting},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, pool := newFakeChain(t)
+			v := newTestValidator(t, pool)
+
+			intakeCtx, stopIntake := context.WithCancel(context.Background())
+			workCtx, stopWork := context.WithCancel(context.Background())
+			v.running = true
+			v.stopIntake, v.stopWork, v.intakeDone = stopIntake, stopWork, intakeCtx.Done()
+
+			for i := 0; i < tt.queued; i++ {
+				v.scheduler.push(VerificationRequest{ID: big.NewInt(int64(100 + i)), Deadline: time.Now().Add(time.Hour)}, time.Now())
+			}
+			for i, run := range tt.inFlight {
+				id := fmt.Sprint(i + 1)
+				v.inFlightRequests[id] = &PendingRequest{
+					Request: VerificationRequest{ID: big.NewInt(int64(i + 1))},
+					Stage:   StageSubmitting,
+					TxHash:  "0x7a",
+				}
+				v.inFlight.Add(1)
+				go func(run func(v *Validator, workCtx context.Context, id string)) {
+					defer v.inFlight.Done()
+					run(v, workCtx, id)
+				}(run)
+			}
+
+			report := v.Shutdown(100 * time.Millisecond)
+
+			if report.InFlight != len(tt.inFlight) || report.Finished != tt.wantFinished || report.TimedOut != tt.wantTimedOut {
+				t.Errorf("finished %d of %d, timed out %v; want %d of %d, %v",
+					report.Finished, report.InFlight, report.TimedOut, tt.wantFinished, len(tt.inFlight), tt.wantTimedOut)
+			}
+
+			var stages []string
+			for _, pending := range report.Pending {
+				stages = append(stages, pending.Stage)
+				if pending.Stage == StageSubmitting && pending.TxHash != "0x7a" {
+					t.Errorf("submitted request saved without its transaction")
+				}
+			}
+			sort.Strings(stages)
+			if fmt.Sprint(stages) != fmt.Sprint(tt.wantPending) {
+				t.Errorf("saved requests at stages %v, want %v", stages, tt.wantPending)
+			}
+
+			saved, err := loadPending(PendingPath(v.config))
+			if err != nil || len(saved) != len(tt.wantPending) || report.Err != nil {
+				t.Errorf("pending file holds %d requests (%v, %v), want %d", len(saved), err, report.Err, len(tt.wantPending))
+			}
+			if v.Shutdown(time.Second).InFlight != 0 {
+				t.Error("a second shutdown did something")
+			}
+		})
+	}
+}
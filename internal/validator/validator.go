@@ -2,10 +2,8 @@ package validator
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"sync"
 	"time"
@@ -14,13 +12,20 @@ import (
 	"github.com/dexponent/geth-validator/internal/config"
 	"github.com/dexponent/geth-validator/internal/consensus"
 	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/dexponent/geth-validator/internal/events"
+	"github.com/dexponent/geth-validator/internal/gas"
+	"github.com/dexponent/geth-validator/internal/journal"
+	"github.com/dexponent/geth-validator/internal/logging"
+	"github.com/dexponent/geth-validator/internal/metrics"
 	"github.com/dexponent/geth-validator/internal/proof"
+	"github.com/dexponent/geth-validator/internal/rpcpool"
+	"github.com/dexponent/geth-validator/internal/signer"
+	"github.com/dexponent/geth-validator/internal/wallet"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 // DXPContract is an interface for the DXP smart contract
@@ -38,69 +43,120 @@ type VerificationRequest struct {
 	Requester common.Address
 	Data      []byte
 	Timestamp *big.Int
+	// Block, BlockHash and LogIndex locate the log the request was emitted in
+	Block     uint64
+	BlockHash common.Hash
+	LogIndex  uint
+	// Reward is the reward offered for the request in wei, and Deadline the
+	// time by which it must be answered; either is unset when unknown
+	Reward   *big.Int
+	Deadline time.Time
 }
 
+// computeTimeout bounds the wait for the compute engine
+const computeTimeout = 30 * time.Second
+
 // Validator represents a GETH-based validator node
 type Validator struct {
-	client          *ethclient.Client
+	rpc             *rpcpool.Pool
+	client          *rpcpool.Client
+	reads           *rpcpool.Client
 	contract        DXPContract
 	config          *config.Config
-	privateKey      *ecdsa.PrivateKey
+	signer          signer.Signer
 	address         common.Address
 	nodeID          string
 	running         bool
 	registered      bool
 	lastBlock       uint64
-	verificationQueue []VerificationRequest
-	consensusEngine  *consensus.Engine
-	computeEngine    *compute.Engine
-	proofGenerator   *proof.Generator
-	mutex            sync.Mutex
-	cancel          context.CancelFunc
+	scheduler       *scheduler
+	consensusEngine *consensus.Engine
+	computeEngine   *compute.Engine
+	proofGenerator  *proof.Generator
+	token           *contracts.DXPToken
+	metrics         *metrics.Metrics
+	events          *events.Bus
+	activity        *activityLog
+	journal         *journal.Journal
+	intakePaused    bool
+	mutex           sync.Mutex
+	// stopIntake stops reading blocks and dequeuing requests; stopWork
+	// cancels verifications still in flight when the drain deadline passes
+	stopIntake context.CancelFunc
+	stopWork   context.CancelFunc
+	intakeDone <-chan struct{}
+	// inFlight tracks verifications being processed, so shutdown can wait for them
+	inFlight         sync.WaitGroup
+	inFlightRequests map[string]*PendingRequest
+	unfinished       []PendingRequest
+	// seen holds the logs requests were taken in from, with their block, so
+	// intake is idempotent
+	seen map[requestKey]uint64
 }
 
 // ValidatorStatus represents the status of the validator node
 type ValidatorStatus struct {
-	Running              bool
-	NodeID               string
-	Account              string
-	Balance              float64
-	Registered           bool
-	LastBlockProcessed   uint64
-	VerificationQueueSize int
-	ConsensusParticipants int
+	Running bool   `json:"running"`
+	NodeID  string `json:"nodeId"`
+	Account string `json:"account"`
+	// Balance is the account's balance in ETH, left out when it could not
+	// be read, in which case BalanceError says why
+	Balance               *float64 `json:"balance,omitempty"`
+	BalanceError          string   `json:"balanceError,omitempty"`
+	Registered            bool     `json:"registered"`
+	LastBlockProcessed    uint64   `json:"lastBlockProcessed"`
+	VerificationQueueSize int      `json:"verificationQueueSize"`
+	ConsensusParticipants int      `json:"consensusParticipants"`
+	IntakePaused          bool     `json:"intakePaused"`
+	// RPCEndpoints is the health of each RPC endpoint
+	RPCEndpoints []rpcpool.EndpointStatus `json:"rpcEndpoints,omitempty"`
 }
 
 // NewValidator creates a new validator instance
 func NewValidator(cfg *config.Config) (*Validator, error) {
-	// Connect to Ethereum client
-	client, err := ethclient.Dial(cfg.BaseRPCURL)
+	// Connect to the RPC endpoints, refusing any serving a different chain
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool, err := rpcpool.Dial(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to the Ethereum client: %v", err)
+		return nil, err
 	}
+	// Calls fail over between the endpoints; the reads the node must trust,
+	// such as registration, request headers and receipts, also need
+	// RPC_QUORUM endpoints to agree
+	client := pool.Client()
 
-	// Parse private key
-	privateKey, err := crypto.HexToECDSA(cfg.WalletPrivateKey)
+	// Create the signer, either in-process or an external signer
+	txSigner, err := signer.New(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
+		return nil, err
 	}
 
-	// Get public address
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
+	address := txSigner.Address()
 
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
+	// The operator key must not control the rewards
+	if cfg.RewardAddress == "" {
+		logging.Logger().Info("No REWARD_ADDRESS configured, rewards are claimed with the operator key", "operator", address.Hex())
+	} else if common.HexToAddress(cfg.RewardAddress) == address {
+		return nil, fmt.Errorf("REWARD_ADDRESS must be a different account from the operator key %s", address.Hex())
+	}
 
 	// Create contract instance
 	contractAddress := common.HexToAddress(cfg.DXPContractAddress)
-	contract, err := contracts.NewDexponentContractWrapper(contractAddress, client)
+	contract, err := contracts.NewDexponentContractWrapper(contractAddress, pool.QuorumClient())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create contract instance: %v", err)
 	}
 
+	// The DXP token is only read for the stake metric, so it is optional
+	var token *contracts.DXPToken
+	if cfg.DXPTokenAddress != "" {
+		token, err = contracts.NewDXPToken(common.HexToAddress(cfg.DXPTokenAddress), client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DXP token instance: %v", err)
+		}
+	}
+
 	// Generate a unique node ID
 	nodeID := hexutil.Encode(crypto.Keccak256([]byte(address.Hex() + time.Now().String())))[2:10]
 
@@ -113,22 +169,44 @@ func NewValidator(cfg *config.Config) (*Validator, error) {
 	// Create proof generator
 	proofGenerator := proof.NewGenerator()
 
-	return &Validator{
-		client:          client,
-		contract:        contract,
-		config:          cfg,
-		privateKey:      privateKey,
-		address:         address,
-		nodeID:          nodeID,
-		running:         false,
-		registered:      false,
-		lastBlock:       0,
-		verificationQueue: make([]VerificationRequest, 0),
+	// Open the journal recording every request's lifecycle
+	requestJournal, err := journal.Open(journal.Path(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Validator{
+		rpc:              pool,
+		client:           client,
+		reads:            pool.QuorumClient(),
+		contract:         contract,
+		config:           cfg,
+		signer:           txSigner,
+		address:          address,
+		nodeID:           nodeID,
+		running:          false,
+		registered:       false,
+		lastBlock:        0,
+		scheduler:        newScheduler(int(cfg.SchedulerMaxConcurrent), int(cfg.SchedulerMaxPerRequester), time.Duration(cfg.SchedulerMinTimeLeftSeconds)*time.Second),
+		inFlightRequests: make(map[string]*PendingRequest),
+		seen:             make(map[requestKey]uint64),
 		consensusEngine:  consensusEngine,
 		computeEngine:    computeEngine,
 		proofGenerator:   proofGenerator,
+		token:            token,
+		metrics:          metrics.New(),
+		events:           events.NewBus(),
+		activity:         newActivityLog(),
+		journal:          requestJournal,
 		mutex:            sync.Mutex{},
-	}, nil
+	}
+
+	// The metrics, activity log and journal follow the node through its events
+	v.events.Handle(v.metrics.Observe)
+	v.events.Handle(v.activity.record)
+	v.events.Handle(v.journal.Record)
+
+	return v, nil
 }
 
 // IsRegistered checks if the validator is registered with the DXP contract
@@ -142,106 +220,104 @@ func (v *Validator) IsRegistered() (bool, error) {
 		return false, fmt.Errorf("failed to check registration status: %v", err)
 	}
 
-	v.registered = isRegistered
+	v.setRegistered(isRegistered)
 	return isRegistered, nil
 }
 
+// setRegistered records whether the validator is registered
+func (v *Validator) setRegistered(registered bool) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.registered = registered
+}
+
 // RegisterValidator registers the validator with the DXP contract
 func (v *Validator) RegisterValidator() (string, error) {
+	logger := logging.Logger()
+
 	// Log start of registration process
-	log.Printf("Starting validator registration process with DXP contract: %s", v.config.DXPContractAddress)
-	log.Printf("Validator address: %s", v.address.Hex())
-	
+	logger.Info("Starting validator registration", "contract", v.config.DXPContractAddress, "address", v.address.Hex())
+
 	// Check connection to blockchain
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	
+
 	blockNumber, err := v.client.BlockNumber(ctx)
 	if err != nil {
-		log.Printf("Failed to connect to blockchain: %v", err)
+		logger.Error("Failed to connect to blockchain", "err", err)
 		return "", fmt.Errorf("failed to connect to blockchain: %v", err)
 	}
-	log.Printf("Connected to blockchain, current block: %d", blockNumber)
-	
+	logger.Info("Connected to blockchain", logging.KeyBlock, blockNumber)
+
 	// Check wallet balance
 	balance, err := v.client.BalanceAt(ctx, v.address, nil)
 	if err != nil {
-		log.Printf("Failed to get wallet balance: %v", err)
+		logger.Error("Failed to get wallet balance", "err", err)
 		return "", fmt.Errorf("failed to get wallet balance: %v", err)
 	}
-	
+
 	// Convert wei to ether for logging
 	ether := new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(1e18))
-	log.Printf("Wallet balance: %s ETH", ether.Text('f', 6))
-	
+	logger.Info("Wallet balance", "eth", ether.Text('f', 6))
+
 	// Check if balance is sufficient for gas
 	if balance.Cmp(big.NewInt(1000000000000000)) < 0 { // 0.001 ETH minimum
-		log.Printf("WARNING: Wallet balance may be too low for transaction fees")
-	}
-
-	// Create transaction options
-	chainID := big.NewInt(v.config.ChainID)
-	auth, err := bind.NewKeyedTransactorWithChainID(v.privateKey, chainID)
-	if err != nil {
-		log.Printf("Failed to create transaction options: %v", err)
-		return "", fmt.Errorf("failed to create transaction options: %v", err)
+		logger.Warn("Wallet balance may be too low for transaction fees")
 	}
 
-	// Set gas price and limit
-	gasPrice, err := v.client.SuggestGasPrice(context.Background())
-	if err != nil {
-		log.Printf("Failed to suggest gas price: %v", err)
-		return "", fmt.Errorf("failed to suggest gas price: %v", err)
-	}
-
-	// Apply gas price multiplier
-	multiplier := big.NewFloat(v.config.GasPriceMultiplier)
-	adjustedGasPrice := new(big.Float).Mul(new(big.Float).SetInt(gasPrice), multiplier)
-	adjustedGasPriceInt, _ := adjustedGasPrice.Int(nil)
-
-	auth.GasPrice = adjustedGasPriceInt
-	auth.GasLimit = v.config.GasLimit
-	
-	log.Printf("Preparing transaction with gas price: %s wei, gas limit: %d", adjustedGasPriceInt.String(), v.config.GasLimit)
-
 	// Register validator
-	log.Printf("Sending registerVerifier transaction to blockchain...")
-	tx, err := v.contract.RegisterValidator(auth)
+	logger.Info("Sending registerVerifier transaction")
+	tx, err := v.transact(ctx, v.contract.RegisterValidator)
 	if err != nil {
-		log.Printf("Failed to register validator: %v", err)
+		logger.Error("Failed to register validator", "err", err)
 		return "", fmt.Errorf("failed to register validator: %v", err)
 	}
 
 	// Get transaction hash
 	txHash := tx.Hash().Hex()
-	log.Printf("Transaction sent successfully! Hash: %s", txHash)
-	
+	logger = logger.With(logging.KeyTxHash, txHash)
+	logger.Info("Transaction sent, waiting for confirmation")
+	v.events.Publish(&events.TxSent{Header: events.Header{TxHash: txHash}, Nonce: tx.Nonce(), GasLimit: tx.Gas()})
+
 	// Wait for transaction receipt with timeout
-	log.Printf("Waiting for transaction confirmation...")
 	ctxReceipt, cancelReceipt := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancelReceipt()
-	
-	receipt, err := bind.WaitMined(ctxReceipt, v.client, tx)
+
+	receipt, err := bind.WaitMined(ctxReceipt, v.reads, tx)
 	if err != nil {
-		log.Printf("Failed to get transaction receipt: %v", err)
-		log.Printf("Transaction may still be pending or dropped. Check the transaction hash: %s", txHash)
+		logger.Warn("Failed to get transaction receipt, the transaction may still be pending or dropped", "err", err)
 		return txHash, nil // Return hash even if we couldn't get receipt
 	}
-	
+
 	// Check transaction status
 	if receipt.Status == types.ReceiptStatusSuccessful {
-		log.Printf("Transaction confirmed successfully in block %d", receipt.BlockNumber)
-		v.registered = true
+		logger.Info("Registration confirmed", logging.KeyBlock, receipt.BlockNumber.Uint64(), "gasUsed", receipt.GasUsed)
+		v.events.Publish(&events.TxMined{Header: receiptHeader("", receipt), GasUsed: receipt.GasUsed, Fee: receiptFee(receipt)})
+		v.setRegistered(true)
 	} else {
-		log.Printf("Transaction failed on-chain. Check block explorer for details: %s", txHash)
-		return txHash, fmt.Errorf("transaction failed on-chain")
+		reason := v.failureReason(tx, receipt)
+		v.events.Publish(&events.TxReverted{Header: receiptHeader("", receipt), Reason: reason, GasUsed: receipt.GasUsed, Fee: receiptFee(receipt)})
+		logger.Error("Registration failed on-chain", logging.KeyBlock, receipt.BlockNumber.Uint64(), "reason", reason)
+		return txHash, fmt.Errorf("transaction failed on-chain: %s", reason)
 	}
 
 	return txHash, nil
 }
 
-// Start starts the validator node
+// Start starts the validator node, first re-queueing the requests left
+// unfinished by the previous shutdown
 func (v *Validator) Start(ctx context.Context, blockPollingInterval int) error {
+	v.mutex.Lock()
+	running := v.running
+	v.mutex.Unlock()
+	if running {
+		return errors.New("validator is already running")
+	}
+
+	// Restoring looks requests up on-chain, so it is done before taking the lock
+	v.requeue(v.restorePending(ctx))
+
 	v.mutex.Lock()
 	defer v.mutex.Unlock()
 
@@ -249,35 +325,62 @@ func (v *Validator) Start(ctx context.Context, blockPollingInterval int) error {
 		return errors.New("validator is already running")
 	}
 
-	// Create a cancellable context
-	ctx, cancel := context.WithCancel(ctx)
-	v.cancel = cancel
+	// Intake and in-flight work are cancelled separately so shutdown can
+	// stop taking requests while letting submissions finish
+	intakeCtx, stopIntake := context.WithCancel(ctx)
+	workCtx, stopWork := context.WithCancel(ctx)
+	v.stopIntake = stopIntake
+	v.stopWork = stopWork
+	v.intakeDone = intakeCtx.Done()
+
+	// Keep checking the RPC endpoints, to fail over from unhealthy ones
+	go v.rpc.Monitor(intakeCtx, time.Duration(v.config.RPCHealthCheckSeconds)*time.Second)
 
 	// Start block processing
-	go v.processBlocks(ctx, blockPollingInterval)
+	go v.processBlocks(intakeCtx, blockPollingInterval)
 
 	// Start verification processing
-	go v.processVerifications(ctx)
+	go v.processVerifications(intakeCtx, workCtx)
+
+	// Keep an eye on the operator account's balances and registration
+	go v.monitorAccount(intakeCtx)
 
 	v.running = true
 	return nil
 }
 
-// Stop stops the validator node
-func (v *Validator) Stop() {
+// ReloadConfig applies the settings from a reloaded configuration that are
+// safe to change while the validator is running
+func (v *Validator) ReloadConfig(next *config.Config) {
 	v.mutex.Lock()
-	defer v.mutex.Unlock()
+	v.config = v.config.WithReloadable(next)
+	cfg := v.config
+	v.mutex.Unlock()
+
+	logging.SetLevel(cfg.LogLevel)
+
+	logging.Logger().Info("Reloaded configuration",
+		"gasPriceMultiplier", cfg.GasPriceMultiplier,
+		"gasLimit", cfg.GasLimit,
+		"gasLimitMargin", cfg.GasLimitMargin,
+		"maxFeeGwei", cfg.MaxFeePerGasGwei,
+		"maxPriorityFeeGwei", cfg.MaxPriorityFeePerGasGwei,
+		"feeCeilingGwei", cfg.GasFeeCeilingGwei,
+		"logLevel", cfg.LogLevel)
+}
 
-	if !v.running {
-		return
-	}
+// currentConfig returns the configuration in effect, which may be replaced by ReloadConfig
+func (v *Validator) currentConfig() *config.Config {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
 
-	// Cancel context to stop all goroutines
-	if v.cancel != nil {
-		v.cancel()
-	}
+	return v.config
+}
 
-	v.running = false
+// Stop stops the validator node without waiting; verifications in flight
+// are persisted for the next start. Use Shutdown to let them finish.
+func (v *Validator) Stop() {
+	v.Shutdown(0)
 }
 
 // processBlocks continuously processes new blocks
@@ -293,187 +396,478 @@ func (v *Validator) processBlocks(ctx context.Context, blockPollingInterval int)
 			// Get latest block number
 			latestBlock, err := v.client.BlockNumber(ctx)
 			if err != nil {
-				log.Printf("Error getting latest block: %v", err)
+				logging.Logger().Warn("Failed to get latest block", "err", err)
 				continue
 			}
 
 			// Process new blocks
-			if v.lastBlock == 0 {
-				v.lastBlock = latestBlock
+			if v.lastProcessedBlock() == 0 {
+				v.setLastBlock(latestBlock)
 				continue
 			}
 
-			for blockNum := v.lastBlock + 1; blockNum <= latestBlock; blockNum++ {
-				if err := v.processBlock(ctx, blockNum); err != nil {
-					log.Printf("Error processing block %d: %v", blockNum, err)
-					continue
-				}
-				v.lastBlock = blockNum
-			}
+			v.events.Publish(&events.HeadChecked{Head: latestBlock, LastProcessed: v.lastProcessedBlock()})
+			v.catchUp(ctx, latestBlock)
 		}
 	}
 }
 
-// processBlock processes a single block
-func (v *Validator) processBlock(ctx context.Context, blockNum uint64) error {
+// catchUp processes the blocks after the last one processed up to head, in
+// order. It stops at the first block that fails, so that block is retried on
+// the next tick instead of being skipped.
+func (v *Validator) catchUp(ctx context.Context, head uint64) {
+	for blockNum := v.lastProcessedBlock() + 1; blockNum <= head; blockNum++ {
+		found, err := v.processBlock(ctx, blockNum)
+		if err != nil {
+			logging.Logger().Error("Failed to process block", logging.KeyBlock, blockNum, "err", err)
+			return
+		}
+		v.setLastBlock(blockNum)
+		v.events.Publish(&events.BlockProcessed{Header: events.Header{Block: blockNum}, Head: head, Requests: found})
+	}
+}
+
+// lastProcessedBlock returns the last block processed, read by the admin API
+func (v *Validator) lastProcessedBlock() uint64 {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	return v.lastBlock
+}
+
+// setLastBlock records the last block processed and forgets the logs too
+// old to be seen again
+func (v *Validator) setLastBlock(blockNum uint64) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.lastBlock = blockNum
+	v.pruneSeenLocked(blockNum)
+}
+
+// processBlock processes a single block and returns the number of requests found
+func (v *Validator) processBlock(ctx context.Context, blockNum uint64) (int, error) {
 	// In a real implementation, we would filter events from the DXP contract
 	// For this example, we'll simulate finding verification requests
-	
+
 	// Simulate finding a verification request every 10 blocks
 	if blockNum%10 == 0 {
+		header, err := v.reads.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNum))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get block header: %v", err)
+		}
+
 		// Create a simulated verification request
 		request := VerificationRequest{
 			ID:        big.NewInt(int64(blockNum)),
 			Requester: common.HexToAddress("0x1234567890123456789012345678901234567890"),
 			Data:      []byte(fmt.Sprintf("verification_data_%d", blockNum)),
-			Timestamp: big.NewInt(time.Now().Unix()),
+			Timestamp: new(big.Int).SetUint64(header.Time),
+			Block:     blockNum,
+			BlockHash: header.Hash(),
+			LogIndex:  0,
 		}
 
-		// Add to verification queue
-		v.mutex.Lock()
-		v.verificationQueue = append(v.verificationQueue, request)
-		v.mutex.Unlock()
-
-		log.Printf("Found verification request: %s", request.ID.String())
+		// Add to verification queue, unless it was taken in before
+		if v.enqueue(ctx, request) {
+			return 1, nil
+		}
 	}
 
-	return nil
+	return 0, nil
 }
 
-// processVerifications processes verification requests in the queue
-func (v *Validator) processVerifications(ctx context.Context) {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+// processVerifications starts queued verification requests as the scheduler
+// allows until intake stops; the verifications themselves run under workCtx.
+// It wakes whenever a request is queued, a verification returns or intake is
+// resumed, and when the next queued request is due to be dropped.
+func (v *Validator) processVerifications(intakeCtx, workCtx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
 
 	for {
+		nextDrop := v.dispatch(workCtx)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !nextDrop.IsZero() {
+			timer.Reset(time.Until(nextDrop))
+		}
+
 		select {
-		case <-ctx.Done():
+		case <-intakeCtx.Done():
 			return
-		case <-ticker.C:
-			v.mutex.Lock()
-			if len(v.verificationQueue) > 0 {
-				// Get the next verification request
-				request := v.verificationQueue[0]
-				v.verificationQueue = v.verificationQueue[1:]
-				v.mutex.Unlock()
-
-				// Process the verification request
-				go v.verifyRequest(ctx, request)
-			} else {
-				v.mutex.Unlock()
-			}
+		case <-v.scheduler.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch drops the queued requests that can no longer finish in time and
+// starts as many of the others as the scheduler allows. It returns when the
+// next queued request is due to be dropped.
+func (v *Validator) dispatch(workCtx context.Context) time.Time {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	now := time.Now()
+	for {
+		var (
+			request VerificationRequest
+			ok      bool
+			dropped []droppedRequest
+		)
+		if v.intakePaused {
+			dropped = v.scheduler.dropLate(now)
+		} else {
+			request, ok, dropped = v.scheduler.next(now)
+		}
+
+		for _, drop := range dropped {
+			logging.Logger().Warn("Dropping verification request", logging.KeyRequestID, drop.request.ID.String(), "reason", drop.reason)
+			v.events.Publish(&events.RequestDropped{Header: events.Header{RequestID: drop.request.ID.String()}, Reason: drop.reason})
 		}
+		v.metrics.QueueDepth.Set(float64(v.scheduler.len()))
+
+		if !ok {
+			return v.scheduler.nextDrop()
+		}
+
+		v.inFlightRequests[request.ID.String()] = &PendingRequest{Request: request, Stage: StageQueued}
+		v.inFlight.Add(1)
+
+		// Process the verification request
+		go func() {
+			defer v.inFlight.Done()
+			defer v.release(request)
+			v.verifyRequest(workCtx, request)
+		}()
 	}
 }
 
-// verifyRequest processes a single verification request
+// release frees the scheduler slot of a verification that has returned
+func (v *Validator) release(request VerificationRequest) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.scheduler.done(request)
+}
+
+// verifyRequest processes a single verification request. Between stages it
+// checks for shutdown, so a request that has not been submitted yet is set
+// aside for the next start instead of holding up the drain.
 func (v *Validator) verifyRequest(ctx context.Context, request VerificationRequest) {
-	log.Printf("Processing verification request: %s", request.ID.String())
+	id := request.ID.String()
+	defer v.untrack(id)
 
-	// 1. Submit the verification task to the compute engine
-	taskID := v.computeEngine.SubmitTask(request.ID.String(), request.Data)
+	// Everything logged about this request, down to the transaction, carries its ID
+	logger := logging.Logger().With(logging.KeyRequestID, id)
+	ctx = logging.NewContext(ctx, logger)
 
-	// 2. Wait for the computation to complete
-	result, err := v.computeEngine.WaitForResult(taskID, 30*time.Second)
+	logger.Info("Processing verification request")
+	header := events.Header{RequestID: id}
+	v.events.Publish(&events.RequestStarted{Header: header})
+
+	// 1. Submit the verification task to the compute engine
+	v.setStage(id, StageComputing)
+	taskID := v.computeEngine.SubmitTask(id, request.Data)
+
+	// 2. Wait for the computation to complete, abandoning it at the deadline
+	computeStart := time.Now()
+	timeout := computeTimeout
+	if !request.Deadline.IsZero() && time.Until(request.Deadline) < timeout {
+		timeout = time.Until(request.Deadline)
+	}
+	result, err := v.computeEngine.WaitForResult(taskID, timeout)
 	if err != nil {
-		log.Printf("Error computing result: %v", err)
+		if v.expire(request, StageComputing, 0) {
+			v.computeEngine.CancelTask(taskID)
+			return
+		}
+		logger.Error("Computation failed", "err", err)
+		v.events.Publish(&events.ComputeFailed{Header: header, Err: err})
+		return
+	}
+	v.events.Publish(&events.ComputeDone{Header: header, TaskID: taskID, Result: result, Duration: time.Since(computeStart)})
+	if v.shuttingDown() {
+		v.setAside(id)
 		return
 	}
 
-	// 3. Submit the result to the consensus engine
-	v.consensusEngine.SubmitResult(request.ID.String(), v.nodeID, result)
+	// 3. Submit the result to the consensus engine, unless the deadline passed
+	if v.expire(request, StageConsensus, 0) {
+		return
+	}
+	v.setStage(id, StageConsensus)
+	v.consensusEngine.SubmitResult(id, v.nodeID, result)
 
-	// 4. Wait for consensus
-	consensusReached, consensusResult := v.consensusEngine.CheckConsensus(request.ID.String())
+	// 4. Check consensus among the results submitted so far
+	consensusReached, consensusResult := v.consensusEngine.CheckConsensus(id)
+	v.events.Publish(&events.ConsensusChecked{Header: header, Reached: consensusReached, Result: consensusResult, Votes: v.consensusEngine.Votes(id)})
 	if !consensusReached {
-		log.Printf("Consensus not reached for request: %s", request.ID.String())
+		logger.Warn("Consensus not reached")
+		return
+	}
+	// A result that cannot be submitted in time is not worth proving
+	if v.expire(request, StageConsensus, v.submitMargin()) {
 		return
 	}
 
 	// 5. Generate proof for the consensus result
-	proof, err := v.proofGenerator.GenerateProof(request.ID.String(), consensusResult)
+	proof, err := v.proofGenerator.GenerateProof(id, consensusResult)
 	if err != nil {
-		log.Printf("Error generating proof: %v", err)
+		logger.Error("Failed to generate proof", "err", err)
+		v.events.Publish(&events.ProofFailed{Header: header, Err: err})
+		return
+	}
+	v.events.Publish(&events.ProofGenerated{Header: header, Proof: proof})
+	if v.shuttingDown() {
+		v.setAside(id)
 		return
 	}
 
-	// 6. Submit the result and proof to the smart contract
-	if err := v.submitResult(request.ID, consensusResult, proof); err != nil {
-		log.Printf("Error submitting result: %v", err)
+	// 6. Submit the result and proof to the smart contract, unless a result
+	// was already submitted meanwhile. Once
+	// submitting, the request is finished even during shutdown unless the
+	// drain deadline passes.
+	if reason := v.handledBefore(ctx, request); reason != "" {
+		logger.Warn("Not submitting verification result", "reason", reason)
+		return
+	}
+	// A result mined after the deadline would revert as late
+	if v.expire(request, StageSubmitting, v.submitMargin()) {
+		return
+	}
+	v.setStage(id, StageSubmitting)
+	if err := v.submitResult(ctx, request, consensusResult, proof); err != nil {
+		if errors.Is(err, errExpired) {
+			return
+		}
+		if ctx.Err() != nil {
+			v.setAside(id)
+			return
+		}
+		logger.Error("Failed to submit result", "err", err)
 		return
 	}
 
-	log.Printf("Successfully processed verification request: %s", request.ID.String())
+	logger.Info("Verification request processed")
 }
 
-// submitResult submits the verification result and proof to the smart contract
-func (v *Validator) submitResult(requestID *big.Int, result []byte, proof []byte) error {
-	// Create transaction options
-	chainID := big.NewInt(v.config.ChainID)
-	auth, err := bind.NewKeyedTransactorWithChainID(v.privateKey, chainID)
+// submitResult submits the verification result and proof to the smart
+// contract. A failure is only published while parent is live; once parent is
+// cancelled the request is set aside instead. The transaction must be sent
+// before the submit margin ahead of the request's deadline, or the request
+// expires and errExpired is returned.
+func (v *Validator) submitResult(parent context.Context, request VerificationRequest, result []byte, proof []byte) error {
+	ctx, cancel := context.WithTimeout(parent, 90*time.Second)
+	defer cancel()
+
+	requestID := request.ID
+	id := requestID.String()
+	failed := func(txHash string, err error) error {
+		if parent.Err() == nil {
+			v.events.Publish(&events.TxFailed{Header: events.Header{RequestID: id, TxHash: txHash}, Err: err})
+		}
+		return err
+	}
+
+	// Submit result and proof
+	sendCtx, cancelSend := deadlineContext(ctx, request, v.submitMargin())
+	tx, err := v.transact(sendCtx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return v.contract.SubmitVerificationResult(auth, requestID, result, proof)
+	})
+	cancelSend()
 	if err != nil {
-		return fmt.Errorf("failed to create transaction options: %v", err)
+		if parent.Err() == nil && v.expire(request, StageSubmitting, v.submitMargin()) {
+			return errExpired
+		}
+		return failed("", fmt.Errorf("failed to submit verification result: %v", err))
 	}
 
-	// Set gas price and limit
-	gasPrice, err := v.client.SuggestGasPrice(context.Background())
+	txHash := tx.Hash().Hex()
+	logger := logging.FromContext(ctx).With(logging.KeyTxHash, txHash)
+	logger.Info("Submitted verification result")
+	v.setTxHash(id, txHash)
+	v.events.Publish(&events.TxSent{Header: events.Header{RequestID: id, TxHash: txHash}, Nonce: tx.Nonce(), GasLimit: tx.Gas()})
+
+	receipt, err := bind.WaitMined(ctx, v.reads, tx)
 	if err != nil {
-		return fmt.Errorf("failed to suggest gas price: %v", err)
+		return failed(txHash, fmt.Errorf("failed to get receipt for tx %s: %v", txHash, err))
 	}
 
-	// Apply gas price multiplier
-	multiplier := big.NewFloat(v.config.GasPriceMultiplier)
-	adjustedGasPrice := new(big.Float).Mul(new(big.Float).SetInt(gasPrice), multiplier)
-	adjustedGasPriceInt, _ := adjustedGasPrice.Int(nil)
+	block := receipt.BlockNumber.Uint64()
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		reason := v.failureReason(tx, receipt)
+		v.events.Publish(&events.TxReverted{Header: receiptHeader(id, receipt), Reason: reason, GasUsed: receipt.GasUsed, Fee: receiptFee(receipt)})
+		return fmt.Errorf("verification result tx %s reverted in block %d: %s", txHash, block, reason)
+	}
 
-	auth.GasPrice = adjustedGasPriceInt
-	auth.GasLimit = v.config.GasLimit
+	logger.Info("Verification result confirmed", logging.KeyBlock, block, "gasUsed", receipt.GasUsed)
+	v.events.Publish(&events.TxMined{Header: receiptHeader(id, receipt), GasUsed: receipt.GasUsed, Fee: receiptFee(receipt)})
+	return nil
+}
 
-	// Submit result and proof
-	tx, err := v.contract.SubmitVerificationResult(auth, requestID, result, proof)
+// receiptHeader returns the event header for a mined transaction
+func receiptHeader(requestID string, receipt *types.Receipt) events.Header {
+	return events.Header{RequestID: requestID, TxHash: receipt.TxHash.Hex(), Block: receipt.BlockNumber.Uint64()}
+}
+
+// receiptFee returns the fee paid for a mined transaction, or nil when the
+// node did not report the effective gas price
+func receiptFee(receipt *types.Receipt) *big.Int {
+	if receipt.EffectiveGasPrice == nil {
+		return nil
+	}
+
+	return new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+}
+
+// failureReason replays a failed transaction to find out why it reverted
+func (v *Validator) failureReason(tx *types.Transaction, receipt *types.Receipt) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reason, err := contracts.FailureReason(ctx, v.client, tx, receipt)
 	if err != nil {
-		return fmt.Errorf("failed to submit verification result: %v", err)
+		return fmt.Sprintf("unknown reason (%v)", err)
 	}
 
-	log.Printf("Submitted verification result, tx: %s", tx.Hash().Hex())
-	return nil
+	return reason
+}
+
+// transact builds a transaction without sending it, checks it with a pre-flight
+// simulation, sizes its gas limit from an estimate and then broadcasts it
+func (v *Validator) transact(ctx context.Context, build func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	return sendTransaction(ctx, v.client, v.currentConfig(), v.signer, build)
 }
 
-// GetValidatorStatus returns the status of a validator node
+// sendTransaction prices, builds, simulates and sends a transaction signed
+// through s
+func sendTransaction(ctx context.Context, client *rpcpool.Client, cfg *config.Config, s signer.Signer, build func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	// Create transaction options that sign through the configured signer
+	auth := signer.TransactOpts(ctx, s, big.NewInt(cfg.ChainID))
+
+	// Set fees
+	fees, err := gas.Suggest(ctx, client, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price transaction: %v", err)
+	}
+	fees.Apply(auth)
+
+	// Build unsigned with a placeholder gas limit so the binding does not
+	// estimate on its own; gas.Prepare replaces it once the simulation has
+	// passed and only then signs the transaction
+	auth.GasLimit = cfg.GasLimit
+
+	tx, err := build(gas.Unsigned(auth))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err = gas.Prepare(ctx, client, cfg, auth, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Sending transaction", logging.KeyTxHash, tx.Hash().Hex(), "fees", fees.String(), "gasLimit", tx.Gas())
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %v", err)
+	}
+
+	return tx, nil
+}
+
+// Status returns the live status of the validator node, served by the admin API
+func (v *Validator) Status(ctx context.Context) (*ValidatorStatus, error) {
+	v.mutex.Lock()
+	status := &ValidatorStatus{
+		Running:               v.running,
+		NodeID:                v.nodeID,
+		Account:               v.address.Hex(),
+		Registered:            v.registered,
+		LastBlockProcessed:    v.lastBlock,
+		VerificationQueueSize: v.scheduler.len(),
+		IntakePaused:          v.intakePaused,
+	}
+	v.mutex.Unlock()
+
+	status.ConsensusParticipants = v.consensusEngine.ParticipantCount()
+	status.RPCEndpoints = v.rpc.Status()
+
+	// The rest of the status is worth having when the balance cannot be read
+	balance, err := v.client.BalanceAt(ctx, v.address, nil)
+	status.setBalance(balance, err)
+
+	return status, nil
+}
+
+// GetValidatorStatus returns the on-chain status of the configured account,
+// used when no validator node is running to ask
 func GetValidatorStatus(cfg *config.Config) (*ValidatorStatus, error) {
-	// In a real implementation, we would check the status of a running validator
-	// For this example, we'll return a simulated status
-	return &ValidatorStatus{
-		Running:              true,
-		NodeID:               "0x1234abcd",
-		Account:              "0x5678efgh",
-		Balance:              1.234,
-		Registered:           true,
-		LastBlockProcessed:   12345,
-		VerificationQueueSize: 5,
-		ConsensusParticipants: 3,
-	}, nil
-}
-
-// GetValidatorRewards returns the pending rewards for a validator
-func GetValidatorRewards(cfg *config.Config) (float64, error) {
-	// In a real implementation, we would check the rewards from the contract
-	// For this example, we'll return a simulated value
-	return 0.5, nil
+	address, err := wallet.AccountAddress(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Connect to the RPC endpoints
+	pool, err := rpcpool.Dial(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+	client := pool.Client()
+
+	// Check registration and balance
+	contract, err := contracts.NewDexponentContractWrapper(common.HexToAddress(cfg.DXPContractAddress), pool.QuorumClient())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contract instance: %v", err)
+	}
+
+	registered, err := contract.IsRegistered(&bind.CallOpts{Context: ctx}, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check registration status: %v", err)
+	}
+
+	status := &ValidatorStatus{
+		Running:    false,
+		Account:    address.Hex(),
+		Registered: registered,
+	}
+	balance, err := client.BalanceAt(ctx, address, nil)
+	status.setBalance(balance, err)
+
+	return status, nil
+}
+
+// setBalance records the balance read, or why it could not be read
+func (s *ValidatorStatus) setBalance(wei *big.Int, err error) {
+	if err != nil {
+		s.BalanceError = fmt.Sprintf("failed to get wallet balance: %v", err)
+		return
+	}
+	balance := weiToEther(wei)
+	s.Balance = &balance
 }
 
-// ClaimValidatorRewards claims the pending rewards for a validator
-func ClaimValidatorRewards(cfg *config.Config) (string, error) {
-	// In a real implementation, we would call the contract to claim rewards
-	// For this example, we'll return a simulated transaction hash
-	return "0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890", nil
+// weiToEther converts wei to ether
+func weiToEther(wei *big.Int) float64 {
+	ether, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return ether
 }
 
-// StopValidator stops a running validator node
-func StopValidator(cfg *config.Config) error {
-	// In a real implementation, we would stop a running validator
-	// For this example, we'll just return success
-	return nil
+// GetValidatorRewards returns the pending rewards for a validator. The
+// deployed contract does not track them, so it returns
+// contracts.ErrRewardsUnsupported rather than an amount.
+func GetValidatorRewards(cfg *config.Config) (float64, error) {
+	return 0, contracts.ErrRewardsUnsupported
 }
 
 // MockDXPContract is a mock implementation of the DXPContract interface
@@ -0,0 +1,92 @@
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dexponent/geth-validator/internal/consensus"
+	"github.com/dexponent/geth-validator/internal/contracts"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCatchUp(t *testing.T) {
+	chain, pool := newFakeChain(t)
+	v := newTestValidator(t, pool)
+	v.setLastBlock(95)
+
+	// Block 100 holds a request, and its header cannot be read
+	chain.mutex.Lock()
+	chain.failing["eth_getBlockByNumber"] = true
+	chain.mutex.Unlock()
+	v.catchUp(context.Background(), 105)
+	if got := v.lastProcessedBlock(); got != 99 {
+		t.Fatalf("processed up to block %d, want to stop before the failed block 100", got)
+	}
+	if v.scheduler.len() != 0 {
+		t.Fatalf("%d requests queued, want none", v.scheduler.len())
+	}
+
+	// The next tick retries it
+	chain.mutex.Lock()
+	chain.failing["eth_getBlockByNumber"] = false
+	chain.mutex.Unlock()
+	v.catchUp(context.Background(), 105)
+	if got := v.lastProcessedBlock(); got != 105 {
+		t.Fatalf("processed up to block %d, want 105", got)
+	}
+	if !v.scheduler.contains("100") {
+		t.Error("the request in block 100 was not queued")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		balanceFails  bool
+		wantBalance   string
+		wantBalanceOK bool
+	}{
+		{name: "balance read", wantBalance: "2.000000", wantBalanceOK: true},
+		{name: "balance cannot be read", balanceFails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, pool := newFakeChain(t)
+			chain.failing["eth_getBalance"] = tt.balanceFails
+			v := newTestValidator(t, pool)
+			v.nodeID = "node-1"
+			v.consensusEngine = consensus.NewEngine()
+			contract, err := contracts.NewDexponentContractWrapper(common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"), pool.QuorumClient())
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.contract = contract
+
+			// The rest of the status is reported either way
+			status, err := v.Status(context.Background())
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			if status.NodeID != "node-1" || len(status.RPCEndpoints) != 1 {
+				t.Errorf("status is missing the node (%q) or its endpoints (%d)", status.NodeID, len(status.RPCEndpoints))
+			}
+			if (status.Balance != nil) != tt.wantBalanceOK || (status.BalanceError == "") != tt.wantBalanceOK {
+				t.Errorf("balance %v with error %q, want a balance %v", status.Balance, status.BalanceError, tt.wantBalanceOK)
+			}
+			if !tt.wantBalanceOK && !strings.Contains(status.BalanceError, "failed to get wallet balance") {
+				t.Errorf("balance error %q does not say what failed", status.BalanceError)
+			}
+
+			// The dashboard shows what could not be read as empty
+			dashboard, err := v.DashboardMetrics(context.Background())
+			if err != nil {
+				t.Fatalf("DashboardMetrics: %v", err)
+			}
+			if dashboard.Balance != tt.wantBalance || dashboard.Rewards != "" {
+				t.Errorf("dashboard shows balance %q and rewards %q, want %q and none", dashboard.Balance, dashboard.Rewards, tt.wantBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/term"
+)
+
+// ErrNoPassphrase is returned when a passphrase is needed but none is configured
+// and there is no terminal to prompt on
+var ErrNoPassphrase = errors.New("no keystore passphrase: set KEYSTORE_PASSWORD_FILE or KEYSTORE_PASSWORD, or run interactively")
+
+// LoadKey returns the signing key configured for the validator, decrypting the
+// keystore file if one is set and falling back to WALLET_PRIVATE_KEY otherwise
+func LoadKey(cfg *config.Config) (*ecdsa.PrivateKey, error) {
+	if cfg.KeystoreFile == "" {
+		if cfg.WalletPrivateKey == "" {
+			return nil, errors.New("no signing key configured, set KEYSTORE_FILE or WALLET_PRIVATE_KEY")
+		}
+
+		privateKey, err := crypto.HexToECDSA(cfg.WalletPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %v", err)
+		}
+		return privateKey, nil
+	}
+
+	keyJSON, err := os.ReadFile(cfg.KeystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %v", err)
+	}
+
+	passphrase, err := Passphrase(cfg, fmt.Sprintf("Passphrase for %s: ", cfg.KeystoreFile), false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore file %s: %v", cfg.KeystoreFile, err)
+	}
+
+	return key.PrivateKey, nil
+}
+
+// Address returns the address belonging to a private key
+func Address(privateKey *ecdsa.PrivateKey) common.Address {
+	return crypto.PubkeyToAddress(privateKey.PublicKey)
+}
+
+// AccountAddress returns the address of the configured signing account
+// without unlocking it: the external signer's address, the address recorded
+// in the keystore file or that of WALLET_PRIVATE_KEY
+func AccountAddress(cfg *config.Config) (common.Address, error) {
+	switch {
+	case cfg.SignerURL != "":
+		return common.HexToAddress(cfg.SignerAddress), nil
+	case cfg.KeystoreFile != "":
+		keyJSON, err := os.ReadFile(cfg.KeystoreFile)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("failed to read keystore file: %v", err)
+		}
+
+		var header struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(keyJSON, &header); err != nil || !common.IsHexAddress(header.Address) {
+			return common.Address{}, fmt.Errorf("keystore file %s has no valid address", cfg.KeystoreFile)
+		}
+		return common.HexToAddress(header.Address), nil
+	}
+
+	privateKey, err := LoadKey(cfg)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return Address(privateKey), nil
+}
+
+// Passphrase resolves the keystore passphrase from the password file, the
+// KEYSTORE_PASSWORD setting or, as a last resort, an interactive prompt. With
+// confirm set, a prompted passphrase has to be typed twice.
+func Passphrase(cfg *config.Config, prompt string, confirm bool) (string, error) {
+	if cfg.KeystorePasswordFile != "" {
+		data, err := os.ReadFile(cfg.KeystorePasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read keystore password file: %v", err)
+		}
+		// Only the first line counts, so files written with echo work
+		return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+	}
+
+	if cfg.KeystorePassword != "" {
+		return cfg.KeystorePassword, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNoPassphrase
+	}
+
+	passphrase, err := readPassword(fd, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		again, err := readPassword(fd, "Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// OpenKeyStore opens the keystore directory used by the account commands
+func OpenKeyStore(cfg *config.Config) *keystore.KeyStore {
+	return keystore.NewKeyStore(cfg.KeystoreDir, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+// ReadHexKey reads a hex-encoded private key from the first line of a file
+func ReadHexKey(path string) (*ecdsa.PrivateKey, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("key file %s is empty", path)
+	}
+
+	line := strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "0x")
+	privateKey, err := crypto.HexToECDSA(line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in %s: %v", path, err)
+	}
+
+	return privateKey, nil
+}
+
+// readPassword prompts on stderr and reads a line from the terminal without echo
+func readPassword(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+
+	return string(password), nil
+}
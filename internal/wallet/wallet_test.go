@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dexponent/geth-validator/internal/config"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+func TestLoadKey(t *testing.T) {
+	want, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A keystore file encrypted with "correct horse"
+	dir := t.TempDir()
+	account, err := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(want, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordFile, []byte("correct horse\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr string
+	}{
+		{
+			name: "private key",
+			cfg:  config.Config{WalletPrivateKey: testKey},
+		},
+		{
+			name: "keystore with a password file",
+			cfg:  config.Config{KeystoreFile: account.URL.Path, KeystorePasswordFile: passwordFile, WalletPrivateKey: "ignored"},
+		},
+		{
+			name: "keystore with a password",
+			cfg:  config.Config{KeystoreFile: account.URL.Path, KeystorePassword: "correct horse"},
+		},
+		{
+			name:    "wrong passphrase",
+			cfg:     config.Config{KeystoreFile: account.URL.Path, KeystorePassword: "battery staple"},
+			wantErr: "failed to decrypt keystore file",
+		},
+		{
+			name:    "missing keystore file",
+			cfg:     config.Config{KeystoreFile: filepath.Join(dir, "missing.json"), KeystorePassword: "correct horse"},
+			wantErr: "failed to read keystore file",
+		},
+		{
+			name:    "invalid private key",
+			cfg:     config.Config{WalletPrivateKey: "0xnot-a-key"},
+			wantErr: "invalid private key",
+		},
+		{
+			name:    "no key",
+			wantErr: "no signing key configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := LoadKey(&tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKey: %v", err)
+			}
+			if Address(key) != Address(want) {
+				t.Errorf("loaded key of %s, want %s", Address(key).Hex(), Address(want).Hex())
+			}
+		})
+	}
+}
+
+func TestReadHexKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "plain", content: testKey + "\n"},
+		{name: "prefixed and padded", content: "  0x" + testKey + "  \nsecond line\n"},
+		{name: "empty", wantErr: "is empty"},
+		{name: "not a key", content: "passphrase\n", wantErr: "invalid private key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := ReadHexKey(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadHexKey: %v", err)
+			}
+			if got := hex.EncodeToString(crypto.FromECDSA(key)); got != testKey {
+				t.Errorf("read key %s, want %s", got, testKey)
+			}
+		})
+	}
+}